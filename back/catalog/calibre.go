@@ -0,0 +1,276 @@
+package catalog
+
+import (
+	"back/config"
+	"back/media"
+	"back/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	_ "modernc.org/sqlite"
+)
+
+// นามสกุลไฟล์ใน Calibre ที่ถือว่าเป็น ebook
+var calibreEbookFormats = map[string]bool{
+	"EPUB": true, "MOBI": true, "AZW": true, "AZW3": true, "KFX": true, "PDF": true, "FB2": true, "DJVU": true,
+}
+
+type CalibreOptions struct {
+	DryRun   bool
+	Progress func(models.ImportReport)
+	// MarkReadFor ถ้าระบุ จะสร้าง mark "read" ให้ผู้ใช้คนนี้กับทุกเล่มที่ import สำเร็จ
+	// เล่มที่ผู้ใช้ mark ไว้แล้ว (ทั้ง edition นี้หรือ edition อื่นของงานเดียวกัน) จะไม่ถูกเปลี่ยน
+	MarkReadFor *primitive.ObjectID
+}
+
+// calibreBook คือหนังสือหนึ่งเล่มใน metadata.db พร้อม path ของปก (ว่างถ้าไม่มีปก)
+type calibreBook struct {
+	row       ParsedRow
+	uuid      string
+	coverPath string
+}
+
+// readCalibre อ่าน metadata.db แบบอ่านอย่างเดียว Line ของแต่ละแถวคือ id ของหนังสือใน Calibre
+func readCalibre(ctx context.Context, libraryDir string) ([]calibreBook, error) {
+	dbPath := filepath.Join(libraryDir, "metadata.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("not a Calibre library: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+(&url.URL{Path: dbPath}).EscapedPath()+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.id, b.title, COALESCE(b.uuid, ''), b.path, b.has_cover, COALESCE(b.pubdate, ''), b.series_index,
+			COALESCE(b.isbn, ''), COALESCE(c.text, '')
+		FROM books b LEFT JOIN comments c ON c.book = b.id
+		ORDER BY b.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Calibre books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []calibreBook
+	byID := map[int]*calibreBook{}
+	seriesIndex := map[int]float64{}
+	for rows.Next() {
+		var (
+			id          int
+			title, uuid string
+			path        string
+			hasCover    bool
+			pubdate     string
+			index       float64
+			isbn, notes string
+		)
+		if err := rows.Scan(&id, &title, &uuid, &path, &hasCover, &pubdate, &index, &isbn, &notes); err != nil {
+			return nil, err
+		}
+		book := calibreBook{row: ParsedRow{Line: id, Row: Row{
+			Title:       title,
+			ISBN:        isbn,
+			Description: plainText(notes),
+		}}, uuid: uuid}
+		// Calibre ใช้ปี 0101 แทน "ไม่ทราบวันที่"
+		if year, err := strconv.Atoi(pubdate[:min(4, len(pubdate))]); err == nil && year >= 1000 {
+			book.row.Row.PublishYear = year
+		}
+		if hasCover {
+			book.coverPath = filepath.Join(libraryDir, filepath.FromSlash(path), "cover.jpg")
+		}
+		seriesIndex[id] = index
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range books {
+		byID[books[i].row.Line] = &books[i]
+	}
+
+	// ตารางเชื่อมแต่ละตาราง เรียงตาม id ของลิงก์เพื่อให้ลำดับผู้แต่งตรงกับใน Calibre
+	links := []struct {
+		query string
+		apply func(book *Row, value string)
+	}{
+		{`SELECT l.book, a.name FROM books_authors_link l JOIN authors a ON a.id = l.author ORDER BY l.id`,
+			func(row *Row, value string) { row.Authors = append(row.Authors, strings.ReplaceAll(value, "|", ",")) }},
+		{`SELECT l.book, t.name FROM books_tags_link l JOIN tags t ON t.id = l.tag ORDER BY l.id`,
+			func(row *Row, value string) { row.Tags = append(row.Tags, value) }},
+		{`SELECT l.book, s.name FROM books_series_link l JOIN series s ON s.id = l.series ORDER BY l.id`,
+			func(row *Row, value string) { row.Series = value }},
+		{`SELECT l.book, p.name FROM books_publishers_link l JOIN publishers p ON p.id = l.publisher ORDER BY l.id`,
+			func(row *Row, value string) { row.Publisher = value }},
+		{`SELECT l.book, g.lang_code FROM books_languages_link l JOIN languages g ON g.id = l.lang_code ORDER BY l.item_order`,
+			func(row *Row, value string) {
+				if row.Language == "" {
+					row.Language = value
+				}
+			}},
+		{`SELECT book, val FROM identifiers WHERE type = 'isbn'`,
+			func(row *Row, value string) { row.ISBN = value }},
+		{`SELECT book, format FROM data`,
+			func(row *Row, value string) {
+				if calibreEbookFormats[strings.ToUpper(value)] {
+					row.Format = models.FormatEbook
+				}
+			}},
+	}
+	for _, link := range links {
+		linkRows, err := db.QueryContext(ctx, link.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Calibre metadata: %w", err)
+		}
+		for linkRows.Next() {
+			var id int
+			var value string
+			if err := linkRows.Scan(&id, &value); err != nil {
+				linkRows.Close()
+				return nil, err
+			}
+			if book, ok := byID[id]; ok && strings.TrimSpace(value) != "" {
+				link.apply(&book.row.Row, value)
+			}
+		}
+		err = linkRows.Err()
+		linkRows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// series_index มีค่า 1 เสมอแม้ไม่อยู่ในซีรีส์ จึงใช้เฉพาะเล่มที่มีซีรีส์
+	for i := range books {
+		row := &books[i].row.Row
+		if index := seriesIndex[books[i].row.Line]; row.Series != "" && index > 0 {
+			row.SeriesNumber = &index
+		}
+	}
+	return books, nil
+}
+
+// copyCover คัดลอกปกไปไว้ใน media.UploadDir ชื่อไฟล์มาจาก uuid ของหนังสือใน Calibre
+// import ซ้ำจึงเขียนทับไฟล์เดิมแทนการสร้างไฟล์ใหม่
+func copyCover(book calibreBook) (string, error) {
+	name := "calibre_" + book.uuid + ".jpg"
+	if book.uuid == "" {
+		name = fmt.Sprintf("calibre_%d_%d.jpg", book.row.Line, time.Now().Unix())
+	}
+
+	src, err := os.Open(book.coverPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(media.UploadDir, 0755); err != nil {
+		return "", err
+	}
+	dst, err := os.Create(filepath.Join(media.UploadDir, name))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return "/uploads/" + name, nil
+}
+
+// markRead สร้าง mark "read" ถ้าผู้ใช้ยังไม่เคย mark หนังสือเล่มนี้หรืองานเดียวกัน คืน true ถ้าสร้างใหม่
+func markRead(ctx context.Context, userID, bookID primitive.ObjectID) (bool, error) {
+	var book struct {
+		WorkID *primitive.ObjectID `bson:"workId"`
+	}
+	if err := config.DB.Database("bookwarm").Collection("books").FindOne(ctx, bson.M{"_id": bookID}).Decode(&book); err != nil {
+		return false, err
+	}
+
+	filter := bson.M{"user_id": userID, "book_id": bookID}
+	if book.WorkID != nil {
+		filter = bson.M{"user_id": userID, "$or": []bson.M{{"book_id": bookID}, {"work_id": *book.WorkID}}}
+	}
+	mark := models.Mark{
+		UserID:    userID,
+		BookID:    bookID,
+		WorkID:    book.WorkID,
+		Status:    "read",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	result, err := config.DB.Database("bookwarm").Collection("marks").UpdateOne(ctx, filter,
+		bson.M{"$setOnInsert": mark}, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// ImportCalibre นำเข้าคลังหนังสือ Calibre จากโฟลเดอร์ที่มี metadata.db
+// หนังสือถูกจับคู่กับเล่มเดิมแบบเดียวกับ Import (ISBN หรือชื่อกับผู้แต่งหลัก) จึงรันซ้ำได้
+// tag ของ Calibre กลายเป็น tag ของเรา ปกถูกคัดลอกไปที่ media.UploadDir (ยกเว้นตอน dry run)
+func ImportCalibre(ctx context.Context, libraryDir string, opts CalibreOptions) (models.ImportReport, error) {
+	books, err := readCalibre(ctx, libraryDir)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	rows := make([]ParsedRow, 0, len(books))
+	var coverErrors []models.ImportRowError
+	for _, book := range books {
+		if book.coverPath != "" && !opts.DryRun {
+			if cover, err := copyCover(book); err == nil {
+				book.row.Row.CoverImage = cover
+			} else {
+				// ปกหายไม่ใช่เหตุให้ข้ามหนังสือ แค่บันทึกไว้ในรายงาน
+				coverErrors = append(coverErrors, models.ImportRowError{
+					Line: book.row.Line, Title: book.row.Row.Title, Error: "cover not copied: " + err.Error(),
+				})
+			}
+		}
+		rows = append(rows, book.row)
+	}
+
+	var imported []primitive.ObjectID
+	report, err := Import(ctx, rows, Options{
+		DryRun:   opts.DryRun,
+		Progress: opts.Progress,
+		Imported: func(line int, bookID primitive.ObjectID) { imported = append(imported, bookID) },
+	})
+	if err != nil {
+		return report, err
+	}
+	for _, coverErr := range coverErrors {
+		if len(report.Errors) < maxReportedErrors {
+			report.Errors = append(report.Errors, coverErr)
+		}
+	}
+
+	if opts.MarkReadFor != nil {
+		for _, bookID := range imported {
+			created, err := markRead(ctx, *opts.MarkReadFor, bookID)
+			if err != nil {
+				return report, fmt.Errorf("failed to mark book %s as read: %w", bookID.Hex(), err)
+			}
+			if created {
+				report.MarksCreated++
+			}
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// calibreSchema คือตารางส่วนที่ readCalibre อ่าน จาก metadata.db ของ Calibre
+var calibreSchema = []string{
+	`CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, uuid TEXT, path TEXT, has_cover BOOL, pubdate TIMESTAMP, series_index REAL, isbn TEXT)`,
+	`CREATE TABLE comments (id INTEGER PRIMARY KEY, book INTEGER, text TEXT)`,
+	`CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_authors_link (id INTEGER PRIMARY KEY, book INTEGER, author INTEGER)`,
+	`CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_tags_link (id INTEGER PRIMARY KEY, book INTEGER, tag INTEGER)`,
+	`CREATE TABLE series (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_series_link (id INTEGER PRIMARY KEY, book INTEGER, series INTEGER)`,
+	`CREATE TABLE publishers (id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE books_publishers_link (id INTEGER PRIMARY KEY, book INTEGER, publisher INTEGER)`,
+	`CREATE TABLE languages (id INTEGER PRIMARY KEY, lang_code TEXT)`,
+	`CREATE TABLE books_languages_link (id INTEGER PRIMARY KEY, book INTEGER, lang_code INTEGER, item_order INTEGER)`,
+	`CREATE TABLE identifiers (id INTEGER PRIMARY KEY, book INTEGER, type TEXT, val TEXT)`,
+	`CREATE TABLE data (id INTEGER PRIMARY KEY, book INTEGER, format TEXT, name TEXT)`,
+}
+
+// newCalibreLibrary สร้าง metadata.db ชั่วคราวจาก calibreSchema และ statements ที่ให้มา
+func newCalibreLibrary(t *testing.T, statements ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "metadata.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, statement := range append(append([]string{}, calibreSchema...), statements...) {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	return dir
+}
+
+func TestReadCalibre(t *testing.T) {
+	dir := newCalibreLibrary(t,
+		`INSERT INTO books VALUES (1, 'Kafka on the Shore', 'uuid-1', 'Haruki Murakami/Kafka on the Shore (1)', 1, '2005-01-03 00:00:00+00:00', 2, '')`,
+		`INSERT INTO books VALUES (2, 'Untitled Notes', 'uuid-2', 'Unknown/Untitled Notes (2)', 0, '0101-01-01 00:00:00+00:00', 1, '0306406152')`,
+		`INSERT INTO comments VALUES (1, 1, '<div><p>A boy &amp; a cat.</p></div>')`,
+		`INSERT INTO authors VALUES (1, 'Haruki Murakami'), (2, 'Philip Gabriel'), (3, 'Smith| John')`,
+		`INSERT INTO books_authors_link VALUES (1, 1, 1), (2, 1, 2), (3, 2, 3)`,
+		`INSERT INTO tags VALUES (1, 'Magical Realism'), (2, ' ')`,
+		`INSERT INTO books_tags_link VALUES (1, 1, 1), (2, 1, 2)`,
+		`INSERT INTO series VALUES (1, 'Murakami Novels')`,
+		`INSERT INTO books_series_link VALUES (1, 1, 1)`,
+		`INSERT INTO publishers VALUES (1, 'Vintage')`,
+		`INSERT INTO books_publishers_link VALUES (1, 1, 1)`,
+		`INSERT INTO languages VALUES (1, 'eng'), (2, 'jpn')`,
+		`INSERT INTO books_languages_link VALUES (1, 1, 2, 1), (2, 1, 1, 0)`,
+		`INSERT INTO identifiers VALUES (1, 1, 'isbn', '9781400079278'), (2, 1, 'amazon', 'B000')`,
+		`INSERT INTO data VALUES (1, 1, 'EPUB', 'kafka'), (2, 2, 'TXT', 'notes')`,
+	)
+
+	books, err := readCalibre(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 {
+		t.Fatalf("books = %d, want 2", len(books))
+	}
+
+	// ISBN จาก identifiers ก่อนคอลัมน์ isbn, ภาษาตาม item_order, EPUB คือ ebook
+	two := 2.0
+	kafka := books[0]
+	want := Row{
+		Title:        "Kafka on the Shore",
+		Description:  "A boy & a cat.",
+		Authors:      []string{"Haruki Murakami", "Philip Gabriel"},
+		Tags:         []string{"Magical Realism"},
+		Series:       "Murakami Novels",
+		SeriesNumber: &two,
+		PublishYear:  2005,
+		ISBN:         "9781400079278",
+		Language:     "eng",
+		Format:       "ebook",
+		Publisher:    "Vintage",
+	}
+	if kafka.row.Err != nil || !reflect.DeepEqual(kafka.row.Row, want) {
+		t.Errorf("row = %+v (err %v)\nwant %+v", kafka.row.Row, kafka.row.Err, want)
+	}
+	if cover := filepath.Join(dir, "Haruki Murakami", "Kafka on the Shore (1)", "cover.jpg"); kafka.uuid != "uuid-1" || kafka.coverPath != cover {
+		t.Errorf("uuid = %q, cover = %q", kafka.uuid, kafka.coverPath)
+	}
+
+	// ปี 0101 คือไม่ทราบวันที่, series_index ไม่ใช้เมื่อไม่มีซีรีส์, "|" ในชื่อผู้แต่งคือ ","
+	notes := books[1].row
+	if notes.Line != 2 || notes.Row.PublishYear != 0 || notes.Row.SeriesNumber != nil || books[1].coverPath != "" {
+		t.Errorf("notes = %+v, cover %q", notes, books[1].coverPath)
+	}
+	if !reflect.DeepEqual(notes.Row.Authors, []string{"Smith, John"}) {
+		t.Errorf("authors = %q", notes.Row.Authors)
+	}
+}
+
+func TestReadCalibreRequiresLibrary(t *testing.T) {
+	if _, err := readCalibre(context.Background(), t.TempDir()); err == nil {
+		t.Fatal("readCalibre accepted a folder without metadata.db")
+	}
+}
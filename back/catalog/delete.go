@@ -0,0 +1,195 @@
+package catalog
+
+import (
+	"back/config"
+	"back/media"
+	"back/models"
+	"back/search"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrBookNotFound = errors.New("book not found")
+
+type DeleteOptions struct {
+	// Archive ย้ายรีวิวและ mark ไปไว้ใน reviews_archive และ marks_archive แทนการลบทิ้ง
+	Archive bool
+}
+
+// DeleteBook ลบหนังสือพร้อมข้อมูลที่อ้างถึงใน transaction เดียว
+//   - รีวิวถูกลบหรือย้ายไป archive
+//   - mark ถูกย้ายไป edition อื่นของงานเดียวกันถ้ามี (mark เป็นของ work) ยกเว้นผู้ใช้ที่มี mark ของงานนั้นอยู่แล้ว
+//     นอกนั้นถูกลบหรือย้ายไป archive
+//   - โพสต์ที่อ้างถึงหนังสือถูกเอา book_id ออก
+//
+// ไฟล์ปกที่อัปโหลดไว้ถูกลบหลัง transaction สำเร็จ
+// ถ้าไม่มีหนังสือแล้วแต่ยังมีข้อมูลค้างอยู่ (ลบไปก่อนมีฟังก์ชันนี้) จะเก็บกวาดข้อมูลนั้น
+// และคืน ErrBookNotFound เฉพาะเมื่อไม่มีอะไรให้ลบเลย
+func DeleteBook(ctx context.Context, bookID primitive.ObjectID, opts DeleteOptions) (models.BookDeleteReport, error) {
+	db := config.DB.Database("bookwarm")
+	var report models.BookDeleteReport
+	var book models.Book
+	found := false
+
+	err := config.WithTransaction(ctx, func(ctx context.Context) error {
+		// transaction อาจถูกรันซ้ำ จึงเริ่มนับใหม่ทุกครั้ง
+		report = models.BookDeleteReport{BookID: bookID.Hex()}
+		book = models.Book{ID: bookID}
+		found = true
+		err := db.Collection("books").FindOneAndDelete(ctx, bson.M{"_id": bookID}).Decode(&book)
+		if err == mongo.ErrNoDocuments {
+			found = false
+		} else if err != nil {
+			return err
+		}
+		report.Title = book.Title
+		filter := bson.M{"book_id": bookID}
+
+		if opts.Archive {
+			if report.ReviewsArchived, err = archive(ctx, "reviews", filter, book); err != nil {
+				return err
+			}
+		} else {
+			result, err := db.Collection("reviews").DeleteMany(ctx, filter)
+			if err != nil {
+				return err
+			}
+			report.ReviewsDeleted = int(result.DeletedCount)
+		}
+
+		if !found {
+			// หนังสือที่ลบไปแล้วไม่มี workId ให้ดู แต่ mark ยังจำ work ไว้
+			var mark models.Mark
+			err := db.Collection("marks").FindOne(ctx, bson.M{"book_id": bookID, "work_id": bson.M{"$type": "objectId"}}).Decode(&mark)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return err
+			}
+			book.WorkID = mark.WorkID
+		}
+		if book.WorkID != nil {
+			var other models.Book
+			err := db.Collection("books").FindOne(ctx, bson.M{"workId": *book.WorkID},
+				options.FindOne().SetSort(bson.D{{Key: "review_count", Value: -1}, {Key: "_id", Value: 1}})).Decode(&other)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return err
+			}
+			if err == nil {
+				// ผู้ใช้ที่มี mark ของงานนี้ใน edition อื่นอยู่แล้วเก็บ mark นั้นไว้ mark ของเล่มที่ลบจะถูกลบหรือ archive ด้านล่าง
+				editions, err := db.Collection("books").Distinct(ctx, "_id", bson.M{"workId": *book.WorkID})
+				if err != nil {
+					return err
+				}
+				marked, err := db.Collection("marks").Distinct(ctx, "user_id", bson.M{
+					"book_id": bson.M{"$ne": bookID},
+					"$or":     []bson.M{{"work_id": *book.WorkID}, {"book_id": bson.M{"$in": editions}}},
+				})
+				if err != nil {
+					return err
+				}
+				move := bson.M{"book_id": bookID}
+				if len(marked) > 0 {
+					move["user_id"] = bson.M{"$nin": marked}
+				}
+				// ไม่แตะ updated_at เพราะไม่ใช่การกระทำของผู้ใช้ (package trending นับจาก updated_at)
+				result, err := db.Collection("marks").UpdateMany(ctx, move, bson.M{"$set": bson.M{"book_id": other.ID}})
+				if err != nil {
+					return err
+				}
+				report.MarksMoved = int(result.ModifiedCount)
+			}
+		}
+		if opts.Archive {
+			if report.MarksArchived, err = archive(ctx, "marks", filter, book); err != nil {
+				return err
+			}
+		} else {
+			result, err := db.Collection("marks").DeleteMany(ctx, filter)
+			if err != nil {
+				return err
+			}
+			report.MarksDeleted = int(result.DeletedCount)
+		}
+
+		result, err := db.Collection("post").UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"book_id": ""}})
+		if err != nil {
+			return err
+		}
+		report.PostsUnlinked = int(result.ModifiedCount)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if !found {
+		if report == (models.BookDeleteReport{BookID: bookID.Hex()}) {
+			return report, ErrBookNotFound
+		}
+		return report, nil
+	}
+	search.RemoveBook(bookID)
+	report.FilesRemoved = removeCoverFiles(ctx, book)
+	return report, nil
+}
+
+// archive ย้ายเอกสารที่ตรง filter จาก collection ไปไว้ที่ <collection>_archive
+// พร้อมเวลาและชื่อหนังสือที่ถูกลบ คืนจำนวนเอกสารที่ย้าย
+func archive(ctx context.Context, collection string, filter bson.M, book models.Book) (int, error) {
+	db := config.DB.Database("bookwarm")
+	cursor, err := db.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	archived := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		doc["archived_at"] = now
+		doc["archived_book"] = bson.M{"_id": book.ID, "title": book.Title}
+		archived = append(archived, doc)
+	}
+	if _, err := db.Collection(collection+"_archive").InsertMany(ctx, archived); err != nil {
+		return 0, err
+	}
+	if _, err := db.Collection(collection).DeleteMany(ctx, filter); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+// removeCoverFiles ลบไฟล์ปกของหนังสือที่ถูกลบ ปกใน coverImage ที่ไม่ได้มาจาก coverSizes
+// (เช่น ปกจาก Calibre) จะถูกลบเฉพาะเมื่อไม่มีหนังสือเล่มอื่นใช้ไฟล์เดียวกัน
+func removeCoverFiles(ctx context.Context, book models.Book) int {
+	removed := 0
+	inSizes := false
+	for _, size := range book.CoverSizes {
+		for _, url := range []string{size.JPEG, size.WebP} {
+			if url == book.CoverImage {
+				inSizes = true
+			}
+			if media.RemoveFile(url) {
+				removed++
+			}
+		}
+	}
+	if book.CoverImage != "" && !inSizes {
+		shared, err := config.DB.Database("bookwarm").Collection("books").CountDocuments(ctx, bson.M{"coverImage": book.CoverImage})
+		if err == nil && shared == 0 && media.RemoveFile(book.CoverImage) {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,127 @@
+package catalog
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func loadNames(ctx context.Context, collection string) (map[primitive.ObjectID]string, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection(collection).Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	names := make(map[primitive.ObjectID]string, len(docs))
+	for _, doc := range docs {
+		names[doc.ID] = doc.Name
+	}
+	return names, nil
+}
+
+func namesOf(ids []primitive.ObjectID, names map[primitive.ObjectID]string) []string {
+	var out []string
+	for _, id := range ids {
+		if name, ok := names[id]; ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Export เขียนหนังสือทุกเล่มในรูปแบบเดียวกับที่ Import อ่านได้ คืนจำนวนเล่มที่เขียน
+func Export(ctx context.Context, w *Writer) (int, error) {
+	lookups := map[string]map[primitive.ObjectID]string{}
+	for _, collection := range []string{"author", "category", "genre", "tag", "series"} {
+		names, err := loadNames(ctx, collection)
+		if err != nil {
+			return 0, err
+		}
+		lookups[collection] = names
+	}
+
+	workKeys := map[primitive.ObjectID]string{}
+	workCursor, err := config.DB.Database("bookwarm").Collection("works").Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	var works []models.Work
+	if err := workCursor.All(ctx, &works); err != nil {
+		return 0, err
+	}
+	for _, work := range works {
+		workKeys[work.ID] = workKeyPrefix + work.ID.Hex()
+		if work.ExternalID != "" {
+			workKeys[work.ID] = work.ExternalID
+		}
+	}
+
+	cursor, err := config.DB.Database("bookwarm").Collection("books").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		var book models.Book
+		if err := cursor.Decode(&book); err != nil {
+			return count, err
+		}
+
+		row := Row{
+			Title:        book.Title,
+			Description:  book.Description,
+			Category:     lookups["category"][book.CategoryID],
+			Genres:       namesOf(book.Genres, lookups["genre"]),
+			Tags:         namesOf(book.TagIDs, lookups["tag"]),
+			SeriesNumber: book.SeriesNumber,
+			PublishYear:  book.PublishYear,
+			PageCount:    book.PageCount,
+			ISBN:         book.ISBN13,
+			Language:     book.Language,
+			Format:       book.Format,
+			Publisher:    book.Publisher,
+			CoverImage:   book.CoverImage,
+		}
+		if book.WorkID != nil {
+			row.WorkKey = workKeys[*book.WorkID]
+		}
+		if book.SeriesID != nil {
+			row.Series = lookups["series"][*book.SeriesID]
+		}
+		contributors := book.Contributors
+		if len(contributors) == 0 && !book.AuthorID.IsZero() {
+			contributors = []models.Contributor{{AuthorID: book.AuthorID, Role: models.RoleAuthor}}
+		}
+		for _, contributor := range contributors {
+			name, ok := lookups["author"][contributor.AuthorID]
+			if !ok {
+				continue
+			}
+			if contributor.Role != models.RoleAuthor {
+				name += " (" + contributor.Role + ")"
+			}
+			row.Authors = append(row.Authors, name)
+		}
+
+		if err := w.Write(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		return count, err
+	}
+	return count, w.Flush()
+}
@@ -0,0 +1,559 @@
+package catalog
+
+import (
+	"back/config"
+	"back/history"
+	"back/models"
+	"back/search"
+	"back/utils"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// จำนวน error สูงสุดที่เก็บในรายงาน (นับจำนวนแถวที่ผิดครบทุกแถวใน Failed)
+const maxReportedErrors = 1000
+
+// ส่งความคืบหน้าทุก ๆ กี่แถว
+const progressEvery = 50
+
+type Options struct {
+	// DryRun ตรวจและนับผลเหมือน import จริงแต่ไม่เขียนอะไรลงฐานข้อมูล
+	DryRun bool
+	// Progress ถูกเรียกระหว่าง import พร้อมรายงาน ณ ขณะนั้น (ไม่บังคับ)
+	Progress func(models.ImportReport)
+	// Imported ถูกเรียกทุกแถวที่ import สำเร็จพร้อม id ของหนังสือ (ไม่ถูกเรียกตอน dry run)
+	Imported func(line int, bookID primitive.ObjectID)
+	// Editor คือผู้ที่สั่ง import บันทึกไว้ในประวัติการแก้ไขของหนังสือ
+	Editor history.Editor
+}
+
+// pendingName คือเอกสารที่สร้างในแถวที่กำลัง import ยังไม่รู้ว่า transaction ของแถวจะสำเร็จหรือไม่
+type pendingName struct {
+	key   string
+	label string
+	id    primitive.ObjectID
+}
+
+// nameCache จำ id ที่ resolve แล้ว เอกสารที่สร้างใหม่รอ commit หลังแถวสำเร็จ
+// ถ้าแถวล้มเหลว rollback ทิ้ง เพื่อไม่ให้แถวถัดไปอ้างถึงเอกสารที่ถูกยกเลิกไปพร้อม transaction
+type nameCache struct {
+	ids     map[string]primitive.ObjectID
+	pending []pendingName
+	created []string
+}
+
+func (n *nameCache) lookup(key string) (primitive.ObjectID, bool) {
+	if id, ok := n.ids[key]; ok {
+		return id, true
+	}
+	for _, p := range n.pending {
+		if p.key == key {
+			return p.id, true
+		}
+	}
+	return primitive.NilObjectID, false
+}
+
+func (n *nameCache) add(key, label string, id primitive.ObjectID) {
+	n.pending = append(n.pending, pendingName{key: key, label: label, id: id})
+}
+
+// commit คืน id ของเอกสารที่สร้างในแถวนี้
+func (n *nameCache) commit() []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(n.pending))
+	for _, p := range n.pending {
+		n.ids[p.key] = p.id
+		n.created = append(n.created, p.label)
+		ids = append(ids, p.id)
+	}
+	n.pending = nil
+	return ids
+}
+
+func (n *nameCache) rollback() {
+	n.pending = nil
+}
+
+// resolver แปลงชื่อเป็น id ของเอกสารใน collection หนึ่ง สร้างเอกสารใหม่ถ้ายังไม่มีชื่อนี้
+type resolver struct {
+	collection string
+	dryRun     bool
+	nameCache
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func newResolver(ctx context.Context, collection string, dryRun bool) (*resolver, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection(collection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var docs []taxonomyDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	r := &resolver{collection: collection, dryRun: dryRun, nameCache: nameCache{ids: map[string]primitive.ObjectID{}}}
+	for _, doc := range docs {
+		if _, exists := r.ids[nameKey(doc.Name)]; !exists {
+			r.ids[nameKey(doc.Name)] = doc.ID
+		}
+	}
+	// ชื่อภาษาอื่นและชื่อเดิมของข้อมูลที่ถูกรวมแล้ว (ดู Merge) หาเจอด้วย แต่ชื่อหลักของเอกสารอื่นมาก่อน
+	for _, doc := range docs {
+		for _, alias := range append(localizedNames(doc.Names), doc.Aliases...) {
+			if _, exists := r.ids[nameKey(alias)]; !exists {
+				r.ids[nameKey(alias)] = doc.ID
+			}
+		}
+	}
+	return r, nil
+}
+
+// newDoc สร้างเอกสารใหม่ในรูปเดียวกับที่ API ของ collection นั้นสร้าง
+func newDoc(collection string, id primitive.ObjectID, name string) interface{} {
+	now := time.Now()
+	switch collection {
+	case "author":
+		return models.Author{ID: id, Name: name, CreatedAt: &now, UpdatedAt: &now}
+	case "series":
+		return models.Series{ID: id, Name: name, CreatedAt: now, UpdatedAt: now}
+	case "category":
+		return models.Category{ID: id, Name: name}
+	case "genre":
+		return models.Genre{ID: id, Name: name}
+	default:
+		return models.Tag{ID: id, Name: name}
+	}
+}
+
+func (r *resolver) resolve(ctx context.Context, name string) (primitive.ObjectID, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	key := nameKey(name)
+	if id, ok := r.lookup(key); ok {
+		return id, nil
+	}
+
+	id := primitive.NewObjectID()
+	if !r.dryRun {
+		if _, err := config.DB.Database("bookwarm").Collection(r.collection).InsertOne(ctx, newDoc(r.collection, id, name)); err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+	r.add(key, name, id)
+	return id, nil
+}
+
+func (r *resolver) resolveAll(ctx context.Context, names []string) ([]primitive.ObjectID, error) {
+	ids := []primitive.ObjectID{}
+	for _, name := range names {
+		id, err := r.resolve(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseAuthor แยกชื่อกับบทบาท เช่น "Jane Doe (translator)" ถ้าไม่ระบุบทบาทถือเป็นผู้แต่ง
+func parseAuthor(value string) (name, role string) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, ")") {
+		if i := strings.LastIndex(value, "("); i > 0 {
+			candidate := strings.ToLower(strings.TrimSpace(value[i+1 : len(value)-1]))
+			if models.IsContributorRole(candidate) {
+				return strings.TrimSpace(value[:i]), candidate
+			}
+		}
+	}
+	return value, models.RoleAuthor
+}
+
+// validateRow ตรวจแถวก่อน resolve ชื่อ เพื่อไม่ให้แถวที่ผิดสร้างผู้แต่งหรือหมวดหมู่ทิ้งไว้
+func validateRow(row *Row) (isbn13, isbn10 string, err error) {
+	if strings.TrimSpace(row.Title) == "" {
+		return "", "", errors.New("title is required")
+	}
+	if row.ISBN != "" {
+		if isbn13, isbn10, err = utils.NormalizeISBN(row.ISBN); err != nil {
+			return "", "", fmt.Errorf("invalid isbn %q", row.ISBN)
+		}
+	}
+	if row.Format != "" && !models.IsEditionFormat(row.Format) {
+		return "", "", fmt.Errorf("format must be one of %s", strings.Join(models.EditionFormats, ", "))
+	}
+	if row.SeriesNumber != nil {
+		if row.Series == "" {
+			return "", "", errors.New("series_number requires series")
+		}
+		if *row.SeriesNumber <= 0 {
+			return "", "", errors.New("series_number must be greater than 0")
+		}
+	}
+	return isbn13, isbn10, nil
+}
+
+type importer struct {
+	opts       Options
+	books      *mongo.Collection
+	authors    *resolver
+	categories *resolver
+	genres     *resolver
+	tags       *resolver
+	series     *resolver
+	works      *workResolver
+	// แถวก่อนหน้าในไฟล์เดียวกัน ใช้ตอน dry run ที่ยังไม่มีอะไรอยู่ในฐานข้อมูล
+	seen map[string]bool
+}
+
+// Import นำเข้าแถวทั้งหมด แถวที่ผิดจะถูกข้ามและบันทึกในรายงาน ไม่ทำให้แถวอื่นล้มเหลว
+// หนังสือที่มี ISBN ตรงกัน (หรือไม่มี ISBN แต่ชื่อและผู้แต่งหลักตรงกัน) จะถูกอัปเดตแทนการสร้างใหม่
+// โดยอัปเดตเฉพาะช่องที่มีค่าในไฟล์
+func Import(ctx context.Context, rows []ParsedRow, opts Options) (models.ImportReport, error) {
+	report := models.ImportReport{Total: len(rows)}
+	imp := &importer{opts: opts, books: config.DB.Database("bookwarm").Collection("books"), seen: map[string]bool{}}
+
+	var err error
+	for _, r := range []struct {
+		dst        **resolver
+		collection string
+	}{
+		{&imp.authors, "author"}, {&imp.categories, "category"}, {&imp.genres, "genre"},
+		{&imp.tags, "tag"}, {&imp.series, "series"},
+	} {
+		if *r.dst, err = newResolver(ctx, r.collection, opts.DryRun); err != nil {
+			return report, err
+		}
+	}
+
+	if imp.works, err = newWorkResolver(ctx, opts.DryRun); err != nil {
+		return report, err
+	}
+
+	for i, parsed := range rows {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		rowErr := parsed.Err
+		if rowErr == nil {
+			var isNew bool
+			var bookID primitive.ObjectID
+			isNew, bookID, rowErr = imp.importRow(ctx, parsed.Row)
+			switch {
+			case rowErr != nil:
+			case isNew:
+				report.Created++
+			default:
+				report.Updated++
+			}
+			if rowErr == nil && opts.Imported != nil && !opts.DryRun {
+				opts.Imported(parsed.Line, bookID)
+			}
+		}
+		if rowErr != nil {
+			report.Failed++
+			if len(report.Errors) < maxReportedErrors {
+				report.Errors = append(report.Errors, models.ImportRowError{Line: parsed.Line, Title: parsed.Row.Title, Error: rowErr.Error()})
+			}
+		}
+		report.Processed = i + 1
+
+		if opts.Progress != nil && report.Processed%progressEvery == 0 {
+			opts.Progress(report)
+		}
+	}
+
+	report.NewRefs = map[string][]string{}
+	for name, r := range map[string]*resolver{
+		"authors": imp.authors, "categories": imp.categories, "genres": imp.genres, "tags": imp.tags, "series": imp.series,
+	} {
+		if len(r.created) > 0 {
+			report.NewRefs[name] = r.created
+		}
+	}
+	if len(imp.works.created) > 0 {
+		report.NewRefs["works"] = imp.works.created
+	}
+
+	return report, nil
+}
+
+// importRow คืน true ถ้าเป็นหนังสือใหม่ false ถ้าอัปเดตเล่มเดิม พร้อม id ของหนังสือ (ว่างตอน dry run)
+// ทุกอย่างของแถวหนึ่ง (ผู้แต่ง/หมวด/work ที่สร้างใหม่ หนังสือ mark และ revision) อยู่ใน transaction เดียว
+// แถวที่ล้มเหลวจึงไม่ทิ้งอะไรไว้ครึ่ง ๆ กลาง ๆ
+func (imp *importer) importRow(ctx context.Context, row Row) (isNew bool, bookID primitive.ObjectID, err error) {
+	if imp.opts.DryRun {
+		isNew, bookID, err = imp.writeRow(ctx, row)
+	} else {
+		err = history.Retry(func() error {
+			return config.WithTransaction(ctx, func(ctx context.Context) error {
+				// transaction อาจถูกรันซ้ำ เอกสารที่สร้างในรอบก่อนถูกยกเลิกไปแล้ว
+				imp.rollback()
+				var err error
+				isNew, bookID, err = imp.writeRow(ctx, row)
+				return err
+			})
+		})
+	}
+	if err != nil {
+		imp.rollback()
+		if config.IsDuplicateKey(err, config.IndexISBN13) {
+			err = errors.New("a book with this isbn already exists")
+		}
+		return false, primitive.NilObjectID, err
+	}
+
+	// อัปเดตดัชนีค้นหาเฉพาะหนังสือและผู้แต่งใหม่ของแถวนี้ ไม่ต้อง rebuild ทั้งดัชนีหลัง import
+	for _, id := range imp.commit() {
+		if !imp.opts.DryRun {
+			search.IndexAuthor(id)
+		}
+	}
+	if !imp.opts.DryRun {
+		search.IndexBook(bookID)
+	}
+	return isNew, bookID, nil
+}
+
+func (imp *importer) caches() []*nameCache {
+	return []*nameCache{
+		&imp.categories.nameCache, &imp.genres.nameCache, &imp.tags.nameCache,
+		&imp.series.nameCache, &imp.works.nameCache,
+	}
+}
+
+// commit ยืนยันเอกสารที่สร้างในแถวที่สำเร็จ คืน id ของผู้แต่งใหม่เพื่อนำเข้าดัชนีค้นหา
+func (imp *importer) commit() []primitive.ObjectID {
+	for _, cache := range imp.caches() {
+		cache.commit()
+	}
+	return imp.authors.commit()
+}
+
+func (imp *importer) rollback() {
+	imp.authors.rollback()
+	for _, cache := range imp.caches() {
+		cache.rollback()
+	}
+}
+
+// writeRow เขียนหนังสือของแถวหนึ่ง ต้องเรียกใน transaction (ยกเว้นตอน dry run)
+func (imp *importer) writeRow(ctx context.Context, row Row) (bool, primitive.ObjectID, error) {
+	isbn13, isbn10, err := validateRow(&row)
+	if err != nil {
+		return false, primitive.NilObjectID, err
+	}
+	row.Title = strings.TrimSpace(row.Title)
+
+	var contributors []models.Contributor
+	seen := map[string]bool{}
+	for _, value := range row.Authors {
+		name, role := parseAuthor(value)
+		if name == "" {
+			continue
+		}
+		id, err := imp.authors.resolve(ctx, name)
+		if err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		if key := id.Hex() + role; !seen[key] {
+			seen[key] = true
+			contributors = append(contributors, models.Contributor{AuthorID: id, Role: role})
+		}
+	}
+	// ผู้แต่งหลักคือคนแรกที่มีบทบาท author ถ้าไม่มีใช้ผู้แต่งร่วมคนแรก (แบบเดียวกับ API)
+	var authorID primitive.ObjectID
+	for _, role := range []string{models.RoleAuthor, models.RoleCoAuthor} {
+		for _, contributor := range contributors {
+			if authorID.IsZero() && contributor.Role == role {
+				authorID = contributor.AuthorID
+			}
+		}
+	}
+
+	set := bson.M{"title": row.Title, "updatedAt": time.Now()}
+	if row.Description != "" {
+		set["description"] = row.Description
+	}
+	if len(contributors) > 0 {
+		set["contributors"] = contributors
+		set["authorId"] = authorID
+	}
+	if row.Category != "" {
+		id, err := imp.categories.resolve(ctx, row.Category)
+		if err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		set["category_id"] = id
+	}
+	if len(row.Genres) > 0 {
+		ids, err := imp.genres.resolveAll(ctx, row.Genres)
+		if err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		set["genres"] = ids
+	}
+	if len(row.Tags) > 0 {
+		ids, err := imp.tags.resolveAll(ctx, row.Tags)
+		if err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		set["tagIds"] = ids
+	}
+	if row.Series != "" {
+		id, err := imp.series.resolve(ctx, row.Series)
+		if err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		set["seriesId"] = id
+		if row.SeriesNumber != nil {
+			set["seriesNumber"] = *row.SeriesNumber
+		}
+	}
+	if row.WorkKey != "" {
+		id, err := imp.works.resolve(ctx, row.WorkKey, row.Title)
+		if err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		set["workId"] = id
+	}
+	for field, value := range map[string]int{"publishYear": row.PublishYear, "pageCount": row.PageCount} {
+		if value != 0 {
+			set[field] = value
+		}
+	}
+	for field, value := range map[string]string{
+		"isbn13": isbn13, "isbn10": isbn10, "language": row.Language, "format": row.Format,
+		"publisher": row.Publisher, "coverImage": row.CoverImage,
+	} {
+		if value != "" {
+			set[field] = value
+		}
+	}
+
+	// หาเล่มเดิม: ISBN ก่อน ถ้าไม่มี ISBN ใช้ชื่อตรงกันทุกตัวอักษรและผู้แต่งหลักเดียวกัน
+	filter := bson.M{"title": row.Title, "authorId": authorID}
+	seenKey := "title:" + nameKey(row.Title) + ":" + authorID.Hex()
+	if isbn13 != "" {
+		filter = bson.M{"isbn13": isbn13}
+		seenKey = "isbn:" + isbn13
+	}
+
+	var existing models.Book
+	err = imp.books.FindOne(ctx, filter).Decode(&existing)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, primitive.NilObjectID, err
+	}
+	found := err == nil || imp.seen[seenKey]
+	imp.seen[seenKey] = true
+
+	if imp.opts.DryRun {
+		return !found, primitive.NilObjectID, nil
+	}
+	if err == nil {
+		if _, err := imp.books.UpdateOne(ctx, bson.M{"_id": existing.ID}, bson.M{"$set": set}); err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		if workID, ok := set["workId"].(primitive.ObjectID); ok {
+			// mark ของผู้ใช้ต้องอ้างถึง work ใหม่ด้วย
+			if err := SyncMarkWork(ctx, []primitive.ObjectID{existing.ID}, &workID); err != nil {
+				return false, primitive.NilObjectID, err
+			}
+		}
+		if err := imp.record(ctx, &existing, existing.ID); err != nil {
+			return false, primitive.NilObjectID, err
+		}
+		return false, existing.ID, nil
+	}
+
+	book := bson.M{
+		"_id":       primitive.NewObjectID(),
+		"genres":    []primitive.ObjectID{},
+		"tagIds":    []primitive.ObjectID{},
+		"createdAt": time.Now(),
+	}
+	for field, value := range set {
+		book[field] = value
+	}
+	if _, ok := book["authorId"]; !ok {
+		book["authorId"] = primitive.NilObjectID
+	}
+	bookID := book["_id"].(primitive.ObjectID)
+	if _, err := imp.books.InsertOne(ctx, book); err != nil {
+		return false, primitive.NilObjectID, err
+	}
+	if err := imp.record(ctx, nil, bookID); err != nil {
+		return false, primitive.NilObjectID, err
+	}
+	return true, bookID, nil
+}
+
+// record บันทึก revision ของหนังสือที่ import แล้ว (before เป็น nil สำหรับเล่มใหม่)
+func (imp *importer) record(ctx context.Context, before *models.Book, bookID primitive.ObjectID) error {
+	var after models.Book
+	if err := imp.books.FindOne(ctx, bson.M{"_id": bookID}).Decode(&after); err != nil {
+		return err
+	}
+	_, err := history.Record(ctx, before, after, history.Entry{Action: models.RevisionImport, Editor: imp.opts.Editor})
+	return err
+}
+
+// workResolver หา work จาก WorkKey สร้าง work ใหม่ถ้ายังไม่มี id ภายนอกนี้
+type workResolver struct {
+	dryRun   bool
+	existing map[primitive.ObjectID]bool
+	nameCache
+}
+
+const workKeyPrefix = "bookwarm:"
+
+func newWorkResolver(ctx context.Context, dryRun bool) (*workResolver, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection("works").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var works []models.Work
+	if err := cursor.All(ctx, &works); err != nil {
+		return nil, err
+	}
+	r := &workResolver{dryRun: dryRun, existing: map[primitive.ObjectID]bool{}, nameCache: nameCache{ids: map[string]primitive.ObjectID{}}}
+	for _, work := range works {
+		r.existing[work.ID] = true
+		if work.ExternalID != "" {
+			r.ids[work.ExternalID] = work.ID
+		}
+	}
+	return r, nil
+}
+
+func (r *workResolver) resolve(ctx context.Context, key, title string) (primitive.ObjectID, error) {
+	if strings.HasPrefix(key, workKeyPrefix) {
+		id, err := primitive.ObjectIDFromHex(strings.TrimPrefix(key, workKeyPrefix))
+		if err != nil || !r.existing[id] {
+			return primitive.NilObjectID, fmt.Errorf("work %q not found", key)
+		}
+		return id, nil
+	}
+	if id, ok := r.lookup(key); ok {
+		return id, nil
+	}
+
+	work := models.Work{ID: primitive.NewObjectID(), Title: title, ExternalID: key, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if !r.dryRun {
+		if _, err := config.DB.Database("bookwarm").Collection("works").InsertOne(ctx, work); err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
+	r.add(key, key, work.ID)
+	return work.ID, nil
+}
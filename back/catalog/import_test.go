@@ -0,0 +1,70 @@
+package catalog
+
+import (
+	"back/models"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNameCacheCommitAndRollback(t *testing.T) {
+	existing := primitive.NewObjectID()
+	cache := nameCache{ids: map[string]primitive.ObjectID{"tolkien": existing}}
+
+	if id, ok := cache.lookup("tolkien"); !ok || id != existing {
+		t.Fatalf("lookup(existing) = %v, %v", id, ok)
+	}
+
+	// แถวที่ล้มเหลว: ชื่อที่สร้างระหว่างแถวต้องหายไป
+	failed := primitive.NewObjectID()
+	cache.add("le guin", "Le Guin", failed)
+	if id, ok := cache.lookup("le guin"); !ok || id != failed {
+		t.Fatal("pending name is not visible within the row")
+	}
+	cache.rollback()
+	if _, ok := cache.lookup("le guin"); ok {
+		t.Fatal("rolled back name is still resolvable")
+	}
+	if len(cache.created) != 0 {
+		t.Fatalf("created = %v after rollback", cache.created)
+	}
+
+	// แถวที่สำเร็จ: ชื่อถูกจำและรายงาน
+	committed := primitive.NewObjectID()
+	cache.add("le guin", "Le Guin", committed)
+	if ids := cache.commit(); !reflect.DeepEqual(ids, []primitive.ObjectID{committed}) {
+		t.Fatalf("commit = %v", ids)
+	}
+	if id, ok := cache.lookup("le guin"); !ok || id != committed {
+		t.Fatal("committed name is not resolvable")
+	}
+	if !reflect.DeepEqual(cache.created, []string{"Le Guin"}) {
+		t.Fatalf("created = %v", cache.created)
+	}
+	if ids := cache.commit(); len(ids) != 0 {
+		t.Fatalf("second commit = %v", ids)
+	}
+}
+
+func TestNewDoc(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	author, ok := newDoc("author", id, "Ursula K. Le Guin").(models.Author)
+	if !ok || author.ID != id || author.Name != "Ursula K. Le Guin" || author.CreatedAt == nil || author.UpdatedAt == nil {
+		t.Fatalf("author = %+v", author)
+	}
+	series, ok := newDoc("series", id, "Earthsea").(models.Series)
+	if !ok || series.Name != "Earthsea" || series.CreatedAt.IsZero() || series.UpdatedAt.IsZero() {
+		t.Fatalf("series = %+v", series)
+	}
+	for collection, want := range map[string]interface{}{
+		"category": models.Category{ID: id, Name: "Fiction"},
+		"genre":    models.Genre{ID: id, Name: "Fiction"},
+		"tag":      models.Tag{ID: id, Name: "Fiction"},
+	} {
+		if got := newDoc(collection, id, "Fiction"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("newDoc(%q) = %#v, want %#v", collection, got, want)
+		}
+	}
+}
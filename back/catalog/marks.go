@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SyncMarkWork ทำให้ work_id ของ mark ตรงกับ work ปัจจุบันของหนังสือ ใช้เมื่อย้ายหนังสือเข้าออก work
+// ผู้ใช้มี mark ได้หนึ่งอันต่องาน (unique index user_id, work_id) ถ้ารวม edition แล้วผู้ใช้มีหลาย mark
+// จะเก็บไว้เฉพาะอันที่แก้ล่าสุด
+func SyncMarkWork(ctx context.Context, bookIDs []primitive.ObjectID, workID *primitive.ObjectID) error {
+	marks := config.DB.Database("bookwarm").Collection("marks")
+	if workID == nil {
+		_, err := marks.UpdateMany(ctx, bson.M{"book_id": bson.M{"$in": bookIDs}}, bson.M{"$unset": bson.M{"work_id": ""}})
+		return err
+	}
+
+	cursor, err := marks.Find(ctx, bson.M{"$or": []bson.M{
+		{"book_id": bson.M{"$in": bookIDs}},
+		{"work_id": *workID},
+	}})
+	if err != nil {
+		return err
+	}
+	var existing []models.Mark
+	if err := cursor.All(ctx, &existing); err != nil {
+		return err
+	}
+	if stale := staleMarks(existing); len(stale) > 0 {
+		if _, err := marks.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": stale}}); err != nil {
+			return err
+		}
+	}
+	_, err = marks.UpdateMany(ctx, bson.M{"book_id": bson.M{"$in": bookIDs}}, bson.M{"$set": bson.M{"work_id": *workID}})
+	return err
+}
+
+// staleMarks คืน id ของ mark ที่ต้องทิ้งเมื่อ marks ทั้งหมดเป็นของงานเดียวกัน
+// ต่อผู้ใช้เก็บ mark ที่ updated_at ล่าสุดไว้ (เท่ากันเลือก id ที่ใหม่กว่า)
+func staleMarks(marks []models.Mark) []primitive.ObjectID {
+	sorted := append([]models.Mark(nil), marks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].UpdatedAt.Equal(sorted[j].UpdatedAt) {
+			return sorted[i].UpdatedAt.After(sorted[j].UpdatedAt)
+		}
+		return sorted[i].ID.Hex() > sorted[j].ID.Hex()
+	})
+	kept := map[primitive.ObjectID]bool{}
+	var stale []primitive.ObjectID
+	for _, mark := range sorted {
+		if kept[mark.UserID] {
+			stale = append(stale, mark.ID)
+			continue
+		}
+		kept[mark.UserID] = true
+	}
+	return stale
+}
+
+// DuplicateMarks คืน id ของ mark ที่ซ้ำกับ mark อื่นของผู้ใช้คนเดียวกันในงานเดียวกัน (ไม่รวมอันที่เก็บไว้)
+// ใช้เก็บกวาดข้อมูลเก่าก่อนสร้าง unique index (user_id, work_id)
+func DuplicateMarks(ctx context.Context) ([]primitive.ObjectID, error) {
+	marks := config.DB.Database("bookwarm").Collection("marks")
+	cursor, err := marks.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"work_id": bson.M{"$type": "objectId"}}},
+		{"$group": bson.M{
+			"_id":   bson.M{"user_id": "$user_id", "work_id": "$work_id"},
+			"marks": bson.M{"$push": "$$ROOT"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []struct {
+		Marks []models.Mark `bson:"marks"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	var stale []primitive.ObjectID
+	for _, group := range groups {
+		stale = append(stale, staleMarks(group.Marks)...)
+	}
+	return stale, nil
+}
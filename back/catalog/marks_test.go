@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"back/models"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStaleMarks(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ids := make([]primitive.ObjectID, 5)
+	for i := range ids {
+		ids[i] = primitive.NewObjectIDFromTimestamp(base.Add(time.Duration(i) * time.Second))
+	}
+
+	tests := []struct {
+		name  string
+		marks []models.Mark
+		want  []primitive.ObjectID
+	}{
+		{"no marks", nil, nil},
+		{
+			"one mark per user is kept",
+			[]models.Mark{{ID: ids[0], UserID: alice, UpdatedAt: base}, {ID: ids[1], UserID: bob, UpdatedAt: base}},
+			nil,
+		},
+		{
+			"older marks of the same user are dropped",
+			[]models.Mark{
+				{ID: ids[0], UserID: alice, UpdatedAt: base.Add(2 * time.Hour)},
+				{ID: ids[1], UserID: alice, UpdatedAt: base},
+				{ID: ids[2], UserID: alice, UpdatedAt: base.Add(time.Hour)},
+				{ID: ids[3], UserID: bob, UpdatedAt: base},
+			},
+			[]primitive.ObjectID{ids[1], ids[2]},
+		},
+		{
+			"ties keep the newer id",
+			[]models.Mark{{ID: ids[4], UserID: alice, UpdatedAt: base}, {ID: ids[3], UserID: alice, UpdatedAt: base}},
+			[]primitive.ObjectID{ids[3]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := staleMarks(tt.marks)
+			sort.Slice(got, func(i, j int) bool { return got[i].Hex() < got[j].Hex() })
+			if len(got) != len(tt.want) {
+				t.Fatalf("staleMarks = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("staleMarks = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
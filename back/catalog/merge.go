@@ -0,0 +1,310 @@
+package catalog
+
+import (
+	"back/config"
+	"back/history"
+	"back/models"
+	"back/search"
+	"back/taxonomy"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ชนิดของข้อมูลที่รวมได้ ชื่อเดียวกับ collection
+const (
+	TaxonomyAuthor   = "author"
+	TaxonomyTag      = "tag"
+	TaxonomyGenre    = "genre"
+	TaxonomyCategory = "category"
+)
+
+var (
+	ErrMergeSelf          = errors.New("cannot merge into itself")
+	ErrMergeSourceMissing = errors.New("source not found")
+	ErrMergeTargetMissing = errors.New("target not found")
+)
+
+// ฟิลด์ของหนังสือที่อ้างถึงข้อมูลแต่ละชนิด
+var taxonomyBookFields = map[string][]string{
+	TaxonomyAuthor:   {"authorId", "contributors.authorId"},
+	TaxonomyTag:      {"tagIds"},
+	TaxonomyGenre:    {"genres"},
+	TaxonomyCategory: {"category_id"},
+}
+
+type taxonomyDoc struct {
+	ID       primitive.ObjectID  `bson:"_id"`
+	Name     string              `bson:"name"`
+	Names    map[string]string   `bson:"names"`
+	Aliases  []string            `bson:"aliases"`
+	ParentID *primitive.ObjectID `bson:"parent_id"`
+}
+
+// Merge รวม source เข้ากับ target ใน transaction เดียว
+//   - หนังสือทุกเล่มที่อ้างถึง source ถูกเปลี่ยนให้อ้างถึง target และได้ revision ใหม่ในประวัติ
+//   - หนังสือที่ผู้อ่านเสนอและยังรอตรวจถูกเปลี่ยนเช่นกัน
+//   - ชื่อและ alias ของ source กลายเป็น alias ของ target แล้ว source ถูกลบ
+//   - ชื่อภาษาอื่นของ source ใช้กับภาษาที่ target ยังไม่มีชื่อ
+//   - หมวด/ประเภทย่อยของ source ย้ายไปอยู่ใต้ target
+//   - การรวมถูกบันทึกใน audit_log
+func Merge(ctx context.Context, kind string, sourceID, targetID primitive.ObjectID, editor history.Editor) (models.MergeReport, error) {
+	fields, ok := taxonomyBookFields[kind]
+	if !ok {
+		return models.MergeReport{}, errors.New("unknown taxonomy type " + kind)
+	}
+	if sourceID == targetID {
+		return models.MergeReport{}, ErrMergeSelf
+	}
+	db := config.DB.Database("bookwarm")
+	var report models.MergeReport
+
+	err := config.WithTransaction(ctx, func(ctx context.Context) error {
+		report = models.MergeReport{Type: kind, SourceID: sourceID, TargetID: targetID}
+		var source, target taxonomyDoc
+		if err := db.Collection(kind).FindOne(ctx, bson.M{"_id": sourceID}).Decode(&source); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return ErrMergeSourceMissing
+			}
+			return err
+		}
+		if err := db.Collection(kind).FindOne(ctx, bson.M{"_id": targetID}).Decode(&target); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return ErrMergeTargetMissing
+			}
+			return err
+		}
+		report.SourceName, report.TargetName = source.Name, target.Name
+
+		refs := make([]bson.M, 0, len(fields))
+		for _, field := range fields {
+			refs = append(refs, bson.M{field: sourceID})
+		}
+
+		cursor, err := db.Collection("books").Find(ctx, bson.M{"$or": refs})
+		if err != nil {
+			return err
+		}
+		var books []models.Book
+		if err := cursor.All(ctx, &books); err != nil {
+			return err
+		}
+		for _, before := range books {
+			after := before
+			set := replaceReference(&after, kind, sourceID, targetID)
+			if len(set) == 0 {
+				continue
+			}
+			after.UpdatedAt = time.Now()
+			set["updatedAt"] = after.UpdatedAt
+			if _, err := db.Collection("books").UpdateOne(ctx, bson.M{"_id": before.ID}, bson.M{"$set": set}); err != nil {
+				return err
+			}
+			if _, err := history.Record(ctx, &before, after, history.Entry{Action: models.RevisionMerge, Editor: editor}); err != nil {
+				return err
+			}
+			report.BooksUpdated++
+		}
+
+		pendingRefs := make([]bson.M, 0, len(fields))
+		for _, field := range fields {
+			pendingRefs = append(pendingRefs, bson.M{"book." + field: sourceID})
+		}
+		cursor, err = db.Collection("book_submissions").Find(ctx, bson.M{"status": models.SubmissionPending, "$or": pendingRefs})
+		if err != nil {
+			return err
+		}
+		var submissions []models.BookSubmission
+		if err := cursor.All(ctx, &submissions); err != nil {
+			return err
+		}
+		for _, submission := range submissions {
+			if len(replaceReference(&submission.Book, kind, sourceID, targetID)) == 0 {
+				continue
+			}
+			if _, err := db.Collection("book_submissions").UpdateOne(ctx,
+				bson.M{"_id": submission.ID}, bson.M{"$set": bson.M{"book": submission.Book}}); err != nil {
+				return err
+			}
+			report.SubmissionsUpdated++
+		}
+
+		if kind == TaxonomyGenre || kind == TaxonomyCategory {
+			if err := adoptChildren(ctx, kind, source, target); err != nil {
+				return err
+			}
+		}
+
+		report.Aliases = mergeAliases(target, source)
+		set := bson.M{"aliases": report.Aliases}
+		if names, changed := mergeNames(target.Names, source.Names); changed {
+			set["names"] = names
+		}
+		if _, err := db.Collection(kind).UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{"$set": set}); err != nil {
+			return err
+		}
+		if _, err := db.Collection(kind).DeleteOne(ctx, bson.M{"_id": sourceID}); err != nil {
+			return err
+		}
+
+		_, err = db.Collection("audit_log").InsertOne(ctx, models.AuditEntry{
+			ID:         primitive.NewObjectID(),
+			Action:     models.AuditMerge,
+			EntityType: kind,
+			EntityID:   targetID,
+			ActorID:    editor.ID,
+			ActorName:  editor.Name,
+			Merge:      &report,
+			CreatedAt:  time.Now(),
+		})
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if kind == TaxonomyAuthor {
+		search.RemoveAuthor(sourceID)
+		search.IndexAuthor(targetID)
+	}
+	return report, nil
+}
+
+// adoptChildren ย้ายลูกของ source ไปอยู่ใต้ target ถ้า target อยู่ใต้ source เอง
+// target จะย้ายขึ้นไปอยู่ที่เดิมของ source ก่อน เพื่อไม่ให้เกิดวงวน
+func adoptChildren(ctx context.Context, kind string, source, target taxonomyDoc) error {
+	collection := config.DB.Database("bookwarm").Collection(kind)
+	tree, err := taxonomy.Load(ctx, kind)
+	if err != nil {
+		return err
+	}
+	if tree.IsDescendant(target.ID, source.ID) {
+		update := bson.M{"$unset": bson.M{"parent_id": ""}}
+		if source.ParentID != nil {
+			update = bson.M{"$set": bson.M{"parent_id": *source.ParentID}}
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": target.ID}, update); err != nil {
+			return err
+		}
+	}
+	_, err = collection.UpdateMany(ctx, bson.M{"parent_id": source.ID, "_id": bson.M{"$ne": target.ID}},
+		bson.M{"$set": bson.M{"parent_id": target.ID}})
+	return err
+}
+
+// replaceReference เปลี่ยนการอ้างถึง from เป็น to ในหนังสือ คืน $set ของฟิลด์ที่เปลี่ยน
+// รายการที่ซ้ำกันหลังเปลี่ยน (เช่น หนังสือที่มีทั้งสองแท็ก) เหลือรายการเดียว
+func replaceReference(book *models.Book, kind string, from, to primitive.ObjectID) bson.M {
+	set := bson.M{}
+	switch kind {
+	case TaxonomyAuthor:
+		if book.AuthorID == from {
+			book.AuthorID = to
+			set["authorId"] = to
+		}
+		changed := false
+		seen := map[string]bool{}
+		contributors := make([]models.Contributor, 0, len(book.Contributors))
+		for _, contributor := range book.Contributors {
+			if contributor.AuthorID == from {
+				contributor.AuthorID = to
+				changed = true
+			}
+			key := contributor.AuthorID.Hex() + ":" + contributor.Role
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			contributors = append(contributors, contributor)
+		}
+		if changed {
+			book.Contributors = contributors
+			set["contributors"] = contributors
+		}
+	case TaxonomyTag:
+		if ids, changed := replaceID(book.TagIDs, from, to); changed {
+			book.TagIDs = ids
+			set["tagIds"] = ids
+		}
+	case TaxonomyGenre:
+		if ids, changed := replaceID(book.Genres, from, to); changed {
+			book.Genres = ids
+			set["genres"] = ids
+		}
+	case TaxonomyCategory:
+		if book.CategoryID == from {
+			book.CategoryID = to
+			set["category_id"] = to
+		}
+	}
+	return set
+}
+
+func replaceID(ids []primitive.ObjectID, from, to primitive.ObjectID) ([]primitive.ObjectID, bool) {
+	changed := false
+	seen := map[primitive.ObjectID]bool{}
+	out := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if id == from {
+			id = to
+			changed = true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out, changed
+}
+
+// mergeAliases คืน alias ของ target รวมชื่อและ alias ของ source โดยไม่ซ้ำกับชื่อของ target
+func mergeAliases(target, source taxonomyDoc) []string {
+	seen := map[string]bool{nameKey(target.Name): true}
+	aliases := []string{}
+	for _, alias := range append(append(append([]string{}, target.Aliases...), source.Name), source.Aliases...) {
+		alias = strings.Join(strings.Fields(alias), " ")
+		if alias == "" || seen[nameKey(alias)] {
+			continue
+		}
+		seen[nameKey(alias)] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// mergeNames เพิ่มชื่อภาษาอื่นของ source ในภาษาที่ target ยังไม่มี
+func mergeNames(target, source map[string]string) (map[string]string, bool) {
+	names := make(map[string]string, len(target)+len(source))
+	for locale, name := range target {
+		names[locale] = name
+	}
+	changed := false
+	for locale, name := range source {
+		if names[locale] == "" && name != "" {
+			names[locale] = name
+			changed = true
+		}
+	}
+	return names, changed
+}
+
+// localizedNames คือชื่อภาษาอื่นเรียงตาม locale เพื่อให้ผลเหมือนเดิมทุกครั้ง
+func localizedNames(names map[string]string) []string {
+	locales := make([]string, 0, len(names))
+	for locale := range names {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	out := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		out = append(out, names[locale])
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package catalog
+
+import (
+	"back/models"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReplaceReferenceAuthor(t *testing.T) {
+	from, to, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	book := models.Book{AuthorID: from, Contributors: []models.Contributor{
+		{AuthorID: to, Role: models.RoleAuthor},
+		{AuthorID: from, Role: models.RoleAuthor},
+		{AuthorID: from, Role: models.RoleIllustrator},
+		{AuthorID: other, Role: models.RoleTranslator},
+	}}
+
+	set := replaceReference(&book, TaxonomyAuthor, from, to)
+
+	// บทบาทเดิมของ from ที่ to มีอยู่แล้วถูกรวมเป็นอันเดียว บทบาทอื่นย้ายไปเป็นของ to
+	contributors := []models.Contributor{
+		{AuthorID: to, Role: models.RoleAuthor},
+		{AuthorID: to, Role: models.RoleIllustrator},
+		{AuthorID: other, Role: models.RoleTranslator},
+	}
+	want := bson.M{"authorId": to, "contributors": contributors}
+	if !reflect.DeepEqual(set, want) {
+		t.Errorf("set = %v, want %v", set, want)
+	}
+	if book.AuthorID != to || !reflect.DeepEqual(book.Contributors, contributors) {
+		t.Errorf("book = %+v", book)
+	}
+}
+
+func TestReplaceReferenceTags(t *testing.T) {
+	from, to, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	book := models.Book{TagIDs: []primitive.ObjectID{from, other, to}}
+	set := replaceReference(&book, TaxonomyTag, from, to)
+	if want := []primitive.ObjectID{to, other}; !reflect.DeepEqual(set, bson.M{"tagIds": want}) || !reflect.DeepEqual(book.TagIDs, want) {
+		t.Errorf("set = %v, tags = %v", set, book.TagIDs)
+	}
+
+	untouched := models.Book{TagIDs: []primitive.ObjectID{other}, AuthorID: from}
+	if set := replaceReference(&untouched, TaxonomyTag, from, to); len(set) != 0 || untouched.AuthorID != from {
+		t.Errorf("book without the tag: set = %v, book = %+v", set, untouched)
+	}
+}
+
+func TestMergeAliases(t *testing.T) {
+	target := taxonomyDoc{Name: "Fantasy", Aliases: []string{"High  Fantasy"}}
+	source := taxonomyDoc{Name: "high fantasy", Aliases: []string{" ", "Epic Fantasy", "epic fantasy", "FANTASY"}}
+
+	// ซ้ำกันโดยไม่สนตัวพิมพ์และช่องว่าง และชื่อของ target ไม่เป็น alias ของตัวเอง
+	want := []string{"High Fantasy", "Epic Fantasy"}
+	if got := mergeAliases(target, source); !reflect.DeepEqual(got, want) {
+		t.Fatalf("mergeAliases = %q, want %q", got, want)
+	}
+}
+
+func TestMergeNames(t *testing.T) {
+	names, changed := mergeNames(
+		map[string]string{"en": "Fantasy"},
+		map[string]string{"en": "Fantasy Fiction", "th": "แฟนตาซี", "ja": ""},
+	)
+	if want := map[string]string{"en": "Fantasy", "th": "แฟนตาซี"}; !reflect.DeepEqual(names, want) || !changed {
+		t.Fatalf("mergeNames = %v, %v", names, changed)
+	}
+
+	if _, changed := mergeNames(names, map[string]string{"th": "นิยายแฟนตาซี"}); changed {
+		t.Fatal("source name replaced the target's")
+	}
+}
@@ -0,0 +1,328 @@
+package catalog
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ONIX 3.0 ใช้รหัสจาก code list ของ EDItEUR ด้านล่างคือรหัสที่เราใช้
+const (
+	onixNotificationDelete = "05"
+
+	onixIDISBN10 = "02"
+	onixIDGTIN13 = "03"
+	onixIDISBN13 = "15"
+
+	onixTitleDistinctive = "01"
+	onixLevelProduct     = "01"
+	onixLevelCollection  = "02"
+	onixCollectionSeries = "10"
+
+	onixLanguageOfText = "01"
+	onixPagesUnit      = "03"
+
+	onixTextDescription      = "03"
+	onixTextShortDescription = "02"
+	onixResourceFrontCover   = "01"
+	onixPublishingDatePub    = "01"
+	onixPublisherRole        = "01"
+)
+
+// รหัส ContributorRole (code list 17) ที่แปลงเป็นบทบาทของเรา
+var onixRoles = map[string]string{
+	"A01": "author",
+	"A02": "co-author",
+	"B06": "translator",
+	"A12": "illustrator",
+	"B01": "editor",
+}
+
+// extent ที่ใช้เป็นจำนวนหน้า เรียงตามลำดับความสำคัญ (code list 23)
+var onixPageExtents = []string{"00", "11", "05", "07"}
+
+type onixTitleDetail struct {
+	Type     string `xml:"TitleType"`
+	Elements []struct {
+		Level              string `xml:"TitleElementLevel"`
+		PartNumber         string `xml:"PartNumber"`
+		TitleText          string `xml:"TitleText"`
+		TitlePrefix        string `xml:"TitlePrefix"`
+		TitleWithoutPrefix string `xml:"TitleWithoutPrefix"`
+		Subtitle           string `xml:"Subtitle"`
+	} `xml:"TitleElement"`
+}
+
+type onixProduct struct {
+	RecordReference  string `xml:"RecordReference"`
+	NotificationType string `xml:"NotificationType"`
+	Identifiers      []struct {
+		Type  string `xml:"ProductIDType"`
+		Value string `xml:"IDValue"`
+	} `xml:"ProductIdentifier"`
+	DescriptiveDetail struct {
+		ProductForm  string            `xml:"ProductForm"`
+		TitleDetails []onixTitleDetail `xml:"TitleDetail"`
+		Collections  []struct {
+			Type         string            `xml:"CollectionType"`
+			TitleDetails []onixTitleDetail `xml:"TitleDetail"`
+		} `xml:"Collection"`
+		Contributors []struct {
+			Roles              []string `xml:"ContributorRole"`
+			PersonName         string   `xml:"PersonName"`
+			PersonNameInverted string   `xml:"PersonNameInverted"`
+			NamesBeforeKey     string   `xml:"NamesBeforeKey"`
+			KeyNames           string   `xml:"KeyNames"`
+			CorporateName      string   `xml:"CorporateName"`
+		} `xml:"Contributor"`
+		Languages []struct {
+			Role string `xml:"LanguageRole"`
+			Code string `xml:"LanguageCode"`
+		} `xml:"Language"`
+		Extents []struct {
+			Type  string `xml:"ExtentType"`
+			Value string `xml:"ExtentValue"`
+			Unit  string `xml:"ExtentUnit"`
+		} `xml:"Extent"`
+		Subjects []struct {
+			Scheme  string `xml:"SubjectSchemeIdentifier"`
+			Code    string `xml:"SubjectCode"`
+			Heading string `xml:"SubjectHeadingText"`
+		} `xml:"Subject"`
+	} `xml:"DescriptiveDetail"`
+	CollateralDetail struct {
+		TextContents []struct {
+			Type string `xml:"TextType"`
+			Text []struct {
+				Inner string `xml:",innerxml"`
+			} `xml:"Text"`
+		} `xml:"TextContent"`
+		Resources []struct {
+			ContentType string `xml:"ResourceContentType"`
+			Versions    []struct {
+				Link string `xml:"ResourceLink"`
+			} `xml:"ResourceVersion"`
+		} `xml:"SupportingResource"`
+	} `xml:"CollateralDetail"`
+	PublishingDetail struct {
+		Publishers []struct {
+			Role string `xml:"PublishingRole"`
+			Name string `xml:"PublisherName"`
+		} `xml:"Publisher"`
+		Dates []struct {
+			Role string `xml:"PublishingDateRole"`
+			Date string `xml:"Date"`
+		} `xml:"PublishingDate"`
+	} `xml:"PublishingDetail"`
+}
+
+var markupPattern = regexp.MustCompile(`<[^>]*>`)
+
+// plainText ตัด XHTML ที่ ONIX อนุญาตให้ใช้ใน <Text> ออก
+func plainText(inner string) string {
+	inner = strings.TrimSpace(inner)
+	inner = strings.TrimSuffix(strings.TrimPrefix(inner, "<![CDATA["), "]]>")
+	inner = markupPattern.ReplaceAllString(inner, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(inner)), " ")
+}
+
+func onixTitle(details []onixTitleDetail, level string) (title, part string) {
+	for _, detail := range details {
+		if detail.Type != onixTitleDistinctive {
+			continue
+		}
+		for _, element := range detail.Elements {
+			if element.Level != level {
+				continue
+			}
+			title = element.TitleText
+			if title == "" {
+				title = strings.TrimSpace(element.TitlePrefix + " " + element.TitleWithoutPrefix)
+			}
+			if element.Subtitle != "" && level == onixLevelProduct {
+				title += ": " + element.Subtitle
+			}
+			return strings.TrimSpace(title), element.PartNumber
+		}
+	}
+	return "", ""
+}
+
+func onixFormat(productForm string) string {
+	switch {
+	case productForm == "BB":
+		return "hardcover"
+	case productForm == "BC":
+		return "paperback"
+	case strings.HasPrefix(productForm, "E"):
+		return "ebook"
+	case strings.HasPrefix(productForm, "A"):
+		return "audiobook"
+	}
+	return ""
+}
+
+// onixRow แปลง Product หนึ่งรายการ ONIX ใช้รหัสภาษา ISO 639-2/B (เช่น tha, eng) เหมือน Open Library
+func onixRow(product onixProduct, mapper *SubjectMapper) (Row, error) {
+	if product.NotificationType == onixNotificationDelete {
+		return Row{}, fmt.Errorf("record %s is a delete notification, remove the book manually", product.RecordReference)
+	}
+
+	detail := product.DescriptiveDetail
+	row := Row{Format: onixFormat(detail.ProductForm)}
+	row.Title, _ = onixTitle(detail.TitleDetails, onixLevelProduct)
+
+	for _, id := range product.Identifiers {
+		switch {
+		case id.Type == onixIDISBN13, id.Type == onixIDGTIN13 && (strings.HasPrefix(id.Value, "978") || strings.HasPrefix(id.Value, "979")):
+			row.ISBN = id.Value
+		case id.Type == onixIDISBN10 && row.ISBN == "":
+			row.ISBN = id.Value
+		}
+	}
+
+	for _, contributor := range detail.Contributors {
+		name := contributor.PersonName
+		if name == "" && contributor.KeyNames != "" {
+			name = strings.TrimSpace(contributor.NamesBeforeKey + " " + contributor.KeyNames)
+		}
+		if name == "" && contributor.PersonNameInverted != "" {
+			// "Murakami, Haruki" → "Haruki Murakami"
+			parts := strings.SplitN(contributor.PersonNameInverted, ",", 2)
+			name = strings.TrimSpace(parts[len(parts)-1] + " " + parts[0])
+		}
+		if name == "" {
+			name = contributor.CorporateName
+		}
+		for _, code := range contributor.Roles {
+			if role, ok := onixRoles[code]; ok && name != "" {
+				if role != "author" {
+					name += " (" + role + ")"
+				}
+				row.Authors = append(row.Authors, name)
+				break
+			}
+		}
+	}
+
+	for _, collection := range detail.Collections {
+		if collection.Type != onixCollectionSeries {
+			continue
+		}
+		title, part := onixTitle(collection.TitleDetails, onixLevelCollection)
+		if title != "" {
+			row.Series = title
+			if number, err := strconv.ParseFloat(part, 64); err == nil && number > 0 {
+				row.SeriesNumber = &number
+			}
+			break
+		}
+	}
+
+	for _, language := range detail.Languages {
+		if language.Role == onixLanguageOfText {
+			row.Language = strings.ToLower(language.Code)
+			break
+		}
+	}
+
+	pages := map[string]int{}
+	for _, extent := range detail.Extents {
+		if extent.Unit == onixPagesUnit {
+			pages[extent.Type], _ = strconv.Atoi(extent.Value)
+		}
+	}
+	for _, extentType := range onixPageExtents {
+		if pages[extentType] > 0 {
+			row.PageCount = pages[extentType]
+			break
+		}
+	}
+
+	// ใช้ชื่อหัวข้อถ้ามี ถ้ามีแต่รหัส (เช่น BISAC FIC009000) ให้ไฟล์ mapping จับคู่จากรหัส
+	var subjects []string
+	for _, subject := range detail.Subjects {
+		if subject.Heading != "" {
+			subjects = append(subjects, subject.Heading)
+		} else if subject.Code != "" {
+			subjects = append(subjects, subject.Code)
+		}
+	}
+	row.Genres, row.Tags = mapper.Map(subjects)
+
+	texts := map[string]string{}
+	for _, content := range product.CollateralDetail.TextContents {
+		if len(content.Text) > 0 && texts[content.Type] == "" {
+			texts[content.Type] = plainText(content.Text[0].Inner)
+		}
+	}
+	row.Description = texts[onixTextDescription]
+	if row.Description == "" {
+		row.Description = texts[onixTextShortDescription]
+	}
+
+	for _, resource := range product.CollateralDetail.Resources {
+		if resource.ContentType == onixResourceFrontCover && len(resource.Versions) > 0 {
+			row.CoverImage = strings.TrimSpace(resource.Versions[0].Link)
+			break
+		}
+	}
+
+	for _, publisher := range product.PublishingDetail.Publishers {
+		if publisher.Role == onixPublisherRole || row.Publisher == "" {
+			row.Publisher = publisher.Name
+		}
+	}
+	for _, date := range product.PublishingDetail.Dates {
+		if date.Role == onixPublishingDatePub && len(date.Date) >= 4 {
+			row.PublishYear, _ = strconv.Atoi(date.Date[:4])
+		}
+	}
+	return row, nil
+}
+
+// ParseONIX อ่าน ONIX 3.0 ที่ใช้ reference tag (<Product>, <ProductIdentifier>, ...)
+// ทีละ Product เพื่อไม่ต้องโหลดทั้งไฟล์ ไฟล์แบบ short tag (<product>, <a001>) ยังไม่รองรับ
+// Line ของแต่ละแถวคือบรรทัดที่ <Product> เริ่มต้น
+func ParseONIX(r io.Reader, mapper *SubjectMapper) ([]ParsedRow, error) {
+	decoder := xml.NewDecoder(r)
+	var rows []ParsedRow
+	sawProduct := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("invalid ONIX XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "product" {
+			return nil, fmt.Errorf("ONIX short tags are not supported, convert the file to reference tags first")
+		}
+		if start.Name.Local != "Product" {
+			continue
+		}
+		sawProduct = true
+
+		line, _ := decoder.InputPos()
+		var product onixProduct
+		if err := decoder.DecodeElement(&product, &start); err != nil {
+			return rows, fmt.Errorf("invalid ONIX product at line %d: %w", line, err)
+		}
+		row, err := onixRow(product, mapper)
+		rows = append(rows, ParsedRow{Line: line, Row: row, Err: err})
+	}
+	if !sawProduct {
+		return nil, fmt.Errorf("no <Product> records found, is this an ONIX 3.0 file?")
+	}
+	return rows, nil
+}
@@ -0,0 +1,83 @@
+package catalog
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseONIX(t *testing.T) {
+	file, err := os.Open("testdata/onix3.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mapper := &SubjectMapper{
+		Genres: map[string]string{"fic009000": "Fantasy"},
+		Tags:   map[string]string{"magic": "magic"},
+	}
+	rows, err := ParseONIX(file, mapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("rows = %d, want 3", len(rows))
+	}
+
+	// ชื่อเรื่องรวม prefix กับ subtitle, ชื่อคนกลับเป็นชื่อ-นามสกุล, ใช้ ISBN-13 และจำนวนหน้าจากเนื้อหาหลัก
+	two := 2.0
+	want := Row{
+		Title:        "The Two Towers: Being the Second Part",
+		Description:  "Frodo & Sam continue .",
+		Authors:      []string{"J. R. R. Tolkien", "สมชาย ใจดี (translator)"},
+		Genres:       []string{"Fantasy"},
+		Tags:         []string{"magic"},
+		Series:       "The Lord of the Rings",
+		SeriesNumber: &two,
+		PublishYear:  1954,
+		PageCount:    352,
+		ISBN:         "9780306406157",
+		Language:     "tha",
+		Format:       "paperback",
+		Publisher:    "Allen & Unwin",
+		CoverImage:   "https://example.com/cover.jpg",
+	}
+	if rows[0].Err != nil || !reflect.DeepEqual(rows[0].Row, want) {
+		t.Fatalf("row = %+v (err %v)\nwant %+v", rows[0].Row, rows[0].Err, want)
+	}
+	if rows[0].Line != 4 {
+		t.Errorf("line = %d, want 4", rows[0].Line)
+	}
+
+	// notification ลบสินค้าไม่ใช่หนังสือที่จะ import
+	if rows[1].Err == nil || !strings.Contains(rows[1].Err.Error(), "rec-2") {
+		t.Errorf("delete notification err = %v", rows[1].Err)
+	}
+	// GTIN-13 ที่ไม่ใช่ ISBN ไม่ถูกเก็บเป็น ISBN
+	if rows[2].Err != nil || rows[2].Row.ISBN != "" || rows[2].Row.Format != "ebook" {
+		t.Errorf("non-book GTIN row = %+v (err %v)", rows[2].Row, rows[2].Err)
+	}
+}
+
+func TestParseONIXRejectsUnsupportedFiles(t *testing.T) {
+	_, err := ParseONIX(strings.NewReader(`<ONIXmessage><product><a001>1</a001></product></ONIXmessage>`), nil)
+	if err == nil || !strings.Contains(err.Error(), "short tags") {
+		t.Fatalf("err = %v", err)
+	}
+
+	_, err = ParseONIX(strings.NewReader(`<ONIXMessage><Header/></ONIXMessage>`), nil)
+	if err == nil || !strings.Contains(err.Error(), "no <Product>") {
+		t.Fatalf("err without products = %v", err)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	if got := plainText("<p>One</p><p>Two &amp; three</p>"); got != "One Two & three" {
+		t.Errorf("html = %q", got)
+	}
+	if got := plainText("<![CDATA[<b>Bold</b>  text]]>"); got != "Bold text" {
+		t.Errorf("cdata = %q", got)
+	}
+}
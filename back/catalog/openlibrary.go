@@ -0,0 +1,297 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// openLibraryCoverURL อ้างถึงรูปปกบนเซิร์ฟเวอร์ของ Open Library โดยตรง (ไม่ดาวน์โหลดมาเก็บ)
+const openLibraryCoverURL = "https://covers.openlibrary.org/b/id/%d-L.jpg"
+
+// description ใน dump เป็นได้ทั้ง string และ {"type": "/type/text", "value": "..."}
+type olText string
+
+func (t *olText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = olText(s)
+		return nil
+	}
+	var v struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t = olText(v.Value)
+	return nil
+}
+
+type olRef struct {
+	Key string `json:"key"`
+}
+
+type olEdition struct {
+	Type           olRef    `json:"type"`
+	Key            string   `json:"key"`
+	Title          string   `json:"title"`
+	Subtitle       string   `json:"subtitle"`
+	ISBN13         []string `json:"isbn_13"`
+	ISBN10         []string `json:"isbn_10"`
+	Publishers     []string `json:"publishers"`
+	PublishDate    string   `json:"publish_date"`
+	NumberOfPages  int      `json:"number_of_pages"`
+	Covers         []int    `json:"covers"`
+	Authors        []olRef  `json:"authors"`
+	Works          []olRef  `json:"works"`
+	Languages      []olRef  `json:"languages"`
+	Subjects       []string `json:"subjects"`
+	PhysicalFormat string   `json:"physical_format"`
+	Description    olText   `json:"description"`
+	Series         []string `json:"series"`
+	Contributors   []struct {
+		Role string `json:"role"`
+		Name string `json:"name"`
+	} `json:"contributors"`
+}
+
+type olWork struct {
+	Key         string   `json:"key"`
+	Title       string   `json:"title"`
+	Description olText   `json:"description"`
+	Subjects    []string `json:"subjects"`
+	Covers      []int    `json:"covers"`
+	Authors     []struct {
+		Author olRef `json:"author"`
+	} `json:"authors"`
+}
+
+type olAuthor struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// scanOpenLibrary อ่าน dump ทีละบรรทัด รองรับทั้งไฟล์ dump ทางการ
+// (type, key, revision, last_modified, JSON คั่นด้วย tab) และ JSONL ที่มีแต่ JSON
+func scanOpenLibrary(r io.Reader, fn func(line int, raw []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if i := strings.LastIndexByte(string(raw), '\t'); i >= 0 {
+			raw = raw[i+1:]
+		}
+		if len(strings.TrimSpace(string(raw))) == 0 {
+			continue
+		}
+		if err := fn(line, raw); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+var (
+	yearPattern   = regexp.MustCompile(`\b(1[0-9]{3}|2[0-9]{3})\b`)
+	seriesPattern = regexp.MustCompile(`(?i)^(.*?)[\s,;:#(]+(?:(?:vol|volume|book|no|part|เล่ม)\.?\s*)?(\d+(?:\.\d+)?)\)?$`)
+)
+
+// splitSeries แยกชื่อซีรีส์กับเลขเล่ม เช่น "Harry Potter ; 1", "Discworld #3", "Dune (Book 2)"
+func splitSeries(value string) (string, *float64) {
+	value = strings.TrimSpace(value)
+	if m := seriesPattern.FindStringSubmatch(value); m != nil && strings.TrimSpace(m[1]) != "" {
+		if number, err := strconv.ParseFloat(m[2], 64); err == nil && number > 0 {
+			return strings.TrimSpace(m[1]), &number
+		}
+	}
+	return value, nil
+}
+
+func openLibraryFormat(physical string) string {
+	physical = strings.ToLower(physical)
+	switch {
+	case strings.Contains(physical, "hardcover"), strings.Contains(physical, "hardback"):
+		return "hardcover"
+	case strings.Contains(physical, "paperback"), strings.Contains(physical, "softcover"):
+		return "paperback"
+	case strings.Contains(physical, "ebook"), strings.Contains(physical, "e-book"), strings.Contains(physical, "electronic"):
+		return "ebook"
+	case strings.Contains(physical, "audio"):
+		return "audiobook"
+	}
+	return ""
+}
+
+// openLibraryRole แปลงบทบาทของ contributors ใน edition เป็นบทบาทของเรา
+func openLibraryRole(role string) string {
+	role = strings.ToLower(role)
+	switch {
+	case strings.Contains(role, "translat"):
+		return "translator"
+	case strings.Contains(role, "illustrat"):
+		return "illustrator"
+	case strings.Contains(role, "edit"):
+		return "editor"
+	}
+	return ""
+}
+
+// ParseOpenLibrary แปลง dump ของ edition เป็นแถวสำหรับ Import
+// authors และ works เป็น dump ของนักเขียนและงานเขียน (ไม่บังคับ แต่ถ้าไม่มีจะไม่รู้ชื่อผู้แต่ง)
+// จะเก็บเฉพาะนักเขียนและงานที่ edition อ้างถึงเพื่อไม่ต้องโหลด dump ทั้งไฟล์เข้าหน่วยความจำ
+func ParseOpenLibrary(editions, works, authors io.Reader, mapper *SubjectMapper) ([]ParsedRow, error) {
+	type parsedEdition struct {
+		line    int
+		edition olEdition
+		err     error
+	}
+
+	var parsed []parsedEdition
+	neededWorks := map[string]bool{}
+	neededAuthors := map[string]bool{}
+	err := scanOpenLibrary(editions, func(line int, raw []byte) error {
+		var edition olEdition
+		if err := json.Unmarshal(raw, &edition); err != nil {
+			parsed = append(parsed, parsedEdition{line: line, err: err})
+			return nil
+		}
+		if edition.Type.Key != "" && edition.Type.Key != "/type/edition" {
+			return nil
+		}
+		for _, work := range edition.Works {
+			neededWorks[work.Key] = true
+		}
+		for _, author := range edition.Authors {
+			neededAuthors[author.Key] = true
+		}
+		parsed = append(parsed, parsedEdition{line: line, edition: edition})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read editions: %w", err)
+	}
+
+	workByKey := map[string]olWork{}
+	if works != nil {
+		err := scanOpenLibrary(works, func(line int, raw []byte) error {
+			var work olWork
+			if json.Unmarshal(raw, &work) != nil || !neededWorks[work.Key] {
+				return nil
+			}
+			workByKey[work.Key] = work
+			for _, author := range work.Authors {
+				neededAuthors[author.Author.Key] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read works: %w", err)
+		}
+	}
+
+	authorNames := map[string]string{}
+	if authors != nil {
+		err := scanOpenLibrary(authors, func(line int, raw []byte) error {
+			var author olAuthor
+			if json.Unmarshal(raw, &author) == nil && neededAuthors[author.Key] {
+				authorNames[author.Key] = author.Name
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read authors: %w", err)
+		}
+	}
+
+	rows := make([]ParsedRow, 0, len(parsed))
+	for _, p := range parsed {
+		if p.err != nil {
+			rows = append(rows, ParsedRow{Line: p.line, Err: p.err})
+			continue
+		}
+		rows = append(rows, ParsedRow{Line: p.line, Row: openLibraryRow(p.edition, workByKey, authorNames, mapper)})
+	}
+	return rows, nil
+}
+
+func openLibraryRow(edition olEdition, workByKey map[string]olWork, authorNames map[string]string, mapper *SubjectMapper) Row {
+	var work olWork
+	if len(edition.Works) > 0 {
+		work = workByKey[edition.Works[0].Key]
+	}
+
+	row := Row{
+		Title:       edition.Title,
+		Description: string(edition.Description),
+		PageCount:   edition.NumberOfPages,
+		Format:      openLibraryFormat(edition.PhysicalFormat),
+	}
+	if row.Title == "" {
+		row.Title = work.Title
+	}
+	if edition.Subtitle != "" {
+		row.Title += ": " + edition.Subtitle
+	}
+	if row.Description == "" {
+		row.Description = string(work.Description)
+	}
+	if len(edition.Works) > 0 {
+		row.WorkKey = "openlibrary:" + edition.Works[0].Key
+	}
+
+	authorKeys := make([]string, 0, len(edition.Authors))
+	for _, author := range edition.Authors {
+		authorKeys = append(authorKeys, author.Key)
+	}
+	if len(authorKeys) == 0 {
+		for _, author := range work.Authors {
+			authorKeys = append(authorKeys, author.Author.Key)
+		}
+	}
+	for _, key := range authorKeys {
+		if name := authorNames[key]; name != "" {
+			row.Authors = append(row.Authors, name)
+		}
+	}
+	for _, contributor := range edition.Contributors {
+		if role := openLibraryRole(contributor.Role); role != "" && contributor.Name != "" {
+			row.Authors = append(row.Authors, contributor.Name+" ("+role+")")
+		}
+	}
+
+	switch {
+	case len(edition.ISBN13) > 0:
+		row.ISBN = edition.ISBN13[0]
+	case len(edition.ISBN10) > 0:
+		row.ISBN = edition.ISBN10[0]
+	}
+	if len(edition.Publishers) > 0 {
+		row.Publisher = edition.Publishers[0]
+	}
+	if m := yearPattern.FindString(edition.PublishDate); m != "" {
+		row.PublishYear, _ = strconv.Atoi(m)
+	}
+	if len(edition.Languages) > 0 {
+		row.Language = strings.TrimPrefix(edition.Languages[0].Key, "/languages/")
+	}
+
+	covers := edition.Covers
+	if len(covers) == 0 {
+		covers = work.Covers
+	}
+	if len(covers) > 0 && covers[0] > 0 {
+		row.CoverImage = fmt.Sprintf(openLibraryCoverURL, covers[0])
+	}
+	if len(edition.Series) > 0 {
+		row.Series, row.SeriesNumber = splitSeries(edition.Series[0])
+	}
+
+	subjects := append(append([]string{}, edition.Subjects...), work.Subjects...)
+	row.Genres, row.Tags = mapper.Map(subjects)
+	return row
+}
@@ -0,0 +1,74 @@
+package catalog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOpenLibrary(t *testing.T) {
+	editions := strings.Join([]string{
+		// dump ทางการ: type, key, revision, last_modified, JSON
+		"/type/edition\t/books/OL1M\t3\t2020-01-01\t" + `{"type": {"key": "/type/edition"}, "key": "/books/OL1M", "title": "Norwegian Wood", "isbn_10": ["0375704027"], "isbn_13": ["9780375704024"], "publishers": ["Vintage"], "publish_date": "September 2000", "number_of_pages": 296, "works": [{"key": "/works/OL1W"}], "languages": [{"key": "/languages/eng"}], "physical_format": "Paperback", "series": ["Vintage International ; 12"], "contributors": [{"role": "Translator", "name": "Jay Rubin"}]}`,
+		// JSONL ที่ไม่มี title: ใช้ของ work
+		`{"key": "/books/OL2M", "works": [{"key": "/works/OL1W"}]}`,
+		`{"type": {"key": "/type/redirect"}, "key": "/books/OL3M"}`,
+		`{not json`,
+	}, "\n")
+	works := `{"key": "/works/OL1W", "title": "ノルウェイの森", "description": {"type": "/type/text", "value": "A love story."}, "covers": [12345], "authors": [{"author": {"key": "/authors/OL1A"}}]}`
+	authors := `{"key": "/authors/OL1A", "name": "Haruki Murakami"}`
+
+	rows, err := ParseOpenLibrary(strings.NewReader(editions), strings.NewReader(works), strings.NewReader(authors), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("rows = %d, want 3 (redirect skipped): %+v", len(rows), rows)
+	}
+
+	twelve := 12.0
+	want := Row{
+		Title:        "Norwegian Wood",
+		Description:  "A love story.",
+		Authors:      []string{"Haruki Murakami", "Jay Rubin (translator)"},
+		Series:       "Vintage International",
+		SeriesNumber: &twelve,
+		PublishYear:  2000,
+		PageCount:    296,
+		ISBN:         "9780375704024",
+		Language:     "eng",
+		Format:       "paperback",
+		Publisher:    "Vintage",
+		CoverImage:   "https://covers.openlibrary.org/b/id/12345-L.jpg",
+		WorkKey:      "openlibrary:/works/OL1W",
+	}
+	if rows[0].Err != nil || !reflect.DeepEqual(rows[0].Row, want) {
+		t.Errorf("row = %+v (err %v)\nwant %+v", rows[0].Row, rows[0].Err, want)
+	}
+	if rows[1].Row.Title != "ノルウェイの森" || rows[1].Row.WorkKey != want.WorkKey {
+		t.Errorf("edition without title = %+v", rows[1].Row)
+	}
+	if rows[2].Line != 4 || rows[2].Err == nil {
+		t.Errorf("invalid JSON row = %+v", rows[2])
+	}
+}
+
+func TestSplitSeries(t *testing.T) {
+	numbered := map[string]string{
+		"Harry Potter ; 1": "Harry Potter",
+		"Discworld #3":     "Discworld",
+		"Dune (Book 2)":    "Dune",
+		"แฮร์รี่ พอตเตอร์ เล่ม 7": "แฮร์รี่ พอตเตอร์",
+	}
+	for value, name := range numbered {
+		if got, number := splitSeries(value); got != name || number == nil {
+			t.Errorf("splitSeries(%q) = %q, %v", value, got, number)
+		}
+	}
+	// ตัวเลขที่เป็นส่วนหนึ่งของชื่อไม่ใช่เลขเล่ม
+	for _, value := range []string{"Catch-22", "1984"} {
+		if got, number := splitSeries(value); got != value || number != nil {
+			t.Errorf("splitSeries(%q) = %q, %v", value, got, number)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package catalog นำเข้าและส่งออกข้อมูลหนังสือทั้งแคตตาล็อกเป็น CSV หรือ JSONL
+// ผู้แต่ง หมวดหมู่ ประเภท แท็ก และซีรีส์อ้างถึงด้วยชื่อ ถ้ายังไม่มีจะสร้างให้ตอน import
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	// FormatONIX ใช้ได้เฉพาะตอน import (ดู ParseONIX)
+	FormatONIX = "onix"
+)
+
+// ตัวคั่นค่าหลายค่าในช่องเดียวของ CSV เช่น genres = "Fantasy|Adventure"
+const listSeparator = "|"
+
+// Row คือหนังสือหนึ่งเล่มในไฟล์ import/export
+// authors ระบุบทบาทต่อท้ายในวงเล็บได้ เช่น "Haruki Murakami", "นพดล เวชสวัสดิ์ (translator)"
+type Row struct {
+	Title        string   `json:"title"`
+	Description  string   `json:"description,omitempty"`
+	Authors      []string `json:"authors,omitempty"`
+	Category     string   `json:"category,omitempty"`
+	Genres       []string `json:"genres,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Series       string   `json:"series,omitempty"`
+	SeriesNumber *float64 `json:"series_number,omitempty"`
+	PublishYear  int      `json:"publish_year,omitempty"`
+	PageCount    int      `json:"page_count,omitempty"`
+	ISBN         string   `json:"isbn,omitempty"`
+	Language     string   `json:"language,omitempty"`
+	Format       string   `json:"format,omitempty"`
+	Publisher    string   `json:"publisher,omitempty"`
+	CoverImage   string   `json:"cover_image,omitempty"`
+	// WorkKey จัดกลุ่ม edition ที่เป็นงานเดียวกัน เป็น id ภายนอก (เช่น "openlibrary:/works/OL45883W")
+	// หรือ "bookwarm:<id>" สำหรับ work ที่มีอยู่แล้ว
+	WorkKey string `json:"work_key,omitempty"`
+}
+
+// Columns คือหัวตารางของ CSV ตามลำดับที่ export
+var Columns = []string{
+	"title", "description", "authors", "category", "genres", "tags", "series", "series_number",
+	"publish_year", "page_count", "isbn", "language", "format", "publisher", "cover_image", "work_key",
+}
+
+// ParsedRow คือแถวที่อ่านจากไฟล์ ถ้าแปลงค่าไม่ได้ Err จะไม่เป็น nil และแถวนี้จะถูกรายงานว่าผิดพลาด
+type ParsedRow struct {
+	Line int
+	Row  Row
+	Err  error
+}
+
+// FormatFromName เดารูปแบบไฟล์จากนามสกุล
+func FormatFromName(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(lower, ".jsonl"), strings.HasSuffix(lower, ".ndjson"):
+		return FormatJSONL
+	case strings.HasSuffix(lower, ".xml"), strings.HasSuffix(lower, ".onix"):
+		return FormatONIX
+	}
+	return ""
+}
+
+// Parse อ่านไฟล์ทั้งไฟล์ error ที่คืนคือไฟล์เสียทั้งไฟล์ (เช่น ไม่มีหัวตาราง)
+// ส่วนแถวที่ผิดจะอยู่ใน ParsedRow.Err
+func Parse(r io.Reader, format string) ([]ParsedRow, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatJSONL:
+		return parseJSONL(r)
+	}
+	return nil, fmt.Errorf("unsupported format %q, use csv or jsonl", format)
+}
+
+func parseCSV(r io.Reader) ([]ParsedRow, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("CSV header must contain a title column")
+	}
+
+	var rows []ParsedRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, ParsedRow{Line: parseErr.StartLine, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		parsed := ParsedRow{Line: line}
+		parsed.Row, parsed.Err = rowFromStrings(get)
+		rows = append(rows, parsed)
+	}
+	return rows, nil
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, listSeparator) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func rowFromStrings(get func(string) string) (Row, error) {
+	row := Row{
+		Title:       get("title"),
+		Description: get("description"),
+		Authors:     splitList(get("authors")),
+		Category:    get("category"),
+		Genres:      splitList(get("genres")),
+		Tags:        splitList(get("tags")),
+		Series:      get("series"),
+		ISBN:        get("isbn"),
+		Language:    get("language"),
+		Format:      get("format"),
+		Publisher:   get("publisher"),
+		CoverImage:  get("cover_image"),
+		WorkKey:     get("work_key"),
+	}
+	if raw := get("series_number"); raw != "" {
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return row, fmt.Errorf("invalid series_number %q", raw)
+		}
+		row.SeriesNumber = &number
+	}
+	for _, field := range []struct {
+		name string
+		dst  *int
+	}{{"publish_year", &row.PublishYear}, {"page_count", &row.PageCount}} {
+		if raw := get(field.name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return row, fmt.Errorf("invalid %s %q", field.name, raw)
+			}
+			*field.dst = value
+		}
+	}
+	return row, nil
+}
+
+func parseJSONL(r io.Reader) ([]ParsedRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var rows []ParsedRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		parsed := ParsedRow{Line: line}
+		parsed.Err = json.Unmarshal([]byte(text), &parsed.Row)
+		rows = append(rows, parsed)
+	}
+	return rows, scanner.Err()
+}
+
+// Writer เขียนแถวออกเป็น CSV หรือ JSONL
+type Writer struct {
+	format string
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(Columns); err != nil {
+			return nil, err
+		}
+		return &Writer{format: format, csv: cw}, nil
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &Writer{format: format, json: enc}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q, use csv or jsonl", format)
+}
+
+func (w *Writer) Write(row Row) error {
+	if w.json != nil {
+		return w.json.Encode(row)
+	}
+	seriesNumber := ""
+	if row.SeriesNumber != nil {
+		seriesNumber = strconv.FormatFloat(*row.SeriesNumber, 'f', -1, 64)
+	}
+	intString := func(v int) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.Itoa(v)
+	}
+	return w.csv.Write([]string{
+		row.Title, row.Description, strings.Join(row.Authors, listSeparator), row.Category,
+		strings.Join(row.Genres, listSeparator), strings.Join(row.Tags, listSeparator), row.Series, seriesNumber,
+		intString(row.PublishYear), intString(row.PageCount), row.ISBN, row.Language, row.Format, row.Publisher, row.CoverImage,
+		row.WorkKey,
+	})
+}
+
+// Flush ต้องเรียกหลังเขียนแถวสุดท้าย
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
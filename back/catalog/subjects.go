@@ -0,0 +1,127 @@
+package catalog
+
+import (
+	"back/config"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SubjectMapper แปลง subject จากแหล่งข้อมูลภายนอก (Open Library, BISAC/Thema ใน ONIX)
+// เป็นชื่อ genre และ tag ของเรา subject ที่ไม่รู้จักจะถูกทิ้ง เว้นแต่ตั้ง UnmappedAsTags
+type SubjectMapper struct {
+	Genres         map[string]string
+	Tags           map[string]string
+	UnmappedAsTags bool
+}
+
+// subjectMapping คือรูปแบบของไฟล์ mapping เช่น
+//
+//	{"genres": {"Fantasy fiction": "Fantasy"}, "tags": {"Magic": "magic"}}
+type subjectMapping struct {
+	Genres map[string]string `json:"genres"`
+	Tags   map[string]string `json:"tags"`
+}
+
+func subjectKey(subject string) string {
+	// "Fiction -- Fantasy -- General" ใช้ส่วนที่เจาะจงที่สุดที่ไม่ใช่ "general"
+	parts := strings.Split(subject, "--")
+	if len(parts) == 1 {
+		parts = strings.Split(subject, "/")
+	}
+	key := ""
+	for _, part := range parts {
+		part = nameKey(part)
+		if part != "" && part != "general" {
+			key = part
+		}
+	}
+	return key
+}
+
+// LoadSubjectMapper เริ่มจากชื่อ genre และ tag ที่มีในฐานข้อมูลอยู่แล้ว (จับคู่แบบไม่สนตัวพิมพ์)
+// แล้วเพิ่ม mapping จากไฟล์ถ้าระบุ path
+func LoadSubjectMapper(ctx context.Context, path string) (*SubjectMapper, error) {
+	mapper := &SubjectMapper{Genres: map[string]string{}, Tags: map[string]string{}}
+	for collection, dst := range map[string]map[string]string{"genre": mapper.Genres, "tag": mapper.Tags} {
+		cursor, err := config.DB.Database("bookwarm").Collection(collection).Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"name": 1}))
+		if err != nil {
+			return nil, err
+		}
+		var docs []struct {
+			Name string `bson:"name"`
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			dst[nameKey(doc.Name)] = doc.Name
+		}
+	}
+
+	if path == "" {
+		return mapper, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mapping subjectMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, err
+	}
+	for subject, genre := range mapping.Genres {
+		mapper.Genres[subjectKey(subject)] = genre
+	}
+	for subject, tag := range mapping.Tags {
+		mapper.Tags[subjectKey(subject)] = tag
+	}
+	return mapper, nil
+}
+
+// Map คืน genre และ tag ที่ตรงกับ subjects โดยไม่ซ้ำกัน
+func (m *SubjectMapper) Map(subjects []string) (genres, tags []string) {
+	if m == nil {
+		return nil, nil
+	}
+	seenGenre, seenTag := map[string]bool{}, map[string]bool{}
+	for _, subject := range subjects {
+		key := subjectKey(subject)
+		if key == "" {
+			continue
+		}
+		// "fantasy fiction" จับคู่กับ genre "Fantasy" ได้
+		candidates := []string{key, strings.TrimSuffix(key, " fiction"), strings.TrimSuffix(key, " stories")}
+
+		matched := false
+		for _, candidate := range candidates {
+			if genre, ok := m.Genres[candidate]; ok {
+				if !seenGenre[genre] {
+					seenGenre[genre] = true
+					genres = append(genres, genre)
+				}
+				matched = true
+				break
+			}
+		}
+		for _, candidate := range candidates {
+			if tag, ok := m.Tags[candidate]; ok {
+				if !seenTag[tag] {
+					seenTag[tag] = true
+					tags = append(tags, tag)
+				}
+				matched = true
+				break
+			}
+		}
+		if !matched && m.UnmappedAsTags && !seenTag[key] {
+			seenTag[key] = true
+			tags = append(tags, key)
+		}
+	}
+	return genres, tags
+}
@@ -0,0 +1,284 @@
+// catalog นำเข้าหรือส่งออกแคตตาล็อกหนังสือจาก command line (ทำงานแบบเดียวกับ /api/admin/catalog)
+//
+//	go run ./cmd/catalog import -file books.csv [-dry-run]
+//	go run ./cmd/catalog export -format jsonl -out catalog.jsonl
+//	go run ./cmd/catalog openlibrary -editions ol_dump_editions.txt -works ol_dump_works.txt -authors ol_dump_authors.txt [-subjects map.json] [-unmapped-tags] [-dry-run]
+//	go run ./cmd/catalog onix -file feed.xml [-subjects map.json] [-dry-run]
+//	go run ./cmd/catalog calibre -library ~/Calibre\ Library [-mark-read-for someone@example.com] [-uploads ./uploads] [-dry-run]
+//
+// openlibrary, onix และ calibre upsert ตาม ISBN จึงรันซ้ำกับไฟล์เดิมได้โดยไม่เกิดหนังสือซ้ำ
+// dump ของ Open Library ทั้งชุดใหญ่มาก ควรกรองให้เหลือเฉพาะ edition ที่ต้องการก่อน
+//
+// import จะสร้างดัชนีค้นหาใหม่ให้ด้วย ควรรันตอนที่ server ไม่ได้ทำงาน
+// หรือรีสตาร์ต server หลังรันเพื่อให้โหลดดัชนีใหม่
+package main
+
+import (
+	"back/catalog"
+	"back/config"
+	"back/history"
+	"back/media"
+	"back/models"
+	"back/search"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: catalog import|export|openlibrary|onix|calibre [flags]")
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "import":
+		runImport(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	case "openlibrary":
+		runOpenLibrary(os.Args[2:])
+	case "onix":
+		runONIX(os.Args[2:])
+	case "calibre":
+		runCalibre(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q, use import, export, openlibrary, onix or calibre\n", os.Args[1])
+		os.Exit(2)
+	}
+}
+
+func runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	path := flags.String("file", "", "CSV or JSONL file to import")
+	format := flags.String("format", "", "csv or jsonl (default: from file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing to the database")
+	flags.Parse(args)
+
+	if *path == "" {
+		log.Fatal("-file is required")
+	}
+	if *format == "" {
+		*format = catalog.FormatFromName(*path)
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := catalog.Parse(file, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config.ConnectDB()
+	importRows(rows, *dryRun)
+}
+
+func runOpenLibrary(args []string) {
+	flags := flag.NewFlagSet("openlibrary", flag.ExitOnError)
+	editionsPath := flags.String("editions", "", "Open Library editions dump (TSV or JSONL)")
+	worksPath := flags.String("works", "", "Open Library works dump")
+	authorsPath := flags.String("authors", "", "Open Library authors dump")
+	subjectsPath := flags.String("subjects", "", "JSON file mapping subjects to genres and tags")
+	unmappedTags := flags.Bool("unmapped-tags", false, "import unknown subjects as new tags")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing to the database")
+	flags.Parse(args)
+
+	if *editionsPath == "" {
+		log.Fatal("-editions is required")
+	}
+	if *authorsPath == "" {
+		log.Println("warning: no -authors dump, books will be imported without author names")
+	}
+
+	config.ConnectDB()
+	mapper, err := catalog.LoadSubjectMapper(context.Background(), *subjectsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mapper.UnmappedAsTags = *unmappedTags
+
+	editions := openFile(*editionsPath)
+	defer editions.Close()
+	works := openFile(*worksPath)
+	defer works.Close()
+	authors := openFile(*authorsPath)
+	defer authors.Close()
+
+	rows, err := catalog.ParseOpenLibrary(editions, optionalReader(works), optionalReader(authors), mapper)
+	if err != nil {
+		log.Fatal(err)
+	}
+	importRows(rows, *dryRun)
+}
+
+func runONIX(args []string) {
+	flags := flag.NewFlagSet("onix", flag.ExitOnError)
+	path := flags.String("file", "", "ONIX 3.0 file with reference tags")
+	subjectsPath := flags.String("subjects", "", "JSON file mapping subjects (headings or BISAC/Thema codes) to genres and tags")
+	unmappedTags := flags.Bool("unmapped-tags", false, "import unknown subjects as new tags")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing to the database")
+	flags.Parse(args)
+
+	if *path == "" {
+		log.Fatal("-file is required")
+	}
+
+	config.ConnectDB()
+	mapper, err := catalog.LoadSubjectMapper(context.Background(), *subjectsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mapper.UnmappedAsTags = *unmappedTags
+
+	file := openFile(*path)
+	defer file.Close()
+	rows, err := catalog.ParseONIX(file, mapper)
+	if err != nil {
+		log.Fatal(err)
+	}
+	importRows(rows, *dryRun)
+}
+
+func runCalibre(args []string) {
+	flags := flag.NewFlagSet("calibre", flag.ExitOnError)
+	library := flags.String("library", "", "Calibre library directory (the one containing metadata.db)")
+	email := flags.String("mark-read-for", "", "email of the user to create \"read\" marks for")
+	uploads := flags.String("uploads", media.UploadDir, "directory served as /uploads by the server")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing to the database or copying covers")
+	flags.Parse(args)
+
+	if *library == "" {
+		log.Fatal("-library is required")
+	}
+	media.UploadDir = *uploads
+
+	config.ConnectDB()
+	if !*dryRun {
+		var err error
+		if search.Default, err = search.Open(config.SearchIndexPath()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	opts := catalog.CalibreOptions{
+		DryRun: *dryRun,
+		Progress: func(report models.ImportReport) {
+			fmt.Fprintf(os.Stderr, "%d/%d books\n", report.Processed, report.Total)
+		},
+	}
+	if *email != "" {
+		var user models.User
+		err := config.DB.Database("bookwarm").Collection("users").FindOne(context.Background(), bson.M{"email": *email}).Decode(&user)
+		if err != nil {
+			log.Fatalf("no user with email %s", *email)
+		}
+		opts.MarkReadFor = &user.ID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+	report, err := catalog.ImportCalibre(ctx, *library, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flushIndex()
+	printReport(report)
+}
+
+// openFile คืน nil ถ้าไม่ได้ระบุ path (Close ของ *os.File ที่เป็น nil ไม่ panic)
+func openFile(path string) *os.File {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return file
+}
+
+// optionalReader กัน *os.File ที่เป็น nil กลายเป็น io.Reader ที่ไม่ใช่ nil
+func optionalReader(file *os.File) io.Reader {
+	if file == nil {
+		return nil
+	}
+	return file
+}
+
+func importRows(rows []catalog.ParsedRow, dryRun bool) {
+	var err error
+	if !dryRun {
+		if search.Default, err = search.Open(config.SearchIndexPath()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+	report, err := catalog.Import(ctx, rows, catalog.Options{
+		DryRun: dryRun,
+		Progress: func(report models.ImportReport) {
+			fmt.Fprintf(os.Stderr, "%d/%d rows\n", report.Processed, report.Total)
+		},
+		Editor: history.Editor{Name: "cmd/catalog"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	flushIndex()
+	printReport(report)
+}
+
+// flushIndex บันทึกการแก้ดัชนีค้นหาที่ยังค้างอยู่ก่อนจบโปรแกรม
+func flushIndex() {
+	if search.Default == nil {
+		return
+	}
+	if err := search.Default.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printReport(report models.ImportReport) {
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(report)
+}
+
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", catalog.FormatCSV, "csv or jsonl")
+	path := flags.String("out", "", "output file (default: stdout)")
+	flags.Parse(args)
+
+	out := os.Stdout
+	if *path != "" {
+		file, err := os.Create(*path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		out = file
+	}
+	writer, err := catalog.NewWriter(out, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config.ConnectDB()
+	count, err := catalog.Export(context.Background(), writer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d books\n", count)
+}
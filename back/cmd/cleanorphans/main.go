@@ -0,0 +1,73 @@
+// cleanorphans เก็บกวาดรีวิว mark และการอ้างถึงในโพสต์ของหนังสือที่ถูกลบไปก่อน
+// DELETE /api/books/:id จะลบข้อมูลเหล่านี้ให้ด้วย ใช้วิธีเดียวกันคือ catalog.DeleteBook
+//
+//	go run ./cmd/cleanorphans -dry-run
+//	go run ./cmd/cleanorphans -archive
+package main
+
+import (
+	"back/catalog"
+	"back/config"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func main() {
+	archive := flag.Bool("archive", false, "move reviews and marks to reviews_archive/marks_archive instead of deleting them")
+	dryRun := flag.Bool("dry-run", false, "only list books that are referenced but missing")
+	flag.Parse()
+
+	config.ConnectDB()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	db := config.DB.Database("bookwarm")
+
+	referenced := map[primitive.ObjectID]bool{}
+	for _, collection := range []string{"reviews", "marks", "post"} {
+		ids, err := db.Collection(collection).Distinct(ctx, "book_id", bson.M{"book_id": bson.M{"$type": "objectId"}})
+		if err != nil {
+			log.Fatalf("failed to read %s: %v", collection, err)
+		}
+		for _, id := range ids {
+			if oid, ok := id.(primitive.ObjectID); ok {
+				referenced[oid] = true
+			}
+		}
+	}
+	ids := make([]primitive.ObjectID, 0, len(referenced))
+	for id := range referenced {
+		ids = append(ids, id)
+	}
+	existing, err := db.Collection("books").Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, id := range existing {
+		delete(referenced, id.(primitive.ObjectID))
+	}
+
+	if len(referenced) == 0 {
+		fmt.Println("No orphaned references found")
+		return
+	}
+	for id := range referenced {
+		if *dryRun {
+			fmt.Printf("%s: missing book\n", id.Hex())
+			continue
+		}
+		report, err := catalog.DeleteBook(ctx, id, catalog.DeleteOptions{Archive: *archive})
+		if err != nil && err != catalog.ErrBookNotFound {
+			log.Fatalf("failed to clean up book %s: %v", id.Hex(), err)
+		}
+		fmt.Printf("%s: reviews %d deleted/%d archived, marks %d deleted/%d archived/%d moved, posts %d unlinked\n",
+			id.Hex(), report.ReviewsDeleted, report.ReviewsArchived,
+			report.MarksDeleted, report.MarksArchived, report.MarksMoved, report.PostsUnlinked)
+	}
+	fmt.Printf("%d missing books\n", len(referenced))
+}
@@ -0,0 +1,43 @@
+// dedupemarks ลบ mark ที่ซ้ำกันในงานเดียวกันของผู้ใช้คนเดียว เก็บไว้เฉพาะอันที่แก้ล่าสุด
+// ต้องรันก่อน unique index (user_id, work_id) ของ marks จะสร้างได้บนข้อมูลเก่า รันซ้ำได้
+//
+//	go run ./cmd/dedupemarks [-dry-run]
+package main
+
+import (
+	"back/catalog"
+	"back/config"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count the marks that would be removed")
+	flag.Parse()
+
+	config.ConnectDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	stale, err := catalog.DuplicateMarks(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *dryRun || len(stale) == 0 {
+		fmt.Printf("%d duplicate marks would be removed\n", len(stale))
+		return
+	}
+
+	result, err := config.DB.Database("bookwarm").Collection("marks").DeleteMany(ctx, bson.M{"_id": bson.M{"$in": stale}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Removed %d duplicate marks\n", result.DeletedCount)
+	config.EnsureIndexes()
+}
@@ -0,0 +1,49 @@
+// migratecategoryfield ย้ายค่า categoryId ที่ UpdateBook รุ่นเก่าเขียนไว้ไปที่ category_id
+// (ฟิลด์ที่ทุกที่อ่าน) แล้วลบ categoryId ทิ้ง categoryId เป็นค่าที่ใหม่กว่าจึงทับ category_id เดิม
+// รันซ้ำได้โดยไม่แก้หนังสือที่ย้ายแล้ว
+//
+//	go run ./cmd/migratecategoryfield [-dry-run]
+package main
+
+import (
+	"back/config"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count the books that would be migrated")
+	flag.Parse()
+
+	config.ConnectDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	collection := config.DB.Database("bookwarm").Collection("books")
+	filter := bson.M{"categoryId": bson.M{"$exists": true}}
+
+	if *dryRun {
+		count, err := collection.CountDocuments(ctx, filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d books would be migrated\n", count)
+		return
+	}
+
+	update := []bson.M{
+		{"$set": bson.M{"category_id": "$categoryId"}},
+		{"$unset": "categoryId"},
+	}
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Migrated %d books\n", result.ModifiedCount)
+}
@@ -0,0 +1,53 @@
+// migratecontributors สร้าง contributors ให้หนังสือที่ยังมีแค่ authorId
+// โดยใส่ผู้แต่งเดิมเป็น contributor บทบาท author รันซ้ำได้โดยไม่แก้หนังสือที่ย้ายแล้ว
+//
+//	go run ./cmd/migratecontributors [-dry-run]
+package main
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count the books that would be migrated")
+	flag.Parse()
+
+	config.ConnectDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	collection := config.DB.Database("bookwarm").Collection("books")
+	filter := bson.M{
+		"contributors": bson.M{"$in": []interface{}{nil, bson.A{}}},
+		"authorId":     bson.M{"$exists": true, "$ne": primitive.NilObjectID},
+	}
+
+	if *dryRun {
+		count, err := collection.CountDocuments(ctx, filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d books would be migrated\n", count)
+		return
+	}
+
+	// update แบบ pipeline เพื่ออ้างถึง authorId ของแต่ละเอกสารได้ในคำสั่งเดียว
+	update := []bson.M{{"$set": bson.M{
+		"contributors": bson.A{bson.M{"authorId": "$authorId", "role": models.RoleAuthor}},
+	}}}
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Migrated %d books\n", result.ModifiedCount)
+}
@@ -0,0 +1,39 @@
+// mockoidc คือ OpenID Connect issuer จำลองสำหรับทดสอบ social login แบบ offline (ดู package oidctest)
+//
+//	go run ./cmd/mockoidc -addr :9000 -email reader@example.com
+//
+// แล้วตั้งค่า backend ด้วย
+//
+//	OIDC_PROVIDERS=mock
+//	OIDC_MOCK_ISSUER=http://localhost:9000
+//	OIDC_MOCK_CLIENT_ID=bookwarm
+//	OIDC_MOCK_REDIRECT_URL=http://localhost:8080/api/auth/oidc/mock/callback
+//
+// หน้า /authorize จะอนุมัติทันทีโดยไม่ถามอะไร และตรวจ PKCE (S256) ที่ /token
+package main
+
+import (
+	"back/utils/oidctest"
+	"flag"
+	"log"
+	"net/http"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuerURL := flag.String("issuer", "http://localhost:9000", "issuer URL advertised in discovery and tokens")
+	email := flag.String("email", "reader@example.com", "email claim of the signed-in user")
+	subject := flag.String("sub", "mock-user-1", "sub claim of the signed-in user")
+	name := flag.String("name", "Mock Reader", "name claim of the signed-in user")
+	verified := flag.Bool("email-verified", true, "email_verified claim")
+	flag.Parse()
+
+	issuer, err := oidctest.New(*issuerURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	issuer.Email, issuer.Subject, issuer.Name, issuer.EmailVerified = *email, *subject, *name, *verified
+
+	log.Printf("mock OIDC issuer %s listening on %s", *issuerURL, *addr)
+	log.Fatal(http.ListenAndServe(*addr, issuer.Handler()))
+}
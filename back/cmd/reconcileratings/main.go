@@ -0,0 +1,44 @@
+// reconcileratings คำนวณ avg_rating, review_count และ rating_histogram ของหนังสือใหม่จาก reviews
+// แล้วแก้เฉพาะเล่มที่ค่าที่เก็บไว้ไม่ตรง รันครั้งแรกหลังอัปเดตเพื่อเติมค่าให้หนังสือเดิม
+// และรันซ้ำได้ทุกเมื่อ (เช่น หลังแก้รีวิวในฐานข้อมูลโดยตรง)
+//
+//	go run ./cmd/reconcileratings
+//	go run ./cmd/reconcileratings -book 665f1c2e8a1b2c3d4e5f6789
+package main
+
+import (
+	"back/config"
+	"back/ratings"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func main() {
+	bookHex := flag.String("book", "", "only reconcile this book ID")
+	flag.Parse()
+
+	var bookIDs []primitive.ObjectID
+	if *bookHex != "" {
+		id, err := primitive.ObjectIDFromHex(*bookHex)
+		if err != nil {
+			log.Fatalf("invalid book ID %q", *bookHex)
+		}
+		bookIDs = []primitive.ObjectID{id}
+	}
+
+	config.ConnectDB()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	fixed, err := ratings.Recompute(ctx, bookIDs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Fixed rating aggregates of %d books in %s\n", fixed, time.Since(start).Round(time.Millisecond))
+}
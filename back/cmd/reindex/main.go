@@ -0,0 +1,34 @@
+// reindex สร้างดัชนีค้นหาใหม่ทั้งหมดจาก MongoDB
+//
+//	go run ./cmd/reindex
+//
+// ใช้ตอนที่ server ไม่ได้ทำงานเท่านั้น เพราะ server โหลดไฟล์ดัชนีตอนเริ่มและบันทึกทับเป็นรอบ
+// ถ้า server ทำงานอยู่ให้เรียก POST /api/admin/search/reindex แทน
+package main
+
+import (
+	"back/config"
+	"back/search"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+func main() {
+	config.ConnectDB()
+
+	index, err := search.Open(config.SearchIndexPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	if err := search.Rebuild(ctx, index); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Indexed %d documents into %s in %s\n", index.Len(), config.SearchIndexPath(), time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,47 @@
+// setrole กำหนด role ของผู้ใช้จากอีเมล ใช้ตั้ง admin คนแรก (ไม่มี API สำหรับเรื่องนี้)
+//
+//	go run ./cmd/setrole -email someone@example.com -role admin
+//	go run ./cmd/setrole -email someone@example.com -role ""
+package main
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the user")
+	role := flag.String("role", models.UserRoleAdmin, `role to set ("admin" or "" for a regular user)`)
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+	if *role != "" && *role != models.UserRoleAdmin {
+		log.Fatalf("unknown role %q", *role)
+	}
+
+	config.ConnectDB()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"role": *role, "updated_at": time.Now()}}
+	if *role == "" {
+		update = bson.M{"$unset": bson.M{"role": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+	result, err := config.DB.Database("bookwarm").Collection("users").UpdateOne(ctx, bson.M{"email": *email}, update)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if result.MatchedCount == 0 {
+		log.Fatalf("no user with email %s", *email)
+	}
+	fmt.Printf("Set role of %s to %q\n", *email, *role)
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ชื่อ index ที่โค้ดใช้แยกว่า duplicate key error มาจาก constraint ไหน
+const (
+	IndexISBN13         = "isbn13_unique"
+	IndexRevisionNumber = "book_id_1_number_-1"
+)
+
+// IsDuplicateKey บอกว่า err เป็น duplicate key error ของ index ชื่อ index
+func IsDuplicateKey(err error, index string) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCodeWithMessage(11000, "index: "+index+" ")
+}
+
+// EnsureIndexes สร้าง index ที่โค้ดต้องพึ่ง (เช่น unique constraint) ถ้ามีอยู่แล้วจะไม่ทำอะไร
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db := DB.Database("bookwarm")
+	indexes := map[string][]mongo.IndexModel{
+		"books": {
+			{
+				// ISBN ไม่บังคับ จึงให้ unique เฉพาะหนังสือที่มี ISBN
+				Keys: bson.D{{Key: "isbn13", Value: 1}},
+				Options: options.Index().SetName(IndexISBN13).SetUnique(true).
+					SetPartialFilterExpression(bson.M{"isbn13": bson.M{"$type": "string"}}),
+			},
+		},
+		"marks": {
+			// ช่วงเวลาที่ package trending ใช้นับกิจกรรม
+			{Keys: bson.D{{Key: "updated_at", Value: -1}}},
+			// ผู้ใช้มี mark ได้หนึ่งอันต่องาน (ดู catalog.SyncMarkWork) ข้อมูลเก่าที่ซ้ำให้รัน cmd/dedupemarks ก่อน
+			{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "work_id", Value: 1}},
+				Options: options.Index().SetName("user_work_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"work_id": bson.M{"$type": "objectId"}}),
+			},
+		},
+		"reviews": {{Keys: bson.D{{Key: "review_date", Value: -1}}}},
+		"post":    {{Keys: bson.D{{Key: "created_at", Value: -1}}}},
+		"book_revisions": {
+			{Keys: bson.D{{Key: "book_id", Value: 1}, {Key: "number", Value: -1}}, Options: options.Index().SetName(IndexRevisionNumber).SetUnique(true)},
+		},
+		"book_submissions": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "submitted_by", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"audit_log": {
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "entity_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"notifications": {{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}}},
+		// state ของ social login ที่ผู้ใช้ไม่ได้กลับมาที่ callback ถูกลบเมื่อถึง expires_at
+		"oidc_states": {{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)}},
+		"club_joins": {
+			{Keys: bson.D{{Key: "club_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "joined_at", Value: -1}}},
+		},
+	}
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			log.Printf("Failed to create indexes on %s: %v", collection, err)
+		}
+	}
+}
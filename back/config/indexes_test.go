@@ -0,0 +1,41 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func duplicateKey(collection, index string) error {
+	return mongo.WriteException{WriteErrors: []mongo.WriteError{{
+		Code:    11000,
+		Message: fmt.Sprintf("E11000 duplicate key error collection: bookwarm.%s index: %s dup key: { : 1 }", collection, index),
+	}}}
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		index string
+		want  bool
+	}{
+		{"isbn conflict", duplicateKey("books", IndexISBN13), IndexISBN13, true},
+		{"revision conflict", duplicateKey("book_revisions", IndexRevisionNumber), IndexRevisionNumber, true},
+		{"revision conflict is not an isbn conflict", duplicateKey("book_revisions", IndexRevisionNumber), IndexISBN13, false},
+		{"_id conflict", duplicateKey("books", "_id_"), IndexISBN13, false},
+		{"wrapped", fmt.Errorf("save: %w", duplicateKey("books", IndexISBN13)), IndexISBN13, true},
+		{"other write error", mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 2, Message: "index: isbn13_unique "}}}, IndexISBN13, false},
+		{"not a server error", errors.New("index: isbn13_unique dup key"), IndexISBN13, false},
+		{"nil", nil, IndexISBN13, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDuplicateKey(tt.err, tt.index); got != tt.want {
+				t.Fatalf("IsDuplicateKey = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
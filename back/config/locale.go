@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// DefaultLocale คือภาษาของฟิลด์ title/name/description เดิม และภาษาที่ใช้เมื่อเลือกภาษาไม่ได้
+// (ตั้งได้ด้วย DEFAULT_LOCALE, ค่าเริ่มต้น "th")
+func DefaultLocale() string {
+	if locale := strings.ToLower(strings.TrimSpace(os.Getenv("DEFAULT_LOCALE"))); locale != "" {
+		return locale
+	}
+	return "th"
+}
+
+// SupportedLocales คือภาษาที่ client เลือกได้ผ่าน ?lang= หรือ Accept-Language
+// (ตั้งได้ด้วย SUPPORTED_LOCALES คั่นด้วย comma, ค่าเริ่มต้น "th,en") มี DefaultLocale อยู่เสมอ
+func SupportedLocales() []string {
+	raw := os.Getenv("SUPPORTED_LOCALES")
+	if strings.TrimSpace(raw) == "" {
+		raw = "th,en"
+	}
+	locales := []string{DefaultLocale()}
+	seen := map[string]bool{locales[0]: true}
+	for _, locale := range strings.Split(raw, ",") {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if locale == "" || seen[locale] {
+			continue
+		}
+		seen[locale] = true
+		locales = append(locales, locale)
+	}
+	return locales
+}
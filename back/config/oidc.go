@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// OIDCProvider คือค่าตั้งของผู้ให้บริการ OpenID Connect หนึ่งราย (Google, Keycloak, ...)
+type OIDCProvider struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OIDCProviders อ่านรายชื่อผู้ให้บริการจาก OIDC_PROVIDERS (คั่นด้วย comma)
+// แล้วอ่านค่าของแต่ละรายจาก OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL และ OIDC_<NAME>_SCOPES
+func OIDCProviders() map[string]OIDCProvider {
+	providers := map[string]OIDCProvider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			IssuerURL:    strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       []string{"openid", "email", "profile"},
+		}
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			provider.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+		if provider.IssuerURL == "" || provider.ClientID == "" {
+			continue
+		}
+		providers[name] = provider
+	}
+	return providers
+}
+
+// OIDCSuccessRedirect คือหน้า frontend ที่จะ redirect กลับไปพร้อม token หลัง login สำเร็จ
+// ถ้าไม่ได้ตั้งไว้ callback จะตอบกลับเป็น JSON เหมือน /api/auth/login
+func OIDCSuccessRedirect() string {
+	return os.Getenv("OIDC_SUCCESS_REDIRECT")
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// RecommendPriorWeight คือจำนวนรีวิวสมมุติของ prior ใน Bayesian average (ตั้งได้ด้วย RECOMMEND_PRIOR_WEIGHT)
+// ยิ่งมากหนังสือที่มีรีวิวน้อยยิ่งถูกดึงเข้าหาค่าเฉลี่ยรวม ค่าเริ่มต้น 10
+func RecommendPriorWeight() float64 {
+	if weight, err := strconv.ParseFloat(os.Getenv("RECOMMEND_PRIOR_WEIGHT"), 64); err == nil && weight >= 0 {
+		return weight
+	}
+	return 10
+}
+
+// RecommendPriorMean คือคะแนนของ prior (ตั้งได้ด้วย RECOMMEND_PRIOR_MEAN ระหว่าง 1-5)
+// ถ้าไม่ได้ตั้ง ok เป็น false และผู้เรียกควรใช้ค่าเฉลี่ยของรีวิวทั้งหมดแทน
+func RecommendPriorMean() (mean float64, ok bool) {
+	if mean, err := strconv.ParseFloat(os.Getenv("RECOMMEND_PRIOR_MEAN"), 64); err == nil && mean >= 1 && mean <= 5 {
+		return mean, true
+	}
+	return 0, false
+}
+
+// RecommendHalfLifeDays คือจำนวนวันที่น้ำหนักของรีวิวลดลงครึ่งหนึ่งเมื่อเปิด time decay
+// (ตั้งได้ด้วย RECOMMEND_HALF_LIFE_DAYS, ค่าเริ่มต้น 180 วัน)
+func RecommendHalfLifeDays() float64 {
+	if days, err := strconv.ParseFloat(os.Getenv("RECOMMEND_HALF_LIFE_DAYS"), 64); err == nil && days > 0 {
+		return days
+	}
+	return 180
+}
+
+// RecommendRefreshInterval คือรอบการ build model ของคำแนะนำเฉพาะบุคคลใหม่
+// (ตั้งได้ด้วย RECOMMEND_REFRESH เช่น "30m", ค่าเริ่มต้น 1 ชั่วโมง)
+func RecommendRefreshInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("RECOMMEND_REFRESH")); err == nil && interval > 0 {
+		return interval
+	}
+	return time.Hour
+}
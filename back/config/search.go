@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// SearchIndexPath คือไฟล์ที่เก็บดัชนีค้นหา (ตั้งได้ด้วย SEARCH_INDEX_PATH)
+func SearchIndexPath() string {
+	if path := os.Getenv("SEARCH_INDEX_PATH"); path != "" {
+		return path
+	}
+	return "data/search.idx"
+}
+
+// AutocompleteRefreshInterval คือรอบการโหลดข้อมูล autocomplete ใหม่จากฐานข้อมูล
+// (ตั้งได้ด้วย AUTOCOMPLETE_REFRESH เช่น "2m", ค่าเริ่มต้น 5 นาที)
+func AutocompleteRefreshInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("AUTOCOMPLETE_REFRESH")); err == nil && interval > 0 {
+		return interval
+	}
+	return 5 * time.Minute
+}
+
+// SearchFlushInterval คือรอบการบันทึกดัชนีค้นหาที่ถูกแก้ไขลงไฟล์
+// (ตั้งได้ด้วย SEARCH_FLUSH_INTERVAL เช่น "10s", ค่าเริ่มต้น 30 วินาที)
+func SearchFlushInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("SEARCH_FLUSH_INTERVAL")); err == nil && interval > 0 {
+		return interval
+	}
+	return 30 * time.Second
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// รหัส IllegalOperation ที่ MongoDB standalone ตอบเมื่อเริ่ม transaction
+const errCodeIllegalOperation = 20
+
+var warnNoTransactions sync.Once
+
+// WithTransaction รัน fn ใน transaction (ต้องใช้ ctx ที่ส่งให้ fn กับทุกคำสั่งฐานข้อมูล)
+// ถ้า fn คืน error ทุกอย่างที่เขียนไปจะถูกยกเลิก
+// MongoDB แบบ standalone (เช่นเครื่อง dev) ไม่รองรับ transaction จะรัน fn ตรง ๆ แทน
+func WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := DB.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == errCodeIllegalOperation {
+		warnNoTransactions.Do(func() {
+			log.Println("MongoDB does not support transactions (standalone server), running without them")
+		})
+		return fn(ctx)
+	}
+	return err
+}
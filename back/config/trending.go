@@ -0,0 +1,15 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// TrendingRefreshInterval คือรอบการคำนวณอันดับหนังสือและคลับที่มาแรงใหม่
+// (ตั้งได้ด้วย TRENDING_REFRESH เช่น "5m", ค่าเริ่มต้น 10 นาที)
+func TrendingRefreshInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("TRENDING_REFRESH")); err == nil && interval > 0 {
+		return interval
+	}
+	return 10 * time.Minute
+}
@@ -2,16 +2,89 @@ package controllers
 
 import (
 	"back/config"
+	"back/i18n"
+	"back/media"
 	"back/models"
+	"back/search"
 	"context"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// รูปแบบวันเกิด/วันเสียชีวิตที่รับ เรียงจากละเอียดน้อยไปมาก
+var authorDateLayouts = []string{"2006", "2006-01", "2006-01-02"}
+
+func validAuthorDate(value string) bool {
+	for _, layout := range authorDateLayouts {
+		if len(layout) == len(value) {
+			_, err := time.Parse(layout, value)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// validateAuthor ตรวจและจัดรูปข้อมูลโปรไฟล์นักเขียน ชื่อเล่นที่ซ้ำกันหรือซ้ำกับชื่อหลักจะถูกตัดออก
+func validateAuthor(author *models.Author) error {
+	var err error
+	if author.Name, author.Names, err = i18n.Fold(author.Name, author.Names); err != nil {
+		return err
+	}
+	author.Name = strings.TrimSpace(author.Name)
+	if author.Name == "" {
+		return errors.New("name is required")
+	}
+	if author.Bio, author.Bios, err = i18n.Fold(author.Bio, author.Bios); err != nil {
+		return err
+	}
+	author.Bio = strings.TrimSpace(author.Bio)
+	author.Nationality = strings.TrimSpace(author.Nationality)
+	author.Photo = strings.TrimSpace(author.Photo)
+
+	seen := map[string]bool{strings.ToLower(author.Name): true}
+	aliases := []string{}
+	for _, alias := range author.Aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		if alias == "" || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		aliases = append(aliases, alias)
+	}
+	author.Aliases = aliases
+
+	for _, date := range []struct {
+		field string
+		value *string
+	}{{"birthDate", &author.BirthDate}, {"deathDate", &author.DeathDate}} {
+		*date.value = strings.TrimSpace(*date.value)
+		if *date.value != "" && !validAuthorDate(*date.value) {
+			return errors.New(date.field + " must be YYYY, YYYY-MM or YYYY-MM-DD")
+		}
+	}
+	// เทียบเท่าที่ความละเอียดเดียวกัน เช่น เกิด 1950-05 เสียชีวิต 1950 ถือว่าถูกต้อง
+	if n := min(len(author.BirthDate), len(author.DeathDate)); n > 0 && author.DeathDate[:n] < author.BirthDate[:n] {
+		return errors.New("deathDate must not be before birthDate")
+	}
+
+	author.Website = strings.TrimSpace(author.Website)
+	if author.Website != "" {
+		u, err := url.Parse(author.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("website must be an http or https URL")
+		}
+	}
+	return nil
+}
+
 func CreateAuthor(c *gin.Context){
 	var input models.Author
 	if err := c.ShouldBindJSON(&input); err != nil{
@@ -19,7 +92,15 @@ func CreateAuthor(c *gin.Context){
 		return
 	}
 
+	if err := validateAuthor(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
 	input.ID = primitive.NewObjectID()
+	input.PhotoSizes = nil
+	input.CreatedAt, input.UpdatedAt = &now, &now
 	collection := config.DB.Database("bookwarm").Collection("author")
 
 	_, err := collection.InsertOne(context.TODO(), input)
@@ -27,6 +108,7 @@ func CreateAuthor(c *gin.Context){
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	search.IndexAuthor(input.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Author created successfully"})
 }
@@ -42,6 +124,7 @@ func GetAllAuthor(c *gin.Context){
 
 	defer cursor.Close(context.TODO())
 
+	locale := contextLocale(c)
 	var authors []models.Author
 	for cursor.Next(context.TODO()){
 		var author models.Author
@@ -49,6 +132,8 @@ func GetAllAuthor(c *gin.Context){
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		author.Name = i18n.Pick(author.Names, author.Name, locale)
+		author.Bio = i18n.Pick(author.Bios, author.Bio, locale)
 		authors = append(authors, author)
 	}
 	c.JSON(http.StatusOK, authors)
@@ -69,13 +154,68 @@ func UpdateAuthor(c *gin.Context){
 		return
 	}
 
+	if err := validateAuthor(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	collection := config.DB.Database("bookwarm").Collection("author")
-	update := bson.M{"$set":bson.M{"name": input.Name, "update_at": time.Now()}}
+	var existing models.Author
+	err = collection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author"})
+		return
+	}
+
+	// PUT แทนที่โปรไฟล์ทั้งหมด ฟิลด์ที่ไม่ได้ส่งมาจะถูกลบ ยกเว้นรูปที่อัปโหลดไว้ถ้า photo ยังเป็นรูปนั้น
+	set := bson.M{"name": input.Name, "update_at": time.Now()}
+	unset := bson.M{}
+	for field, value := range map[string]string{
+		"bio": input.Bio, "photo": input.Photo, "birthDate": input.BirthDate, "deathDate": input.DeathDate,
+		"nationality": input.Nationality, "website": input.Website,
+	} {
+		if value == "" {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	if len(input.Aliases) > 0 {
+		set["aliases"] = input.Aliases
+	} else {
+		unset["aliases"] = ""
+	}
+	for field, values := range map[string]map[string]string{"names": input.Names, "bios": input.Bios} {
+		if len(values) > 0 {
+			set[field] = values
+		} else {
+			unset[field] = ""
+		}
+	}
+	stalePhoto := existing.PhotoSizes
+	for _, size := range existing.PhotoSizes {
+		if size.JPEG == input.Photo || size.WebP == input.Photo {
+			stalePhoto = nil
+		}
+	}
+	if len(stalePhoto) > 0 {
+		unset["photoSizes"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": objectID},update)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update author"})
 		return
 	}
+	media.Remove(stalePhoto)
+	search.IndexAuthor(objectID)
 	c.JSON(http.StatusOK, gin.H{"message": "Author updated successfully"})
 }
 
@@ -88,10 +228,13 @@ func DeleteAuthor(c *gin.Context){
 	}
 
 	collection := config.DB.Database("bookwarm").Collection("author")
-	_, err = collection.DeleteOne(context.TODO(),bson.M{"_id":objectID})
-	if err != nil {
+	var author models.Author
+	err = collection.FindOneAndDelete(context.TODO(),bson.M{"_id":objectID}).Decode(&author)
+	if err != nil && err != mongo.ErrNoDocuments {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete author"})
 		return
 	}
+	media.Remove(author.PhotoSizes)
+	search.RemoveAuthor(objectID)
 	c.JSON(http.StatusOK, gin.H{"message": "Author deleted successfully"})
 }
\ No newline at end of file
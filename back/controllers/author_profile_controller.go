@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"back/config"
+	"back/i18n"
+	"back/media"
+	"back/models"
+	"back/search"
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// authorBook คือหนังสือหนึ่งเล่มในบรรณานุกรมของนักเขียน พร้อมบทบาทของนักเขียนในเล่มนั้น
+type authorBook struct {
+	ID           primitive.ObjectID   `json:"id" bson:"_id"`
+	Title        string               `json:"title" bson:"title"`
+	Titles       map[string]string    `json:"titles,omitempty" bson:"titles,omitempty"`
+	CoverImage   string               `json:"coverImage" bson:"coverImage"`
+	PublishYear  int                  `json:"publishYear" bson:"publishYear"`
+	SeriesID     *primitive.ObjectID  `json:"seriesId,omitempty" bson:"seriesId,omitempty"`
+	SeriesNumber *float64             `json:"seriesNumber,omitempty" bson:"seriesNumber,omitempty"`
+	WorkID       *primitive.ObjectID  `json:"workId,omitempty" bson:"workId,omitempty"`
+	AvgRating    float64              `json:"avg_rating" bson:"avg_rating"`
+	ReviewCount  int                  `json:"review_count" bson:"review_count"`
+	RatingSum    int                  `json:"-" bson:"rating_sum"`
+	AuthorID     primitive.ObjectID   `json:"-" bson:"authorId"`
+	Contributors []models.Contributor `json:"-" bson:"contributors"`
+	Roles        []string             `json:"roles" bson:"-"`
+}
+
+// authorStats คำนวณจากหนังสือที่นักเขียนเป็นผู้แต่งหรือผู้แต่งร่วม งานแปลหรือภาพประกอบไม่นับ
+type authorStats struct {
+	BookCount    int     `json:"bookCount"`
+	AvgRating    float64 `json:"avgRating"` // เฉลี่ยจากทุกรีวิวของทุกเล่ม ไม่ใช่ค่าเฉลี่ยของค่าเฉลี่ย
+	RatingCount  int     `json:"ratingCount"`
+	TotalReaders int     `json:"totalReaders"` // ผู้ใช้ที่ mark ว่าอ่านแล้วหรือกำลังอ่านอย่างน้อยหนึ่งเล่ม
+}
+
+// authorRoles คืนบทบาทของนักเขียนในหนังสือ หนังสือเก่าที่ไม่มี contributors ถือว่าเป็นผู้แต่ง
+func authorRoles(book authorBook, authorID primitive.ObjectID) []string {
+	roles := []string{}
+	for _, contributor := range book.Contributors {
+		if contributor.AuthorID == authorID {
+			roles = append(roles, contributor.Role)
+		}
+	}
+	if len(roles) == 0 && book.AuthorID == authorID {
+		roles = append(roles, models.RoleAuthor)
+	}
+	return roles
+}
+
+// GetAuthorByID คืนโปรไฟล์นักเขียน บรรณานุกรมเรียงตามปีที่พิมพ์ (เล่มที่ไม่รู้ปีอยู่ท้าย) และสถิติรวม
+//
+//	GET /api/authors/:id
+func GetAuthorByID(c *gin.Context) {
+	authorID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+	ctx := context.TODO()
+	db := config.DB.Database("bookwarm")
+
+	var author models.Author
+	err = db.Collection("author").FindOne(ctx, bson.M{"_id": authorID}).Decode(&author)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author"})
+		return
+	}
+
+	cursor, err := db.Collection("books").Find(ctx, bson.M{"$or": []bson.M{
+		{"authorId": authorID},
+		{"contributors.authorId": authorID},
+	}}, options.Find().SetProjection(bson.M{
+		"title": 1, "titles": 1, "coverImage": 1, "publishYear": 1, "seriesId": 1, "seriesNumber": 1, "workId": 1,
+		"avg_rating": 1, "review_count": 1, "rating_sum": 1, "authorId": 1, "contributors": 1,
+	}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+		return
+	}
+	books := []authorBook{}
+	if err := cursor.All(ctx, &books); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+		return
+	}
+	locale := contextLocale(c)
+	author.Name = i18n.Pick(author.Names, author.Name, locale)
+	author.Bio = i18n.Pick(author.Bios, author.Bio, locale)
+	for i := range books {
+		books[i].Title = i18n.Pick(books[i].Titles, books[i].Title, locale)
+	}
+	sort.SliceStable(books, func(i, j int) bool {
+		a, b := books[i].PublishYear, books[j].PublishYear
+		if (a == 0) != (b == 0) {
+			return b == 0
+		}
+		if a != b {
+			return a < b
+		}
+		return books[i].Title < books[j].Title
+	})
+
+	var stats authorStats
+	var written []primitive.ObjectID
+	ratingSum := 0
+	for i := range books {
+		books[i].Roles = authorRoles(books[i], authorID)
+		for _, role := range books[i].Roles {
+			if role == models.RoleAuthor || role == models.RoleCoAuthor {
+				written = append(written, books[i].ID)
+				stats.RatingCount += books[i].ReviewCount
+				ratingSum += books[i].RatingSum
+				break
+			}
+		}
+	}
+	stats.BookCount = len(written)
+	if stats.RatingCount > 0 {
+		stats.AvgRating = math.Round(float64(ratingSum)/float64(stats.RatingCount)*100) / 100
+	}
+	if len(written) > 0 {
+		stats.TotalReaders, err = countReaders(ctx, written)
+		if err != nil {
+			log.Printf("Failed to count readers of author %s: %v", authorID.Hex(), err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"author": author, "books": books, "stats": stats})
+}
+
+// countReaders นับผู้ใช้ที่ mark หนังสือในรายการว่าอ่านแล้วหรือกำลังอ่าน คนเดียวอ่านหลายเล่มนับครั้งเดียว
+func countReaders(ctx context.Context, bookIDs []primitive.ObjectID) (int, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection("marks").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"book_id": bson.M{"$in": bookIDs}, "status": bson.M{"$in": []string{"read", "now reading"}}}},
+		{"$group": bson.M{"_id": "$user_id"}},
+		{"$count": "readers"},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var result []struct {
+		Readers int `bson:"readers"`
+	}
+	if err := cursor.All(ctx, &result); err != nil || len(result) == 0 {
+		return 0, err
+	}
+	return result[0].Readers, nil
+}
+
+// SearchAuthors ค้นหานักเขียนจากชื่อ (ทุกภาษา) หรือนามปากกา
+//
+//	GET /api/authors/search?query=&page=&limit=
+//
+// total_capped เป็น true เมื่อคำค้นตรงกับนักเขียนเกิน maxIndexHits คน
+func SearchAuthors(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("query"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+	page, limit := parsePagination(c)
+	ctx := context.TODO()
+	collection := config.DB.Database("bookwarm").Collection("author")
+
+	// ใช้ดัชนีค้นหา (ตัดคำไทยได้) เป็นหลัก ถ้าใช้ไม่ได้ค่อยถอยไปใช้ regex
+	useIndex, capped := false, false
+	var hitIDs []primitive.ObjectID
+	if search.Default != nil {
+		hitIDs, _, capped, useIndex = indexHits(search.TypeAuthor, query)
+	}
+
+	authors := []models.Author{}
+	var total int64
+	if useIndex {
+		total = int64(len(hitIDs))
+		start := min((page-1)*limit, len(hitIDs))
+		pageIDs := hitIDs[start:min(start+limit, len(hitIDs))]
+		if len(pageIDs) > 0 {
+			cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": pageIDs}})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search authors"})
+				return
+			}
+			var found []models.Author
+			if err := cursor.All(ctx, &found); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search authors"})
+				return
+			}
+			byID := make(map[primitive.ObjectID]models.Author, len(found))
+			for _, author := range found {
+				byID[author.ID] = author
+			}
+			// เรียงตามคะแนนของดัชนี
+			for _, id := range pageIDs {
+				if author, ok := byID[id]; ok {
+					authors = append(authors, author)
+				}
+			}
+		}
+	} else {
+		pattern := regexp.QuoteMeta(query)
+		conds := []bson.M{
+			{"name": bson.M{"$regex": pattern, "$options": "i"}},
+			{"aliases": bson.M{"$regex": pattern, "$options": "i"}},
+		}
+		for _, locale := range config.SupportedLocales()[1:] {
+			conds = append(conds, bson.M{"names." + locale: bson.M{"$regex": pattern, "$options": "i"}})
+		}
+		filter := bson.M{"$or": conds}
+		var err error
+		if total, err = collection.CountDocuments(ctx, filter); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search authors"})
+			return
+		}
+		cursor, err := collection.Find(ctx, filter, options.Find().
+			SetSort(bson.D{{Key: "name", Value: 1}}).
+			SetSkip(int64((page-1)*limit)).
+			SetLimit(int64(limit)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search authors"})
+			return
+		}
+		if err := cursor.All(ctx, &authors); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search authors"})
+			return
+		}
+	}
+
+	locale := contextLocale(c)
+	for i := range authors {
+		authors[i].Name = i18n.Pick(authors[i].Names, authors[i].Name, locale)
+		authors[i].Bio = i18n.Pick(authors[i].Bios, authors[i].Bio, locale)
+	}
+	c.JSON(http.StatusOK, gin.H{"authors": authors, "total": total, "total_capped": capped, "page": page, "limit": limit})
+}
+
+// UploadAuthorPhoto รับรูปนักเขียน (multipart field "photo") ย่อเป็นทุกขนาดใน media.AuthorPhotoVariants
+// แล้วตั้ง photo เป็น JPEG ขนาดใหญ่สุด ไฟล์ของรูปเดิมจะถูกลบ
+//
+//	POST /api/authors/:id/photo
+func UploadAuthorPhoto(c *gin.Context) {
+	authorID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+
+	collection := config.DB.Database("bookwarm").Collection("author")
+	var author models.Author
+	err = collection.FindOne(context.TODO(), bson.M{"_id": authorID}).Decode(&author)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author"})
+		return
+	}
+
+	data, err := readImageUpload(c, "photo")
+	if errors.Is(err, media.ErrImageTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo file is required"})
+		return
+	}
+	img, err := media.Decode(data)
+	if err != nil {
+		c.JSON(imageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	sizes, err := media.SaveVariants(img, "authors", authorID.Hex(), media.AuthorPhotoVariants)
+	if err != nil {
+		log.Printf("Failed to save photo of author %s: %v", authorID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+		return
+	}
+	photo := sizes[len(sizes)-1].JPEG
+
+	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": authorID}, bson.M{"$set": bson.M{
+		"photo":      photo,
+		"photoSizes": sizes,
+	}})
+	if err != nil {
+		media.Remove(sizes)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update author photo"})
+		return
+	}
+	media.Remove(author.PhotoSizes)
+
+	c.JSON(http.StatusOK, gin.H{"photo": photo, "photoSizes": sizes})
+}
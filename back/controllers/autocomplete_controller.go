@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"back/i18n"
+	"back/search"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// โควตาผลลัพธ์ต่อประเภท เพื่อให้หนังสือไม่กินที่ของนักเขียน คลับ และผู้ใช้จนหมด
+var autocompleteQuota = map[string]int{
+	search.TypeBook:   5,
+	search.TypeAuthor: 3,
+	search.TypeClub:   3,
+	search.TypeUser:   3,
+}
+
+// Autocomplete คืนคำแนะนำระหว่างพิมพ์จากดัชนีในหน่วยความจำ
+//
+//	GET /api/autocomplete?q=&types=book,author,club,user&limit=
+func Autocomplete(c *gin.Context) {
+	start := time.Now()
+	query := strings.TrimSpace(c.Query("q"))
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 20 {
+		limit = 20
+	}
+
+	perType := autocompleteQuota
+	if raw := c.Query("types"); raw != "" {
+		perType = map[string]int{}
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			quota, ok := autocompleteQuota[t]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown type: " + t})
+				return
+			}
+			perType[t] = quota
+		}
+		// ขอประเภทเดียวให้ใช้ limit ได้เต็ม
+		if len(perType) == 1 {
+			for t := range perType {
+				perType[t] = limit
+			}
+		}
+	}
+
+	results := []search.Suggestion{}
+	if query != "" {
+		results = search.Suggestions.Complete(query, perType, limit)
+	}
+	locale := contextLocale(c)
+	for i := range results {
+		results[i].Label = i18n.Pick(results[i].Labels, results[i].Label, locale)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"results": results,
+		"took_ms": float64(time.Since(start).Microseconds()) / 1000,
+	})
+}
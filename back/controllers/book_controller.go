@@ -1,11 +1,19 @@
 package controllers
 
 import (
+	"back/catalog"
 	"back/config"
+	"back/history"
+	"back/i18n"
+	"back/media"
 	"back/models"
+	"back/ratings"
+	"back/search"
 	"context"
-	"math"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,22 +33,223 @@ func CreateBook(c *gin.Context) {
 	input.ID = primitive.NewObjectID()
 	input.CreatedAt = time.Now()
 	input.UpdatedAt = time.Now()
+	// คะแนนมาจากรีวิวเท่านั้น (ดู package ratings) ไม่รับค่าจาก client
+	input.Rating, input.AvgRating, input.ReviewCount, input.RatingSum = 0, 0, 0, 0
+	input.RatingCounts = ratings.EmptyHistogram()
+
+	if !checkNewBook(c, &input) {
+		return
+	}
 
 	collection := config.DB.Database("bookwarm").Collection("books")
-	_, err := collection.InsertOne(context.TODO(), input)
+	err := history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			if _, err := collection.InsertOne(ctx, input); err != nil {
+				return err
+			}
+			_, err := history.Record(ctx, nil, input, history.Entry{Action: models.RevisionCreate, Editor: contextEditor(c)})
+			return err
+		})
+	})
+	if config.IsDuplicateKey(err, config.IndexISBN13) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A book with this ISBN already exists"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create book"})
 		return
 	}
+	search.IndexBook(input.ID)
+
+	// ไม่บล็อกการสร้าง แต่แจ้งหนังสือที่น่าจะซ้ำให้ผู้แก้ไขตรวจ
+	duplicates, err := findDuplicateBooks(context.TODO(), input.Title, bookAuthorIDs(input), input.ID)
+	if err != nil {
+		log.Printf("Failed to check duplicates of book %s: %v", input.ID.Hex(), err)
+	}
 
-	c.JSON(http.StatusOK, input)
+	c.JSON(http.StatusOK, struct {
+		models.Book
+		DuplicateWarnings []duplicateCandidate `json:"duplicateWarnings,omitempty"`
+	}{input, duplicates})
 }
 
-func GetAllBooks(c *gin.Context) {
-	collection := config.DB.Database("bookwarm").Collection("books")
+// checkNewBook ตรวจหนังสือที่จะเพิ่มใหม่แบบเดียวกับ CreateBook ถ้าไม่ผ่านจะตอบ error แล้วคืน false
+func checkNewBook(c *gin.Context, book *models.Book) bool {
+	if err := foldBookText(book); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err := normalizeContributors(book); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err := validateBookRefs(context.TODO(), book); err != nil {
+		bookRefErrorResponse(c, err)
+		return false
+	}
+	if err := validateSeriesPlacement(context.TODO(), book.ID, book.SeriesID, book.SeriesNumber); err != nil {
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return false
+	}
+	if err := applyISBN(book); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err := validateEdition(context.TODO(), book); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// foldBookText ตรวจชื่อเรื่องและคำอธิบายหลายภาษา ข้อความภาษาหลักย้ายไปอยู่ที่ title/description (ดู i18n.Fold)
+func foldBookText(book *models.Book) error {
+	var err error
+	if book.Title, book.Titles, err = i18n.Fold(book.Title, book.Titles); err != nil {
+		return err
+	}
+	book.Description, book.Descriptions, err = i18n.Fold(book.Description, book.Descriptions)
+	return err
+}
+
+// normalizeContributors ตรวจบทบาทของ contributors และทำให้ authorId กับ contributors สอดคล้องกัน
+// ถ้าส่งมาแค่ authorId จะสร้าง contributors ให้ ถ้าส่ง contributors มา authorId จะเป็นผู้แต่งคนแรก
+func normalizeContributors(book *models.Book) error {
+	seen := map[string]bool{}
+	contributors := make([]models.Contributor, 0, len(book.Contributors)+1)
+	for _, contributor := range book.Contributors {
+		if contributor.AuthorID.IsZero() {
+			return errors.New("contributor authorId is required")
+		}
+		if contributor.Role == "" {
+			contributor.Role = models.RoleAuthor
+		}
+		if !models.IsContributorRole(contributor.Role) {
+			return fmt.Errorf("invalid contributor role %q, must be one of %s", contributor.Role, strings.Join(models.ContributorRoles, ", "))
+		}
+		key := contributor.AuthorID.Hex() + ":" + contributor.Role
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		contributors = append(contributors, contributor)
+	}
 
-	// ใช้ pipeline เพื่อดึงข้อมูลทั้งหมด
-	pipeline := []bson.M{
+	primary := -1
+	for _, role := range []string{models.RoleAuthor, models.RoleCoAuthor} {
+		for i, contributor := range contributors {
+			if primary < 0 && contributor.Role == role {
+				primary = i
+			}
+		}
+	}
+	switch {
+	case primary >= 0:
+		book.AuthorID = contributors[primary].AuthorID
+	case !book.AuthorID.IsZero():
+		contributors = append([]models.Contributor{{AuthorID: book.AuthorID, Role: models.RoleAuthor}}, contributors...)
+	}
+	book.Contributors = contributors
+	return nil
+}
+
+// missingRefsError บอกว่า id ใดที่หนังสืออ้างถึงแต่ไม่มีอยู่จริง แยกตามฟิลด์
+type missingRefsError struct {
+	Missing map[string][]string
+}
+
+func (e *missingRefsError) Error() string {
+	fields := make([]string, 0, len(e.Missing))
+	for _, field := range []string{"authorId", "contributors", "category_id", "genres", "tagIds"} {
+		if ids, ok := e.Missing[field]; ok {
+			fields = append(fields, field+" ("+strings.Join(ids, ", ")+")")
+		}
+	}
+	return "referenced documents not found: " + strings.Join(fields, "; ")
+}
+
+// bookRefErrorResponse ตอบ 400 พร้อมรายการ id ที่ไม่มีอยู่ ถ้า err เป็น missingRefsError
+func bookRefErrorResponse(c *gin.Context, err error) {
+	var missing *missingRefsError
+	if errors.As(err, &missing) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "missing": missing.Missing})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate book references"})
+}
+
+// validateBookRefs ตรวจว่าผู้แต่ง หมวด genre และ tag ที่หนังสืออ้างถึงมีอยู่จริง
+// และตัด id ที่ซ้ำใน genres กับ tagIds (ซีรีส์และ work ตรวจใน validateSeriesPlacement และ validateEdition)
+func validateBookRefs(ctx context.Context, book *models.Book) error {
+	book.Genres = uniqueObjectIDs(book.Genres)
+	book.TagIDs = uniqueObjectIDs(book.TagIDs)
+
+	var contributorIDs []primitive.ObjectID
+	for _, contributor := range book.Contributors {
+		contributorIDs = append(contributorIDs, contributor.AuthorID)
+	}
+	checks := []struct {
+		field      string
+		collection string
+		ids        []primitive.ObjectID
+	}{
+		{"authorId", "author", nonZeroIDs(book.AuthorID)},
+		{"contributors", "author", uniqueObjectIDs(contributorIDs)},
+		{"category_id", "category", nonZeroIDs(book.CategoryID)},
+		{"genres", "genre", book.Genres},
+		{"tagIds", "tag", book.TagIDs},
+	}
+
+	missing := map[string][]string{}
+	for _, check := range checks {
+		if len(check.ids) == 0 {
+			continue
+		}
+		found, err := config.DB.Database("bookwarm").Collection(check.collection).Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": check.ids}})
+		if err != nil {
+			return err
+		}
+		exists := map[primitive.ObjectID]bool{}
+		for _, id := range found {
+			if oid, ok := id.(primitive.ObjectID); ok {
+				exists[oid] = true
+			}
+		}
+		for _, id := range check.ids {
+			if !exists[id] {
+				missing[check.field] = append(missing[check.field], id.Hex())
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return &missingRefsError{Missing: missing}
+	}
+	return nil
+}
+
+func nonZeroIDs(id primitive.ObjectID) []primitive.ObjectID {
+	if id.IsZero() {
+		return nil
+	}
+	return []primitive.ObjectID{id}
+}
+
+// uniqueObjectIDs ตัด id ที่ซ้ำโดยคงลำดับเดิม และคืน slice ว่างแทน nil เพื่อไม่ให้บันทึกเป็น null
+func uniqueObjectIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{}
+	out := []primitive.ObjectID{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// bookLookupStages ดึงข้อมูล author, category, genres และ tags มาแทน id ในเอกสารหนังสือ
+func bookLookupStages() []bson.M {
+	return []bson.M{
 		{
 			"$lookup": bson.M{
 				"from":         "author",
@@ -49,6 +258,34 @@ func GetAllBooks(c *gin.Context) {
 				"as":           "author",
 			},
 		},
+		{
+			"$lookup": bson.M{
+				"from":         "author",
+				"localField":   "contributors.authorId",
+				"foreignField": "_id",
+				"as":           "contributorAuthors",
+			},
+		},
+		{
+			// แทน authorId ของ contributors แต่ละคนด้วยเอกสารนักเขียน โดยคงลำดับเดิมไว้
+			"$addFields": bson.M{
+				"contributors": bson.M{"$map": bson.M{
+					"input": bson.M{"$ifNull": []interface{}{"$contributors", []interface{}{}}},
+					"as":    "c",
+					"in": bson.M{
+						"role": "$$c.role",
+						"author": bson.M{"$arrayElemAt": []interface{}{
+							bson.M{"$filter": bson.M{
+								"input": "$contributorAuthors",
+								"as":    "a",
+								"cond":  bson.M{"$eq": []interface{}{"$$a._id", "$$c.authorId"}},
+							}},
+							0,
+						}},
+					},
+				}},
+			},
+		},
 		{
 			"$lookup": bson.M{
 				"from":         "category",
@@ -74,9 +311,23 @@ func GetAllBooks(c *gin.Context) {
 			},
 		},
 		{
-			"$unset": []string{"authorId", "category_id", "tagIds"}, // ลบฟิลด์ที่ไม่ต้องการ
+			"$unset": []string{"authorId", "category_id", "tagIds", "contributorAuthors"}, // ลบฟิลด์ที่ไม่ต้องการ
 		},
 	}
+}
+
+func GetAllBooks(c *gin.Context) {
+	collection := config.DB.Database("bookwarm").Collection("books")
+
+	conds, err := contributorConditions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pipeline := bookLookupStages()
+	if len(conds) > 0 {
+		pipeline = append([]bson.M{{"$match": bson.M{"$and": conds}}}, pipeline...)
+	}
 
 	cursor, err := collection.Aggregate(context.TODO(), pipeline)
 	if err != nil {
@@ -91,6 +342,7 @@ func GetAllBooks(c *gin.Context) {
 		return
 	}
 
+	i18n.Localize(books, contextLocale(c))
 	c.JSON(http.StatusOK, books)
 }
 
@@ -104,48 +356,7 @@ func GetBookByID(c *gin.Context) {
 
 	collection := config.DB.Database("bookwarm").Collection("books")
 
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"_id": bookID,
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "author",
-				"localField":   "authorId",
-				"foreignField": "_id",
-				"as":           "author",
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "category",
-				"localField":   "category_id",
-				"foreignField": "_id",
-				"as":           "category",
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "genre",
-				"localField":   "genres",
-				"foreignField": "_id",
-				"as":           "genres",
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "tag",
-				"localField":   "tagIds",
-				"foreignField": "_id",
-				"as":           "tags",
-			},
-		},
-		{
-			"$unset": []string{"authorId", "category_id", "tagIds"}, // ลบฟิลด์ที่ไม่ต้องการ
-		},
-	}
+	pipeline := append([]bson.M{{"$match": bson.M{"_id": bookID}}}, bookLookupStages()...)
 
 	cursor, err := collection.Aggregate(context.TODO(), pipeline)
 	if err != nil {
@@ -166,6 +377,29 @@ func GetBookByID(c *gin.Context) {
 		return
 	}
 
+	// แนบข้อมูลซีรีส์และเล่มถัดไป (ใช้สถานะการอ่านของผู้ใช้ถ้า login อยู่)
+	if seriesID, ok := results[0]["seriesId"].(primitive.ObjectID); ok {
+		info, err := seriesInfo(context.TODO(), seriesID, bookID, contextUserID(c))
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Printf("Failed to load series %s: %v", seriesID.Hex(), err)
+		}
+		if info != nil {
+			results[0]["series"] = info
+		}
+	}
+
+	// แนบ edition อื่นของงานเดียวกันและคะแนนรวมของทุก edition
+	if workID, ok := results[0]["workId"].(primitive.ObjectID); ok {
+		info, err := workSummary(context.TODO(), workID)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Printf("Failed to load work %s: %v", workID.Hex(), err)
+		}
+		if info != nil {
+			results[0]["work"] = info
+		}
+	}
+
+	i18n.Localize(results[0], contextLocale(c))
 	c.JSON(http.StatusOK, results[0])
 }
 
@@ -182,75 +416,158 @@ func UpdateBook(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	saveBook(c, bookID, input, history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)})
+}
+
+// saveBook ตรวจและบันทึกหนังสือทั้งเล่มทับของเดิม แล้วตอบหนังสือที่บันทึกแล้ว ใช้ทั้ง PUT และ PATCH
+// ฟิลด์ที่คำนวณเอง (คะแนน, coverSizes, createdAt) ไม่ถูกแก้จาก input
+func saveBook(c *gin.Context, bookID primitive.ObjectID, input models.Book, entry history.Entry) {
+	collection := config.DB.Database("bookwarm").Collection("books")
+	var existing models.Book
+	err := collection.FindOne(context.TODO(), bson.M{"_id": bookID}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	// client ที่ส่งมาแค่ authorId (ไม่มี contributors) จะเปลี่ยนเฉพาะผู้แต่งหลัก
+	// ผู้มีส่วนร่วมคนอื่น เช่น ผู้แปล ยังอยู่เหมือนเดิม
+	if input.Contributors == nil {
+		input.Contributors = []models.Contributor{{AuthorID: input.AuthorID, Role: models.RoleAuthor}}
+		for _, contributor := range existing.Contributors {
+			if contributor.AuthorID == existing.AuthorID && contributor.Role == models.RoleAuthor {
+				continue
+			}
+			input.Contributors = append(input.Contributors, contributor)
+		}
+		if input.AuthorID.IsZero() {
+			input.Contributors = input.Contributors[1:]
+		}
+	}
+	// เช่นเดียวกัน client ที่ไม่ได้ส่ง titles/descriptions มาจะไม่ลบข้อความภาษาอื่นที่มีอยู่
+	if input.Titles == nil {
+		input.Titles = existing.Titles
+	}
+	if input.Descriptions == nil {
+		input.Descriptions = existing.Descriptions
+	}
+	if err := foldBookText(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeContributors(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateBookRefs(context.TODO(), &input); err != nil {
+		bookRefErrorResponse(c, err)
+		return
+	}
+	if err := validateSeriesPlacement(context.TODO(), bookID, input.SeriesID, input.SeriesNumber); err != nil {
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyISBN(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateEdition(context.TODO(), &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	input.UpdatedAt = time.Now()
 	update := bson.M{
 		"$set": bson.M{
-			"title":       input.Title,
-			"description": input.Description,
-			"authorId":    input.AuthorID,
-			"seriesId":    input.SeriesID,
-			"categoryId":  input.CategoryID,
-			"genres":      input.Genres,
-			"tagIds":      input.TagIDs,
-			"publishYear": input.PublishYear,
-			"pageCount":   input.PageCount,
-			"rating":      input.Rating,
-			"coverImage":  input.CoverImage,
-			"updatedAt":   input.UpdatedAt,
+			"title":        input.Title,
+			"description":  input.Description,
+			"titles":       input.Titles,
+			"descriptions": input.Descriptions,
+			"authorId":     input.AuthorID,
+			"contributors": input.Contributors,
+			"seriesId":     input.SeriesID,
+			"seriesNumber": input.SeriesNumber,
+			"isbn13":       input.ISBN13,
+			"isbn10":       input.ISBN10,
+			"workId":       input.WorkID,
+			"language":     input.Language,
+			"format":       input.Format,
+			"publisher":    input.Publisher,
+			"category_id":  input.CategoryID,
+			"genres":       input.Genres,
+			"tagIds":       input.TagIDs,
+			"publishYear":  input.PublishYear,
+			"pageCount":    input.PageCount,
+			"coverImage":   input.CoverImage,
+			"updatedAt":    input.UpdatedAt,
 		},
 	}
 
-	collection := config.DB.Database("bookwarm").Collection("books")
-	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": bookID}, update)
+	// ISBN ว่างต้องลบฟิลด์ออก ไม่เช่นนั้นจะชน unique index กับหนังสือเล่มอื่นที่ไม่มี ISBN
+	set := update["$set"].(bson.M)
+	// categoryId เป็นชื่อฟิลด์ผิดที่ UpdateBook รุ่นเก่าเขียนไว้ (ดู cmd/migratecategoryfield)
+	unset := bson.M{"categoryId": ""}
+	for _, field := range []string{"isbn13", "isbn10"} {
+		if set[field] == "" {
+			delete(set, field)
+			unset[field] = ""
+		}
+	}
+	if input.Titles == nil {
+		delete(set, "titles")
+		unset["titles"] = ""
+	}
+	if input.Descriptions == nil {
+		delete(set, "descriptions")
+		unset["descriptions"] = ""
+	}
+	staleCover := staleCoverSizes(context.TODO(), bookID, input.CoverImage)
+	if len(staleCover) > 0 {
+		unset["coverSizes"] = ""
+	}
+	update["$unset"] = unset
+
+	err = history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			result, err := collection.UpdateOne(ctx, bson.M{"_id": bookID}, update)
+			if err != nil {
+				return err
+			}
+			if result.MatchedCount == 0 {
+				return mongo.ErrNoDocuments
+			}
+			var after models.Book
+			if err := collection.FindOne(ctx, bson.M{"_id": bookID}).Decode(&after); err != nil {
+				return err
+			}
+			_, err = history.Record(ctx, &existing, after, entry)
+			return err
+		})
+	})
+	if config.IsDuplicateKey(err, config.IndexISBN13) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A book with this ISBN already exists"})
+		return
+	}
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update book"})
 		return
 	}
-
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"_id": bookID,
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "author",
-				"localField":   "authorId",
-				"foreignField": "_id",
-				"as":           "author",
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "category",
-				"localField":   "category_id",
-				"foreignField": "_id",
-				"as":           "category",
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "genre",
-				"localField":   "genres",
-				"foreignField": "_id",
-				"as":           "genres",
-			},
-		},
-		{
-			"$lookup": bson.M{
-				"from":         "tag",
-				"localField":   "tagIds",
-				"foreignField": "_id",
-				"as":           "tags",
-			},
-		},
-		{
-			"$unset": []string{"authorId", "category_id", "tagIds"}, 
-		},
+	search.IndexBook(bookID)
+	media.Remove(staleCover)
+	if err := catalog.SyncMarkWork(context.TODO(), []primitive.ObjectID{bookID}, input.WorkID); err != nil {
+		log.Printf("Failed to update marks of book %s: %v", bookID.Hex(), err)
 	}
 
+	pipeline := append([]bson.M{{"$match": bson.M{"_id": bookID}}}, bookLookupStages()...)
+
 	cursor, err := collection.Aggregate(context.TODO(), pipeline)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{"message": "Book updated successfully"})
@@ -270,41 +587,14 @@ func UpdateBook(c *gin.Context) {
 		return
 	}
 
+	i18n.Localize(results[0], contextLocale(c))
 	c.JSON(http.StatusOK, results[0])
 }
 
-func SearchBooks(c *gin.Context) {
-    query := c.Query("query")
-    if query == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Query is required"})
-        return
-    }
-
-    collection := config.DB.Database("bookwarm").Collection("books")
-
-    filter := bson.M{
-        "title": bson.M{
-            "$regex":   query,
-            "$options": "i", 
-        },
-    }
-
-    cursor, err := collection.Find(context.TODO(), filter)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding books"})
-        return
-    }
-
-    var books []bson.M
-    if err := cursor.All(context.TODO(), &books); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding books"})
-        return
-    }
-
-    c.JSON(http.StatusOK, books)
-}
-
-
+// DeleteBook ลบหนังสือพร้อมรีวิว mark การอ้างถึงในโพสต์ และไฟล์ปก (ดู catalog.DeleteBook)
+// แล้วตอบสรุปว่ามีข้อมูลใดได้รับผลบ้าง
+//
+//	DELETE /api/books/:id?archive=true
 func DeleteBook(c *gin.Context) {
 	idParam := c.Param("id")
 	bookID, err := primitive.ObjectIDFromHex(idParam)
@@ -313,100 +603,16 @@ func DeleteBook(c *gin.Context) {
 		return
 	}
 
-	collection := config.DB.Database("bookwarm").Collection("books")
-	_, err = collection.DeleteOne(context.TODO(), bson.M{"_id": bookID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete book"})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"message": "Book deleted successfully"})
-}
-
-func GetRecommendedBooks(c *gin.Context) {
-	log.Println("Getting recommended books...")
-	
-	pipeline := mongo.Pipeline{
-		{{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "reviews"},
-			{Key: "localField", Value: "_id"},
-			{Key: "foreignField", Value: "book_id"},
-			{Key: "as", Value: "reviews"},
-		}}},
-		{{Key: "$addFields", Value: bson.D{
-			{Key: "avg_rating", Value: bson.D{
-				{Key: "$avg", Value: "$reviews.rating"},
-			}},
-			{Key: "review_count", Value: bson.D{
-				{Key: "$size", Value: "$reviews"},
-			}},
-		}}},
-		{{Key: "$match", Value: bson.D{
-			{Key: "review_count", Value: bson.D{
-				{Key: "$gt", Value: 0},
-			}},
-		}}},
-		{{Key: "$sort", Value: bson.D{
-			{Key: "avg_rating", Value: -1},
-			{Key: "review_count", Value: -1},
-		}}},
-		{{Key: "$limit", Value: 6}},
-		{{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 1},
-			{Key: "title", Value: 1},
-			{Key: "author", Value: 1},
-			{Key: "coverImage", Value: 1},
-			{Key: "description", Value: 1},
-			{Key: "avg_rating", Value: 1},
-			{Key: "review_count", Value: 1},
-		}}},
-	}
-
-	log.Println("Executing aggregation pipeline...")
-	collection := config.DB.Database("bookwarm").Collection("books")
-	if collection == nil {
-		log.Println("Error: Collection is nil")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database collection not found"})
+	// ?archive=true เก็บรีวิวและ mark ไว้ใน reviews_archive / marks_archive แทนการลบทิ้ง
+	report, err := catalog.DeleteBook(c.Request.Context(), bookID, catalog.DeleteOptions{Archive: c.Query("archive") == "true"})
+	if err == catalog.ErrBookNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
 		return
 	}
-
-	cursor, err := collection.Aggregate(context.Background(), pipeline)
 	if err != nil {
-		log.Printf("Error in aggregation: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommended books"})
-		return
-	}
-	defer cursor.Close(context.Background())
-
-	var recommendedBooks []bson.M
-	if err = cursor.All(context.TODO(), &recommendedBooks); err != nil {
-		log.Printf("Error decoding recommended books: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding recommended books", "details": err.Error()})
+		log.Printf("Failed to delete book %s: %v", bookID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete book"})
 		return
 	}
-
-	log.Printf("Found %d recommended books", len(recommendedBooks))
-
-	for _, book := range recommendedBooks {
-		if id, ok := book["_id"]; ok {
-			if oid, isOID := id.(primitive.ObjectID); isOID {
-				book["_id"] = oid.Hex()
-			}
-		}
-
-		if coverImage, ok := book["coverImage"]; ok {
-			log.Printf("Book %s cover_image: %v", book["_id"], coverImage)
-		} else {
-			log.Printf("Book %s cover_image: nil or not found", book["_id"])
-		}
-
-		if avgRating, ok := book["avg_rating"].(float64); ok {
-			book["avg_rating"] = math.Round(avgRating*10) / 10
-		} else if avgRating, ok := book["avg_rating"].(int32); ok {
-			book["avg_rating"] = float64(avgRating)
-		} else {
-			book["avg_rating"] = 0.0
-		}
-	}
-
-	c.JSON(http.StatusOK, gin.H{"books": recommendedBooks})
+	c.JSON(http.StatusOK, gin.H{"message": "Book deleted successfully", "report": report})
 }
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"back/config"
+	"back/history"
+	"back/media"
+	"back/models"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func imageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, media.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, media.ErrUnsupportedImage):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, media.ErrImageDimensions):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// readImageUpload อ่านไฟล์รูปจาก multipart field ที่กำหนด โดยไม่อ่านเกิน media.MaxImageSize
+func readImageUpload(c *gin.Context, field string) ([]byte, error) {
+	// เผื่อขนาดของ multipart header ไว้ 1MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, media.MaxImageSize+1<<20)
+	file, _, err := c.Request.FormFile(field)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, media.ErrImageTooLarge
+		}
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > media.MaxImageSize {
+		return nil, media.ErrImageTooLarge
+	}
+	return data, nil
+}
+
+// UploadBookCover รับรูปปก (multipart field "cover") ย่อเป็นทุกขนาดใน media.CoverVariants
+// ทั้ง JPEG และ WebP แล้วตั้ง coverImage เป็น JPEG ขนาดใหญ่สุด ไฟล์ของปกเดิมจะถูกลบ
+//
+//	POST /api/books/:id/cover
+func UploadBookCover(c *gin.Context) {
+	bookID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	collection := config.DB.Database("bookwarm").Collection("books")
+	var book models.Book
+	err = collection.FindOne(context.TODO(), bson.M{"_id": bookID}).Decode(&book)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	data, err := readImageUpload(c, "cover")
+	if errors.Is(err, media.ErrImageTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cover file is required"})
+		return
+	}
+	img, err := media.Decode(data)
+	if err != nil {
+		c.JSON(imageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	sizes, err := media.SaveVariants(img, "covers", bookID.Hex(), media.CoverVariants)
+	if err != nil {
+		log.Printf("Failed to save cover of book %s: %v", bookID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cover"})
+		return
+	}
+	coverImage := sizes[len(sizes)-1].JPEG
+
+	err = history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			_, err := collection.UpdateOne(ctx, bson.M{"_id": bookID}, bson.M{"$set": bson.M{
+				"coverImage": coverImage,
+				"coverSizes": sizes,
+			}})
+			if err != nil {
+				return err
+			}
+			after := book
+			after.CoverImage = coverImage
+			after.CoverSizes = sizes
+			_, err = history.Record(ctx, &book, after, history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)})
+			return err
+		})
+	})
+	if err != nil {
+		media.Remove(sizes)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update book cover"})
+		return
+	}
+	media.Remove(book.CoverSizes)
+
+	c.JSON(http.StatusOK, gin.H{"coverImage": coverImage, "coverSizes": sizes})
+}
+
+// staleCoverSizes คืนไฟล์ปกที่อัปโหลดไว้ ถ้า coverImage ใหม่ไม่ใช่ปกที่อัปโหลดนั้นแล้ว
+// (เช่น แก้เป็น URL ภายนอกผ่าน UpdateBook) เพื่อให้ผู้เรียกลบ coverSizes ทิ้ง
+func staleCoverSizes(ctx context.Context, bookID primitive.ObjectID, coverImage string) []models.ImageVariant {
+	var book models.Book
+	if err := config.DB.Database("bookwarm").Collection("books").FindOne(ctx, bson.M{"_id": bookID}).Decode(&book); err != nil {
+		return nil
+	}
+	for _, size := range book.CoverSizes {
+		if size.JPEG == coverImage || size.WebP == coverImage {
+			return nil
+		}
+	}
+	return book.CoverSizes
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"back/config"
+	"back/history"
+	"back/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// contextEditor คืนผู้ใช้ที่ login อยู่ในรูปผู้แก้ไขสำหรับประวัติของหนังสือ
+func contextEditor(c *gin.Context) history.Editor {
+	name, _ := c.Get("displayName")
+	editor := history.Editor{ID: contextUserID(c)}
+	editor.Name, _ = name.(string)
+	return editor
+}
+
+// updateBooks แก้หนังสือทุกเล่มที่ตรง filter ทีละเล่มพร้อมบันทึก revision ของแต่ละเล่ม
+// ต้องเรียกใน config.WithTransaction คืน id ของหนังสือที่ถูกแก้
+func updateBooks(ctx context.Context, filter, update bson.M, entry history.Entry) ([]primitive.ObjectID, error) {
+	collection := config.DB.Database("bookwarm").Collection("books")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var books []models.Book
+	if err := cursor.All(ctx, &books); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(books))
+	for _, before := range books {
+		var after models.Book
+		err := collection.FindOneAndUpdate(ctx, bson.M{"_id": before.ID}, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&after)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := history.Record(ctx, &before, after, entry); err != nil {
+			return nil, err
+		}
+		ids = append(ids, before.ID)
+	}
+	return ids, nil
+}
+
+// GetBookHistory คืนประวัติการแก้ไขของหนังสือ ใหม่สุดก่อน แต่ละ revision มีผู้แก้ เวลา และฟิลด์ที่เปลี่ยน
+//
+//	GET /api/books/:id/history?page=1&limit=20
+func GetBookHistory(c *gin.Context) {
+	bookID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+	page, limit := parsePagination(c)
+
+	revisions, total, err := history.List(context.TODO(), bookID, int64((page-1)*limit), int64(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book history"})
+		return
+	}
+	if total == 0 {
+		// หนังสือที่มีอยู่ก่อนเริ่มเก็บประวัติจะยังไม่มี revision
+		count, err := config.DB.Database("bookwarm").Collection("books").CountDocuments(context.TODO(), bson.M{"_id": bookID})
+		if err == nil && count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// RevertBook ย้อนฟิลด์ที่แก้ได้ของหนังสือกลับไปเป็นค่าใน revision ที่เลือก
+// การย้อนกลับเป็นการแก้ไขครั้งใหม่ จึงได้ revision ใหม่ที่ชี้กลับไปยัง revision นั้น
+//
+//	POST /api/admin/books/:id/revert/:revision
+func RevertBook(c *gin.Context) {
+	bookID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	revision, err := history.Get(context.TODO(), bookID, number)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+		return
+	}
+
+	var existing models.Book
+	err = config.DB.Database("bookwarm").Collection("books").FindOne(context.TODO(), bson.M{"_id": bookID}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	// แทนที่ฟิลด์ที่แก้ได้ทั้งหมดด้วยค่าใน snapshot ฟิลด์ที่ไม่มีใน snapshot คือว่างในตอนนั้น
+	current, err := json.Marshal(existing)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode book"})
+		return
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode book"})
+		return
+	}
+	for _, field := range history.EditableFields {
+		delete(doc, field)
+		if value, ok := revision.Snapshot[field]; ok {
+			doc[field] = value
+		}
+	}
+	reverted, err := json.Marshal(doc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode book"})
+		return
+	}
+	var input models.Book
+	if err := json.Unmarshal(reverted, &input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Revision snapshot is invalid"})
+		return
+	}
+	if input.Contributors == nil {
+		// ไม่เช่นนั้น saveBook จะเก็บผู้มีส่วนร่วมปัจจุบันไว้
+		input.Contributors = []models.Contributor{}
+	}
+	if input.Titles == nil {
+		input.Titles = map[string]string{}
+	}
+	if input.Descriptions == nil {
+		input.Descriptions = map[string]string{}
+	}
+
+	// การตรวจเดียวกับ PUT ยังใช้อยู่ เช่น ผู้แต่งหรือหมวดที่ถูกลบไปแล้วจะย้อนกลับไม่ได้
+	saveBook(c, bookID, input, history.Entry{Action: models.RevisionRevert, Editor: contextEditor(c), RevertedFrom: number})
+}
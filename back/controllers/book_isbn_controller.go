@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"back/config"
+	"back/i18n"
+	"back/models"
+	"back/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ชื่อที่คล้ายกันตั้งแต่ค่านี้ขึ้นไป (จากผู้แต่งคนเดียวกัน) ถือว่าอาจเป็นหนังสือซ้ำ
+const duplicateTitleThreshold = 0.85
+
+var errISBNMismatch = errors.New("isbn10 and isbn13 refer to different books")
+
+// applyISBN ตรวจ checksum และเก็บ ISBN ทั้งสองแบบในรูปที่ไม่มีขีด
+func applyISBN(book *models.Book) error {
+	if book.ISBN13 == "" && book.ISBN10 == "" {
+		return nil
+	}
+	raw := book.ISBN13
+	if raw == "" {
+		raw = book.ISBN10
+	}
+	isbn13, isbn10, err := utils.NormalizeISBN(raw)
+	if err != nil {
+		return err
+	}
+	if book.ISBN13 != "" && book.ISBN10 != "" {
+		other, _, err := utils.NormalizeISBN(book.ISBN10)
+		if err != nil {
+			return err
+		}
+		if other != isbn13 {
+			return errISBNMismatch
+		}
+	}
+	book.ISBN13, book.ISBN10 = isbn13, isbn10
+	return nil
+}
+
+type duplicateCandidate struct {
+	ID         primitive.ObjectID `json:"id"`
+	Title      string             `json:"title"`
+	ISBN13     string             `json:"isbn13,omitempty"`
+	Similarity float64            `json:"similarity"`
+}
+
+// findDuplicateBooks หาหนังสือของผู้แต่งคนเดียวกันที่ชื่อคล้ายกันหลังทำ normalize แล้ว
+func findDuplicateBooks(ctx context.Context, title string, authorIDs []primitive.ObjectID, exclude primitive.ObjectID) ([]duplicateCandidate, error) {
+	normalized := utils.NormalizeTitle(title)
+	if normalized == "" || len(authorIDs) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{
+		"_id": bson.M{"$ne": exclude},
+		"$or": []bson.M{
+			{"authorId": bson.M{"$in": authorIDs}},
+			{"contributors.authorId": bson.M{"$in": authorIDs}},
+		},
+	}
+	projection := options.Find().SetProjection(bson.M{"title": 1, "isbn13": 1})
+	cursor, err := config.DB.Database("bookwarm").Collection("books").Find(ctx, filter, projection)
+	if err != nil {
+		return nil, err
+	}
+	var books []models.Book
+	if err := cursor.All(ctx, &books); err != nil {
+		return nil, err
+	}
+
+	var candidates []duplicateCandidate
+	for _, book := range books {
+		similarity := utils.Similarity(normalized, utils.NormalizeTitle(book.Title))
+		if similarity >= duplicateTitleThreshold {
+			candidates = append(candidates, duplicateCandidate{
+				ID:         book.ID,
+				Title:      book.Title,
+				ISBN13:     book.ISBN13,
+				Similarity: similarity,
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// bookAuthorIDs คือผู้แต่งหลักและผู้แต่งร่วมที่ใช้เทียบหาหนังสือซ้ำ
+func bookAuthorIDs(book models.Book) []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	if !book.AuthorID.IsZero() {
+		ids = append(ids, book.AuthorID)
+	}
+	for _, contributor := range book.Contributors {
+		if contributor.Role == models.RoleAuthor || contributor.Role == models.RoleCoAuthor {
+			ids = append(ids, contributor.AuthorID)
+		}
+	}
+	return ids
+}
+
+// GetBookByISBN ค้นหนังสือจาก ISBN-10 หรือ ISBN-13 (มีหรือไม่มีขีดก็ได้)
+func GetBookByISBN(c *gin.Context) {
+	isbn13, _, err := utils.NormalizeISBN(c.Param("isbn"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ISBN"})
+		return
+	}
+
+	collection := config.DB.Database("bookwarm").Collection("books")
+	pipeline := append([]bson.M{{"$match": bson.M{"isbn13": isbn13}}}, bookLookupStages()...)
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book: " + err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var results []bson.M
+	if err := cursor.All(context.TODO(), &results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode book: " + err.Error()})
+		return
+	}
+	if len(results) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+
+	i18n.Localize(results[0], contextLocale(c))
+	c.JSON(http.StatusOK, results[0])
+}
+
+// CheckDuplicateBooks ให้หน้าแก้ไขตรวจก่อนบันทึกว่ามีหนังสือนี้อยู่แล้วหรือไม่
+//
+//	GET /api/books/duplicates?title=&author=&isbn=
+func CheckDuplicateBooks(c *gin.Context) {
+	authorIDs, err := parseObjectIDList(c, "author")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+
+	response := gin.H{}
+	if raw := c.Query("isbn"); raw != "" {
+		isbn13, _, err := utils.NormalizeISBN(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ISBN"})
+			return
+		}
+		var existing models.Book
+		err = config.DB.Database("bookwarm").Collection("books").
+			FindOne(context.TODO(), bson.M{"isbn13": isbn13}, options.FindOne().SetProjection(bson.M{"title": 1, "isbn13": 1})).
+			Decode(&existing)
+		if err == nil {
+			response["isbnMatch"] = duplicateCandidate{ID: existing.ID, Title: existing.Title, ISBN13: existing.ISBN13, Similarity: 1}
+		}
+	}
+
+	candidates, err := findDuplicateBooks(context.TODO(), c.Query("title"), authorIDs, primitive.NilObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check duplicates"})
+		return
+	}
+	if candidates == nil {
+		candidates = []duplicateCandidate{}
+	}
+	response["duplicates"] = candidates
+
+	c.JSON(http.StatusOK, response)
+}
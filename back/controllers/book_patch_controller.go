@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"back/config"
+	"back/history"
+	"back/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ฟิลด์ที่ระบบคำนวณเอง แก้ผ่าน PATCH ไม่ได้
+var readOnlyBookFields = map[string]bool{
+	"id":               true,
+	"rating":           true,
+	"avg_rating":       true,
+	"review_count":     true,
+	"rating_histogram": true,
+	"coverSizes":       true,
+	"createdAt":        true,
+	"updatedAt":        true,
+}
+
+// mergePatch ใช้ JSON merge patch (RFC 7396) กับ target
+// ค่า null ลบฟิลด์ object รวมกันแบบ recursive และค่าอื่นแทนที่ของเดิมทั้งก้อน (รวมถึง array)
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// PatchBook แก้หนังสือเฉพาะฟิลด์ที่ส่งมาแบบ JSON merge patch ฟิลด์ที่ไม่ได้ส่งยังเหมือนเดิม
+// และ null คือการลบค่า (เช่น "seriesId": null เอาหนังสือออกจากซีรีส์)
+// ผลลัพธ์ผ่านการตรวจเดียวกับ PUT
+//
+//	PATCH /api/books/:id
+//	Content-Type: application/merge-patch+json
+//	{"title": "ชื่อใหม่", "seriesNumber": null}
+func PatchBook(c *gin.Context) {
+	bookID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+	contentType := c.ContentType()
+	if contentType != "application/merge-patch+json" && contentType != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/merge-patch+json"})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Patch must be a JSON object"})
+		return
+	}
+	var readOnly []string
+	for field := range patch {
+		if readOnlyBookFields[field] {
+			readOnly = append(readOnly, field)
+		}
+	}
+	if len(readOnly) > 0 {
+		sort.Strings(readOnly)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "read-only fields cannot be patched: " + strings.Join(readOnly, ", ")})
+		return
+	}
+
+	var existing models.Book
+	err = config.DB.Database("bookwarm").Collection("books").FindOne(context.TODO(), bson.M{"_id": bookID}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	// ใช้ patch กับหนังสือในรูป JSON (ชื่อฟิลด์เดียวกับที่ client เห็น) แล้วแปลงกลับเป็น models.Book
+	current, err := json.Marshal(existing)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode book"})
+		return
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode book"})
+		return
+	}
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply patch"})
+		return
+	}
+	var input models.Book
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": patchDecodeError(err)})
+		return
+	}
+	if strings.TrimSpace(input.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title cannot be empty"})
+		return
+	}
+
+	// ส่ง authorId มาโดยไม่มี contributors ให้ทำแบบเดียวกับ PUT คือเปลี่ยนเฉพาะผู้แต่งหลัก
+	_, hasAuthor := patch["authorId"]
+	_, hasContributors := patch["contributors"]
+	if hasAuthor && !hasContributors {
+		input.Contributors = nil
+	}
+	// "titles": null คือการลบ ไม่ใช่ไม่ส่งมา (ซึ่ง saveBook จะเก็บของเดิมไว้)
+	if input.Titles == nil {
+		input.Titles = map[string]string{}
+	}
+	if input.Descriptions == nil {
+		input.Descriptions = map[string]string{}
+	}
+	saveBook(c, bookID, input, history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)})
+}
+
+// patchDecodeError ทำให้ข้อความ error ของ encoding/json บอกชื่อฟิลด์ที่ผิด
+func patchDecodeError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return "invalid value for field " + typeErr.Field
+	}
+	if msg, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return "unknown field " + msg
+	}
+	return err.Error()
+}
@@ -0,0 +1,463 @@
+package controllers
+
+import (
+	"back/config"
+	"back/i18n"
+	"back/models"
+	"back/recommend"
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultRecommendLimit = 6
+	maxRecommendLimit     = 50
+)
+
+// recommendParams คือตัวเลือกของการจัดอันดับ อ่านจาก query โดยใช้ค่าใน config เป็นค่าเริ่มต้น
+type recommendParams struct {
+	limit, offset int
+	priorWeight   float64
+	priorMean     float64
+	hasPriorMean  bool
+	decay         bool
+	halfLifeDays  float64
+}
+
+func parseRecommendParams(c *gin.Context) (recommendParams, error) {
+	p := recommendParams{
+		limit:        defaultRecommendLimit,
+		priorWeight:  config.RecommendPriorWeight(),
+		halfLifeDays: config.RecommendHalfLifeDays(),
+	}
+	p.priorMean, p.hasPriorMean = config.RecommendPriorMean()
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxRecommendLimit {
+			return p, errors.New("limit must be between 1 and 50")
+		}
+		p.limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return p, errors.New("offset must be 0 or greater")
+		}
+		p.offset = offset
+	}
+	if raw := c.Query("prior_weight"); raw != "" {
+		weight, err := strconv.ParseFloat(raw, 64)
+		if err != nil || weight < 0 {
+			return p, errors.New("prior_weight must be 0 or greater")
+		}
+		p.priorWeight = weight
+	}
+	if raw := c.Query("prior_mean"); raw != "" {
+		mean, err := strconv.ParseFloat(raw, 64)
+		if err != nil || mean < 1 || mean > 5 {
+			return p, errors.New("prior_mean must be between 1 and 5")
+		}
+		p.priorMean, p.hasPriorMean = mean, true
+	}
+	if raw := c.Query("decay"); raw != "" {
+		decay, err := strconv.ParseBool(raw)
+		if err != nil {
+			return p, errors.New("decay must be true or false")
+		}
+		p.decay = decay
+	}
+	if raw := c.Query("half_life_days"); raw != "" {
+		days, err := strconv.ParseFloat(raw, 64)
+		if err != nil || days <= 0 {
+			return p, errors.New("half_life_days must be greater than 0")
+		}
+		p.halfLifeDays = days
+	}
+	return p, nil
+}
+
+// globalMeanRating คือค่าเฉลี่ยของรีวิวทั้งหมดในระบบ ใช้เป็น prior เมื่อไม่ได้ตั้งค่าไว้
+func globalMeanRating(ctx context.Context) (float64, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection("books").Aggregate(ctx, []bson.M{
+		{"$group": bson.M{
+			"_id":   nil,
+			"sum":   bson.M{"$sum": "$rating_sum"},
+			"count": bson.M{"$sum": "$review_count"},
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var out []struct {
+		Sum   float64 `bson:"sum"`
+		Count float64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &out); err != nil {
+		return 0, err
+	}
+	if len(out) == 0 || out[0].Count == 0 {
+		// ยังไม่มีรีวิวเลย ใช้กึ่งกลางของสเกล 1-5
+		return 3, nil
+	}
+	return out[0].Sum / out[0].Count, nil
+}
+
+// markedBookFilter คืนเงื่อนไขที่ตัดหนังสือที่ผู้ใช้ mark ไว้แล้วออก
+// รวมถึง edition อื่นของงานเดียวกัน (mark เป็นของ work ไม่ใช่ของ edition)
+func markedBookFilter(ctx context.Context, userID primitive.ObjectID) (bson.M, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection("marks").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	var marks []struct {
+		BookID primitive.ObjectID  `bson:"book_id"`
+		WorkID *primitive.ObjectID `bson:"work_id"`
+	}
+	if err := cursor.All(ctx, &marks); err != nil {
+		return nil, err
+	}
+	if len(marks) == 0 {
+		return nil, nil
+	}
+	bookIDs := []primitive.ObjectID{}
+	workIDs := []primitive.ObjectID{}
+	for _, mark := range marks {
+		bookIDs = append(bookIDs, mark.BookID)
+		if mark.WorkID != nil {
+			workIDs = append(workIDs, *mark.WorkID)
+		}
+	}
+	return bson.M{"_id": bson.M{"$nin": bookIDs}, "workId": bson.M{"$nin": workIDs}}, nil
+}
+
+// prefixed ใส่ prefix ให้ทุก field ในเงื่อนไข ใช้กับเงื่อนไขของหนังสือหลัง $lookup
+func prefixed(filter bson.M, prefix string) bson.M {
+	out := bson.M{}
+	for field, cond := range filter {
+		out[prefix+field] = cond
+	}
+	return out
+}
+
+var recommendProjection = bson.M{
+	"_id":          1,
+	"title":        1,
+	"titles":       1,
+	"authorId":     1,
+	"coverImage":   1,
+	"coverSizes":   1,
+	"description":  1,
+	"descriptions": 1,
+	"avg_rating":   1,
+	"review_count": 1,
+	"score":        1,
+}
+
+// GetRecommendedBooks จัดอันดับหนังสือด้วย Bayesian average
+//
+//	score = (rating_sum + m*C) / (review_count + m)
+//
+// m คือ prior_weight และ C คือ prior_mean (ค่าเริ่มต้นคือค่าเฉลี่ยของรีวิวทั้งหมด)
+// หนังสือที่มีรีวิว 5 ดาวแค่อันเดียวจึงไม่แซงเล่มที่มีรีวิวดีหลายร้อยอัน
+// ถ้า decay=true รีวิวเก่าจะมีน้ำหนักน้อยลงครึ่งหนึ่งทุก half_life_days วัน
+// ผู้ที่ login แล้วจะไม่เห็นหนังสือที่ตัวเอง mark ไว้ เว้นแต่ส่ง include_marked=true
+//
+//	GET /api/books/recommended?genre=&category=&limit=6&offset=0
+//	    &prior_weight=&prior_mean=&decay=true&half_life_days=
+func GetRecommendedBooks(c *gin.Context) {
+	params, err := parseRecommendParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	filter := bson.M{}
+	genreIDs, err := parseObjectIDList(c, "genre")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid genre ID"})
+		return
+	}
+	if len(genreIDs) > 0 {
+		if genreIDs, err = expandTaxonomy(ctx, "genre", genreIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load genre tree"})
+			return
+		}
+		filter["genres"] = bson.M{"$in": genreIDs}
+	}
+	categoryIDs, err := parseObjectIDList(c, "category")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+	if len(categoryIDs) > 0 {
+		if categoryIDs, err = expandTaxonomy(ctx, "category", categoryIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load category tree"})
+			return
+		}
+		filter["category_id"] = bson.M{"$in": categoryIDs}
+	}
+	if userID := contextUserID(c); userID != nil && c.Query("include_marked") != "true" {
+		marked, err := markedBookFilter(ctx, *userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load marks"})
+			return
+		}
+		for field, cond := range marked {
+			filter[field] = cond
+		}
+	}
+
+	if !params.hasPriorMean {
+		if params.priorMean, err = globalMeanRating(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute average rating"})
+			return
+		}
+	}
+	prior := params.priorWeight * params.priorMean
+
+	var pipeline []bson.M
+	collection := "books"
+	if !params.decay {
+		// ใช้ค่าที่เก็บไว้ในหนังสือ (ดู package ratings) ไม่ต้องอ่านรีวิว
+		filter["review_count"] = bson.M{"$gt": 0}
+		pipeline = []bson.M{
+			{"$match": filter},
+			{"$addFields": bson.M{"score": bson.M{"$divide": []interface{}{
+				bson.M{"$add": []interface{}{"$rating_sum", prior}},
+				bson.M{"$add": []interface{}{"$review_count", params.priorWeight}},
+			}}}},
+		}
+	} else {
+		// น้ำหนักของรีวิว = 0.5^(อายุ / half life) ต้องคำนวณจากรีวิวทีละอัน
+		collection = "reviews"
+		halfLifeMs := params.halfLifeDays * float64(24*time.Hour/time.Millisecond)
+		weight := bson.M{"$pow": []interface{}{0.5, bson.M{"$divide": []interface{}{
+			bson.M{"$max": []interface{}{0, bson.M{"$subtract": []interface{}{"$$NOW", "$review_date"}}}},
+			halfLifeMs,
+		}}}}
+		pipeline = []bson.M{
+			{"$group": bson.M{
+				"_id":            "$book_id",
+				"weight":         bson.M{"$sum": weight},
+				"weightedRating": bson.M{"$sum": bson.M{"$multiply": []interface{}{weight, "$rating"}}},
+			}},
+			{"$lookup": bson.M{"from": "books", "localField": "_id", "foreignField": "_id", "as": "book"}},
+			{"$unwind": "$book"},
+			{"$match": prefixed(filter, "book.")},
+			{"$replaceRoot": bson.M{"newRoot": bson.M{"$mergeObjects": []interface{}{"$book", bson.M{
+				"score": bson.M{"$divide": []interface{}{
+					bson.M{"$add": []interface{}{"$weightedRating", prior}},
+					bson.M{"$add": []interface{}{"$weight", params.priorWeight}},
+				}},
+			}}}}},
+		}
+	}
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "score", Value: -1}, {Key: "review_count", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$skip": params.offset},
+		bson.M{"$limit": params.limit},
+		bson.M{"$project": recommendProjection},
+	)
+
+	cursor, err := config.DB.Database("bookwarm").Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Error in recommended books aggregation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommended books"})
+		return
+	}
+	recommendedBooks := []bson.M{}
+	if err := cursor.All(ctx, &recommendedBooks); err != nil {
+		log.Printf("Error decoding recommended books: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding recommended books"})
+		return
+	}
+
+	for _, book := range recommendedBooks {
+		if oid, ok := book["_id"].(primitive.ObjectID); ok {
+			book["_id"] = oid.Hex()
+		}
+		if avgRating, ok := book["avg_rating"].(float64); ok {
+			book["avg_rating"] = math.Round(avgRating*10) / 10
+		}
+		if score, ok := book["score"].(float64); ok {
+			book["score"] = math.Round(score*100) / 100
+		}
+	}
+	i18n.Localize(recommendedBooks, contextLocale(c))
+
+	c.JSON(http.StatusOK, gin.H{
+		"books":  recommendedBooks,
+		"limit":  params.limit,
+		"offset": params.offset,
+		"prior":  gin.H{"weight": params.priorWeight, "mean": math.Round(params.priorMean*100) / 100},
+		"decay":  params.decay,
+	})
+}
+
+// recommendedBookDocs โหลดหนังสือด้วย recommendProjection ในภาษา locale คืนเป็น map ตาม _id
+// เพื่อให้ผู้เรียกเรียงตามลำดับของตัวเอง หนังสือที่ถูกลบไปแล้วจะไม่อยู่ใน map
+func recommendedBookDocs(ctx context.Context, bookIDs []primitive.ObjectID, locale string) (map[primitive.ObjectID]bson.M, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection("books").Find(ctx,
+		bson.M{"_id": bson.M{"$in": bookIDs}}, options.Find().SetProjection(recommendProjection))
+	if err != nil {
+		return nil, err
+	}
+	var found []bson.M
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	i18n.Localize(found, locale)
+	byID := map[primitive.ObjectID]bson.M{}
+	for _, book := range found {
+		if oid, ok := book["_id"].(primitive.ObjectID); ok {
+			book["_id"] = oid.Hex()
+			if avgRating, ok := book["avg_rating"].(float64); ok {
+				book["avg_rating"] = math.Round(avgRating*10) / 10
+			}
+			byID[oid] = book
+		}
+	}
+	return byID, nil
+}
+
+// GetBooksForMe แนะนำหนังสือเฉพาะผู้ใช้จากประวัติ mark และรีวิว (ดู package recommend)
+// แต่ละเล่มมี reason อธิบายเหตุผล เช่น "Because you read X"
+// model ถูก build ใหม่เบื้องหลังทุก RECOMMEND_REFRESH ผู้อ่านร่วมใหม่จึงมีผลในรอบถัดไป
+// แต่ mark ของผู้ใช้เองมีผลทันที
+//
+//	GET /api/books/for-me?limit=20
+func GetBooksForMe(c *gin.Context) {
+	userID := contextUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxRecommendLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+			return
+		}
+		limit = n
+	}
+
+	model := recommend.Current()
+	if model == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Recommendations are not ready yet, please try again shortly"})
+		return
+	}
+	ctx := c.Request.Context()
+	recs, personalized, err := model.ForUser(ctx, *userID, limit)
+	if err != nil {
+		log.Printf("Error computing recommendations for %s: %v", userID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute recommendations"})
+		return
+	}
+
+	bookIDs := make([]primitive.ObjectID, 0, len(recs))
+	for _, rec := range recs {
+		bookIDs = append(bookIDs, rec.BookID)
+	}
+	byID, err := recommendedBookDocs(ctx, bookIDs, contextLocale(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommended books"})
+		return
+	}
+
+	// เรียงตามคะแนน หนังสือที่ถูกลบหลัง build model จะหายไปจากผลลัพธ์
+	books := []bson.M{}
+	for _, rec := range recs {
+		book, ok := byID[rec.BookID]
+		if !ok {
+			continue
+		}
+		book["score"] = math.Round(rec.Score*1000) / 1000
+		book["reason"] = rec.Reason
+		books = append(books, book)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books":        books,
+		"personalized": personalized,
+		"generated_at": model.BuiltAt,
+	})
+}
+
+// GetSimilarBooks คืนหนังสือที่ "ผู้อ่านชอบเหมือนกัน" จาก genre, tag, หมวด, ผู้แต่ง, ซีรีส์ที่ตรงกัน
+// และจำนวนผู้ที่ mark ทั้งสองเล่มว่าอ่านแล้ว รายการคำนวณไว้ล่วงหน้าเบื้องหลัง (ดู package recommend)
+// ไม่รวม edition อื่นของงานเดียวกัน แต่ละเล่มมี reasons บอกสิ่งที่เหมือนกัน
+//
+//	GET /api/books/:id/similar?limit=10
+func GetSimilarBooks(c *gin.Context) {
+	bookID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+	limit := 10
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxRecommendLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+			return
+		}
+		limit = n
+	}
+
+	model := recommend.Current()
+	if model == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Recommendations are not ready yet, please try again shortly"})
+		return
+	}
+	ctx := c.Request.Context()
+	var book models.Book
+	err = config.DB.Database("bookwarm").Collection("books").FindOne(ctx, bson.M{"_id": bookID}).Decode(&book)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	similar := model.SimilarBooks(book, limit)
+	bookIDs := make([]primitive.ObjectID, 0, len(similar))
+	for _, s := range similar {
+		bookIDs = append(bookIDs, s.BookID)
+	}
+	byID, err := recommendedBookDocs(ctx, bookIDs, contextLocale(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch similar books"})
+		return
+	}
+	books := []bson.M{}
+	for _, s := range similar {
+		doc, ok := byID[s.BookID]
+		if !ok {
+			continue
+		}
+		doc["score"] = math.Round(s.Score*1000) / 1000
+		doc["reasons"] = s.Reasons
+		books = append(books, doc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books":        books,
+		"generated_at": model.BuiltAt,
+	})
+}
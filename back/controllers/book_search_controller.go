@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"back/config"
+	"back/i18n"
+	"back/models"
+	"back/search"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// maxIndexHits คือจำนวนผลจากดัชนีค้นหาที่นำไปกรอง/นับต่อ ถ้าคำค้นตรงมากกว่านี้ total และ facet
+	// นับจากผลที่ดีที่สุด maxIndexHits อันเท่านั้น และตอบ total_capped เป็น true
+	maxIndexHits = 1000
+)
+
+// indexHits ค้นดัชนีแล้วคืน id เรียงตามคะแนน พร้อมบอกว่ามีผลเกิน maxIndexHits หรือไม่
+func indexHits(docType, query string) (ids []primitive.ObjectID, scores []float64, capped, ok bool) {
+	// ขอเกินหนึ่งอันเพื่อรู้ว่าถูกตัดหรือไม่
+	hits, ok := search.Default.Search(docType, query, maxIndexHits+1)
+	if len(hits) > maxIndexHits {
+		hits, capped = hits[:maxIndexHits], true
+	}
+	for _, hit := range hits {
+		id, err := primitive.ObjectIDFromHex(hit.ID)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		scores = append(scores, hit.Score)
+	}
+	return ids, scores, capped, ok
+}
+
+// parseObjectIDList อ่าน query parameter ที่เป็น id คั่นด้วย comma หรือส่งซ้ำหลายครั้ง
+func parseObjectIDList(c *gin.Context, key string) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, raw := range c.QueryArray(key) {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := primitive.ObjectIDFromHex(part)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// parseNumberRange สร้างเงื่อนไข $gte/$lte จาก <prefix>_min และ <prefix>_max
+func parseNumberRange(c *gin.Context, prefix string) (bson.M, error) {
+	cond := bson.M{}
+	if raw := c.Query(prefix + "_min"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, err
+		}
+		cond["$gte"] = value
+	}
+	if raw := c.Query(prefix + "_max"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, err
+		}
+		cond["$lte"] = value
+	}
+	if len(cond) == 0 {
+		return nil, nil
+	}
+	return cond, nil
+}
+
+func parsePagination(c *gin.Context) (page, limit int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+	if limit < 1 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	return page, limit
+}
+
+// contributorConditions สร้างเงื่อนไขกรองหนังสือตามผู้มีส่วนร่วม
+//
+//	author=<id>           หนังสือที่คนนี้เป็นผู้แต่งหรือผู้แต่งร่วม
+//	contributor=<id>      หนังสือที่คนนี้มีส่วนร่วมในบทบาทใดก็ได้ (หรือเฉพาะบทบาทใน role)
+//	role=translator,...   หนังสือที่มีผู้มีส่วนร่วมในบทบาทนี้ (ใช้ร่วมกับ contributor ได้)
+func contributorConditions(c *gin.Context) ([]bson.M, error) {
+	var conds []bson.M
+
+	authorIDs, err := parseObjectIDList(c, "author")
+	if err != nil {
+		return nil, errors.New("Invalid author ID")
+	}
+	if len(authorIDs) > 0 {
+		conds = append(conds, bson.M{"$or": []bson.M{
+			{"authorId": bson.M{"$in": authorIDs}},
+			{"contributors": bson.M{"$elemMatch": bson.M{
+				"authorId": bson.M{"$in": authorIDs},
+				"role":     bson.M{"$in": []string{models.RoleAuthor, models.RoleCoAuthor}},
+			}}},
+		}})
+	}
+
+	contributorIDs, err := parseObjectIDList(c, "contributor")
+	if err != nil {
+		return nil, errors.New("Invalid contributor ID")
+	}
+	var roles []string
+	for _, raw := range c.QueryArray("role") {
+		for _, role := range strings.Split(raw, ",") {
+			role = strings.TrimSpace(role)
+			if role == "" {
+				continue
+			}
+			if !models.IsContributorRole(role) {
+				return nil, errors.New("role must be one of " + strings.Join(models.ContributorRoles, ", "))
+			}
+			roles = append(roles, role)
+		}
+	}
+	elem := bson.M{}
+	if len(contributorIDs) > 0 {
+		elem["authorId"] = bson.M{"$in": contributorIDs}
+	}
+	if len(roles) > 0 {
+		elem["role"] = bson.M{"$in": roles}
+	}
+	if len(elem) > 0 {
+		conds = append(conds, bson.M{"contributors": bson.M{"$elemMatch": elem}})
+	}
+	return conds, nil
+}
+
+// facetStages นับจำนวนหนังสือต่อค่าใน field แล้วดึงชื่อจาก collection ที่อ้างถึง
+func facetStages(field, from string, unwind bool) []bson.M {
+	stages := []bson.M{}
+	if unwind {
+		stages = append(stages, bson.M{"$unwind": "$" + field})
+	}
+	return append(stages,
+		bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"count": -1}},
+		bson.M{"$lookup": bson.M{
+			"from":         from,
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "ref",
+		}},
+		bson.M{"$project": bson.M{
+			"_id":   0,
+			"id":    "$_id",
+			"name":  bson.M{"$arrayElemAt": []interface{}{"$ref.name", 0}},
+			"names": bson.M{"$arrayElemAt": []interface{}{"$ref.names", 0}},
+			"count": 1,
+		}},
+	)
+}
+
+// SearchBooks ค้นหาหนังสือด้วยข้อความพร้อมตัวกรอง การเรียงลำดับ แบ่งหน้า และ facet counts
+//
+//	GET /api/books/search?query=&genre=&category=&tag=&author=&contributor=&role=&series=
+//	    &year_min=&year_max=&pages_min=&pages_max=&min_rating=
+//	    &sort=relevance|rating|newest|title&page=&limit=
+//
+// ตอบเป็น object (รุ่นก่อนตอบเป็น array ของหนังสือเปล่า ๆ client รุ่นเก่าต้องอ่าน books แทน)
+//
+//	{"books": [...], "total": 42, "total_capped": false, "page": 1, "limit": 20, "sort": "relevance",
+//	 "facets": {"genres": [...], "categories": [...], "tags": [...]}}
+//
+// total_capped เป็น true เมื่อคำค้นตรงกับหนังสือเกิน maxIndexHits เล่ม total และ facet จึงเป็นค่าขั้นต่ำ
+func SearchBooks(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("query"))
+
+	// ใช้ดัชนีค้นหา (ตัดคำไทยได้) เป็นหลัก ถ้าใช้ไม่ได้ค่อยถอยไปใช้ regex
+	match := bson.M{}
+	var hitIDs []primitive.ObjectID
+	var hitScores []float64
+	useIndex, capped := false, false
+	if query != "" && search.Default != nil {
+		hitIDs, hitScores, capped, useIndex = indexHits(search.TypeBook, query)
+	}
+	if useIndex {
+		if hitIDs == nil {
+			hitIDs = []primitive.ObjectID{}
+		}
+		match["_id"] = bson.M{"$in": hitIDs}
+	} else if query != "" {
+		pattern := regexp.QuoteMeta(query)
+		textConds := []bson.M{
+			{"title": bson.M{"$regex": pattern, "$options": "i"}},
+			{"description": bson.M{"$regex": pattern, "$options": "i"}},
+		}
+		// ชื่อเรื่องและคำอธิบายภาษาอื่นค้นได้เหมือนภาษาหลัก
+		for _, locale := range config.SupportedLocales()[1:] {
+			textConds = append(textConds,
+				bson.M{"titles." + locale: bson.M{"$regex": pattern, "$options": "i"}},
+				bson.M{"descriptions." + locale: bson.M{"$regex": pattern, "$options": "i"}})
+		}
+		match["$or"] = textConds
+	}
+
+	idFilters := []struct {
+		param string
+		field string
+	}{
+		{"genre", "genres"},
+		{"category", "category_id"},
+		{"tag", "tagIds"},
+		{"series", "seriesId"},
+	}
+	for _, f := range idFilters {
+		ids, err := parseObjectIDList(c, f.param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + f.param + " ID"})
+			return
+		}
+		// หมวดและประเภทรวมหมวด/ประเภทย่อยทุกระดับ
+		if f.param == "genre" || f.param == "category" {
+			if ids, err = expandTaxonomy(c.Request.Context(), f.param, ids); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load " + f.param + " tree"})
+				return
+			}
+		}
+		if len(ids) > 0 {
+			match[f.field] = bson.M{"$in": ids}
+		}
+	}
+
+	conds, err := contributorConditions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(conds) > 0 {
+		match["$and"] = conds
+	}
+
+	yearRange, err := parseNumberRange(c, "year")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid publish year range"})
+		return
+	}
+	if yearRange != nil {
+		match["publishYear"] = yearRange
+	}
+
+	pageRange, err := parseNumberRange(c, "pages")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page count range"})
+		return
+	}
+	if pageRange != nil {
+		match["pageCount"] = pageRange
+	}
+
+	var minRating float64
+	if raw := c.Query("min_rating"); raw != "" {
+		minRating, err = strconv.ParseFloat(raw, 64)
+		if err != nil || minRating < 0 || minRating > 5 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_rating must be between 0 and 5"})
+			return
+		}
+	}
+
+	sortBy := c.Query("sort")
+	if sortBy == "" {
+		sortBy = "newest"
+		if query != "" {
+			sortBy = "relevance"
+		}
+	}
+	var sortStage bson.D
+	switch sortBy {
+	case "relevance":
+		sortStage = bson.D{{Key: "relevance", Value: -1}, {Key: "avg_rating", Value: -1}, {Key: "_id", Value: 1}}
+	case "rating":
+		sortStage = bson.D{{Key: "avg_rating", Value: -1}, {Key: "review_count", Value: -1}, {Key: "_id", Value: 1}}
+	case "newest":
+		sortStage = bson.D{{Key: "publishYear", Value: -1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: 1}}
+	case "title":
+		sortStage = bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of relevance, rating, newest, title"})
+		return
+	}
+
+	page, limit := parsePagination(c)
+
+	// avg_rating และ review_count เก็บไว้ในเอกสารหนังสือแล้ว (ดู package ratings)
+	pipeline := []bson.M{
+		{"$match": match},
+	}
+	if minRating > 0 {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"avg_rating": bson.M{"$gte": minRating}}})
+	}
+
+	// ให้คะแนนตรงทั้งชื่อ > ขึ้นต้นด้วยคำค้น > มีคำค้นในชื่อ > มีในคำอธิบาย
+	relevance := bson.M{"$literal": 0}
+	if useIndex {
+		relevance = bson.M{"$arrayElemAt": []interface{}{
+			hitScores,
+			bson.M{"$indexOfArray": []interface{}{hitIDs, "$_id"}},
+		}}
+	} else if query != "" {
+		pattern := regexp.QuoteMeta(query)
+		relevance = bson.M{"$add": []interface{}{
+			bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{bson.M{"$toLower": "$title"}, strings.ToLower(query)}}, 100, 0}},
+			bson.M{"$cond": []interface{}{bson.M{"$regexMatch": bson.M{"input": "$title", "regex": "^" + pattern, "options": "i"}}, 50, 0}},
+			bson.M{"$cond": []interface{}{bson.M{"$regexMatch": bson.M{"input": "$title", "regex": pattern, "options": "i"}}, 20, 0}},
+			bson.M{"$cond": []interface{}{bson.M{"$regexMatch": bson.M{"input": bson.M{"$ifNull": []interface{}{"$description", ""}}, "regex": pattern, "options": "i"}}, 5, 0}},
+		}}
+	}
+	pipeline = append(pipeline, bson.M{"$addFields": bson.M{"relevance": relevance}})
+
+	results := append([]bson.M{
+		{"$sort": sortStage},
+		{"$skip": (page - 1) * limit},
+		{"$limit": limit},
+	}, bookLookupStages()...)
+
+	pipeline = append(pipeline, bson.M{"$facet": bson.M{
+		"books":      results,
+		"total":      []bson.M{{"$count": "count"}},
+		"genres":     facetStages("genres", "genre", true),
+		"categories": facetStages("category_id", "category", false),
+		"tags":       facetStages("tagIds", "tag", true),
+	}})
+
+	collection := config.DB.Database("bookwarm").Collection("books")
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		log.Printf("Search aggregation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error finding books"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var out []struct {
+		Books      []bson.M `bson:"books"`
+		Total      []bson.M `bson:"total"`
+		Genres     []bson.M `bson:"genres"`
+		Categories []bson.M `bson:"categories"`
+		Tags       []bson.M `bson:"tags"`
+	}
+	if err := cursor.All(context.TODO(), &out); err != nil || len(out) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding books"})
+		return
+	}
+	result := out[0]
+
+	total := int32(0)
+	if len(result.Total) > 0 {
+		total, _ = result.Total[0]["count"].(int32)
+	}
+	if result.Books == nil {
+		result.Books = []bson.M{}
+	}
+	locale := contextLocale(c)
+	for _, docs := range [][]bson.M{result.Books, result.Genres, result.Categories, result.Tags} {
+		i18n.Localize(docs, locale)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books":        result.Books,
+		"total":        total,
+		"total_capped": capped,
+		"page":         page,
+		"limit":        limit,
+		"sort":         sortBy,
+		"facets": gin.H{
+			"genres":     result.Genres,
+			"categories": result.Categories,
+			"tags":       result.Tags,
+		},
+	})
+}
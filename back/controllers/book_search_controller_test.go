@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"back/search"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIndexHitsReportsCap(t *testing.T) {
+	previous := search.Default
+	t.Cleanup(func() { search.Default = previous })
+
+	tests := []struct {
+		name       string
+		books      int
+		wantHits   int
+		wantCapped bool
+	}{
+		{"below the cap", 3, 3, false},
+		{"exactly the cap", maxIndexHits, maxIndexHits, false},
+		{"above the cap", maxIndexHits + 5, maxIndexHits, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search.Default = search.New("")
+			for i := 0; i < tt.books; i++ {
+				search.Default.Put(search.TypeBook, primitive.NewObjectID().Hex(), search.Field{Text: "dragon", Weight: 1})
+			}
+			ids, scores, capped, ok := indexHits(search.TypeBook, "dragon")
+			if !ok {
+				t.Fatal("query was not searchable")
+			}
+			if len(ids) != tt.wantHits || len(scores) != tt.wantHits || capped != tt.wantCapped {
+				t.Fatalf("hits = %d, scores = %d, capped = %v; want %d, %v", len(ids), len(scores), capped, tt.wantHits, tt.wantCapped)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package controllers
+
+import (
+	"back/config"
+	"back/history"
+	"back/models"
+	"back/ratings"
+	"back/search"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errSubmissionReviewed = errors.New("submission has already been reviewed")
+
+func submissionCollection() *mongo.Collection {
+	return config.DB.Database("bookwarm").Collection("book_submissions")
+}
+
+// SubmitBook ให้ผู้อ่านเสนอหนังสือที่ยังไม่มีในระบบ หนังสือจะรอผู้ดูแลอนุมัติก่อนแสดงในรายการ
+// ข้อมูลผ่านการตรวจเดียวกับ POST /api/books และตอบหนังสือที่น่าจะซ้ำกลับไปด้วย
+//
+//	POST /api/books/submissions
+//	{"title": "...", "authorId": "...", "note": "ข้อมูลจากหน้าปก"}
+func SubmitBook(c *gin.Context) {
+	userID := contextUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input struct {
+		models.Book
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	book := input.Book
+	if strings.TrimSpace(book.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+		return
+	}
+	book.ID = primitive.NewObjectID()
+	book.Rating, book.AvgRating, book.ReviewCount, book.RatingSum = 0, 0, 0, 0
+	book.RatingCounts = ratings.EmptyHistogram()
+	book.CoverSizes = nil
+	if !checkNewBook(c, &book) {
+		return
+	}
+
+	if book.ISBN13 != "" {
+		var existing models.Book
+		err := config.DB.Database("bookwarm").Collection("books").
+			FindOne(context.TODO(), bson.M{"isbn13": book.ISBN13}, options.FindOne().SetProjection(bson.M{"title": 1})).
+			Decode(&existing)
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "A book with this ISBN already exists", "bookId": existing.ID.Hex()})
+			return
+		}
+		if err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ISBN"})
+			return
+		}
+	}
+
+	duplicates, err := findDuplicateBooks(context.TODO(), book.Title, bookAuthorIDs(book), book.ID)
+	if err != nil {
+		log.Printf("Failed to check duplicates of submission %s: %v", book.ID.Hex(), err)
+	}
+	submission := models.BookSubmission{
+		ID:            primitive.NewObjectID(),
+		Book:          book,
+		Note:          strings.TrimSpace(input.Note),
+		Status:        models.SubmissionPending,
+		SubmittedBy:   *userID,
+		SubmitterName: contextEditor(c).Name,
+		CreatedAt:     time.Now(),
+	}
+	for _, duplicate := range duplicates {
+		submission.DuplicateIDs = append(submission.DuplicateIDs, duplicate.ID)
+	}
+	if _, err := submissionCollection().InsertOne(context.TODO(), submission); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submission"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		models.BookSubmission
+		DuplicateWarnings []duplicateCandidate `json:"duplicateWarnings,omitempty"`
+	}{submission, duplicates})
+}
+
+// GetMySubmissions คืนหนังสือที่ผู้ใช้เคยเสนอ ใหม่สุดก่อน พร้อมสถานะและเหตุผลจากผู้ดูแล
+//
+//	GET /api/books/submissions?page=1&limit=20
+func GetMySubmissions(c *gin.Context) {
+	userID := contextUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	listSubmissions(c, bson.M{"submitted_by": *userID}, -1)
+}
+
+// ListSubmissions คือคิวของผู้ดูแล ค่าเริ่มต้นคือรายการที่รอตรวจ เก่าสุดก่อน
+//
+//	GET /api/admin/submissions?status=pending&page=1&limit=20
+func ListSubmissions(c *gin.Context) {
+	status := c.DefaultQuery("status", models.SubmissionPending)
+	switch status {
+	case models.SubmissionPending, models.SubmissionApproved, models.SubmissionMerged, models.SubmissionRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of pending, approved, merged, rejected"})
+		return
+	}
+	order := 1
+	if status != models.SubmissionPending {
+		order = -1
+	}
+	listSubmissions(c, bson.M{"status": status}, order)
+}
+
+func listSubmissions(c *gin.Context, filter bson.M, order int) {
+	page, limit := parsePagination(c)
+	ctx := context.TODO()
+	total, err := submissionCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions"})
+		return
+	}
+	cursor, err := submissionCollection().Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: order}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions"})
+		return
+	}
+	submissions := []models.BookSubmission{}
+	if err := cursor.All(ctx, &submissions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"submissions": submissions, "total": total, "page": page, "limit": limit})
+}
+
+// pendingSubmission โหลด submission จาก :id ที่ยังรอตรวจ ถ้าไม่พบหรือตรวจไปแล้วจะตอบ error แล้วคืน false
+func pendingSubmission(c *gin.Context) (models.BookSubmission, bool) {
+	var submission models.BookSubmission
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		return submission, false
+	}
+	err = submissionCollection().FindOne(context.TODO(), bson.M{"_id": id}).Decode(&submission)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return submission, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submission"})
+		return submission, false
+	}
+	if submission.Status != models.SubmissionPending {
+		c.JSON(http.StatusConflict, gin.H{"error": errSubmissionReviewed.Error(), "status": submission.Status})
+		return submission, false
+	}
+	return submission, true
+}
+
+// closeSubmission เปลี่ยนสถานะ submission ที่ยังรอตรวจ และแจ้งผลผู้ส่ง ต้องเรียกใน transaction
+// คืน errSubmissionReviewed ถ้าผู้ดูแลคนอื่นตรวจไปก่อนแล้ว
+func closeSubmission(ctx context.Context, submission *models.BookSubmission, status, reason string, bookID *primitive.ObjectID, reviewer history.Editor) error {
+	now := time.Now()
+	submission.Status = status
+	submission.Reason = reason
+	submission.BookID = bookID
+	submission.ReviewedBy = reviewer.ID
+	submission.ReviewerName = reviewer.Name
+	submission.ReviewedAt = &now
+	set := bson.M{"status": status, "reviewed_at": now, "reviewer_name": reviewer.Name}
+	if reason != "" {
+		set["reason"] = reason
+	}
+	if bookID != nil {
+		set["book_id"] = *bookID
+	}
+	if reviewer.ID != nil {
+		set["reviewed_by"] = *reviewer.ID
+	}
+	result, err := submissionCollection().UpdateOne(ctx,
+		bson.M{"_id": submission.ID, "status": models.SubmissionPending}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return errSubmissionReviewed
+	}
+
+	notification := models.Notification{
+		UserID:       submission.SubmittedBy,
+		Reason:       reason,
+		BookID:       bookID,
+		SubmissionID: &submission.ID,
+	}
+	switch status {
+	case models.SubmissionApproved:
+		notification.Type = models.NotifySubmissionApproved
+		notification.Message = "Your book \"" + submission.Book.Title + "\" was approved"
+	case models.SubmissionMerged:
+		notification.Type = models.NotifySubmissionMerged
+		notification.Message = "Your book \"" + submission.Book.Title + "\" was merged into an existing book"
+	default:
+		notification.Type = models.NotifySubmissionRejected
+		notification.Message = "Your book \"" + submission.Book.Title + "\" was rejected"
+	}
+	return notify(ctx, notification)
+}
+
+func submissionErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errSubmissionReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case config.IsDuplicateKey(err, config.IndexISBN13):
+		c.JSON(http.StatusConflict, gin.H{"error": "A book with this ISBN already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review submission"})
+	}
+}
+
+type reviewSubmissionInput struct {
+	Reason string `json:"reason"`
+	BookID string `json:"bookId"`
+}
+
+// bindReviewInput อ่าน body ของการตรวจ (ไม่บังคับส่ง body)
+func bindReviewInput(c *gin.Context) (reviewSubmissionInput, bool) {
+	var input reviewSubmissionInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return input, false
+		}
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	return input, true
+}
+
+// ApproveSubmission เพิ่มหนังสือที่เสนอเป็นหนังสือเล่มใหม่ ผู้เสนอถูกบันทึกเป็นผู้สร้างในประวัติการแก้ไข
+// ข้อมูลถูกตรวจอีกครั้ง เพราะผู้แต่งหรือหมวดที่อ้างถึงอาจถูกลบไประหว่างรอ
+//
+//	POST /api/admin/submissions/:id/approve
+//	{"reason": "ข้อความถึงผู้เสนอ (ไม่บังคับ)"}
+func ApproveSubmission(c *gin.Context) {
+	input, ok := bindReviewInput(c)
+	if !ok {
+		return
+	}
+	submission, ok := pendingSubmission(c)
+	if !ok {
+		return
+	}
+
+	book := submission.Book
+	book.CreatedAt = time.Now()
+	book.UpdatedAt = book.CreatedAt
+	if !checkNewBook(c, &book) {
+		return
+	}
+
+	submitter := history.Editor{ID: &submission.SubmittedBy, Name: submission.SubmitterName}
+	err := history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			if _, err := config.DB.Database("bookwarm").Collection("books").InsertOne(ctx, book); err != nil {
+				return err
+			}
+			if _, err := history.Record(ctx, nil, book, history.Entry{Action: models.RevisionCreate, Editor: submitter}); err != nil {
+				return err
+			}
+			return closeSubmission(ctx, &submission, models.SubmissionApproved, input.Reason, &book.ID, contextEditor(c))
+		})
+	})
+	if err != nil {
+		submissionErrorResponse(c, err)
+		return
+	}
+	search.IndexBook(book.ID)
+
+	c.JSON(http.StatusOK, gin.H{"submission": submission, "book": book})
+}
+
+// MergeSubmission ปิด submission ที่ซ้ำกับหนังสือที่มีอยู่แล้ว ฟิลด์ที่หนังสือเล่มนั้นยังว่าง
+// (เช่น คำอธิบาย ISBN จำนวนหน้า) จะถูกเติมจากข้อมูลที่เสนอ ฟิลด์ที่มีค่าอยู่แล้วไม่ถูกแก้
+//
+//	POST /api/admin/submissions/:id/merge
+//	{"bookId": "...", "reason": "มีหนังสือเล่มนี้อยู่แล้ว"}
+func MergeSubmission(c *gin.Context) {
+	input, ok := bindReviewInput(c)
+	if !ok {
+		return
+	}
+	bookID, err := primitive.ObjectIDFromHex(input.BookID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bookId is required"})
+		return
+	}
+	submission, ok := pendingSubmission(c)
+	if !ok {
+		return
+	}
+
+	books := config.DB.Database("bookwarm").Collection("books")
+	var existing models.Book
+	err = books.FindOne(context.TODO(), bson.M{"_id": bookID}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	merged, set := fillMissingFields(existing, submission.Book)
+	submitter := history.Editor{ID: &submission.SubmittedBy, Name: submission.SubmitterName}
+	err = history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			if len(set) > 0 {
+				if _, err := books.UpdateOne(ctx, bson.M{"_id": bookID}, bson.M{"$set": set}); err != nil {
+					return err
+				}
+				if _, err := history.Record(ctx, &existing, merged, history.Entry{Action: models.RevisionUpdate, Editor: submitter}); err != nil {
+					return err
+				}
+			}
+			return closeSubmission(ctx, &submission, models.SubmissionMerged, input.Reason, &bookID, contextEditor(c))
+		})
+	})
+	if err != nil {
+		submissionErrorResponse(c, err)
+		return
+	}
+	if len(set) > 0 {
+		search.IndexBook(bookID)
+	}
+
+	filled := make([]string, 0, len(set))
+	for _, field := range history.EditableFields {
+		if _, ok := set[field]; ok {
+			filled = append(filled, field)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"submission": submission, "filledFields": filled})
+}
+
+// fillMissingFields คืนหนังสือหลังเติมฟิลด์ที่ว่างจาก submitted และ $set ของฟิลด์ที่เติม
+func fillMissingFields(book, submitted models.Book) (models.Book, bson.M) {
+	set := bson.M{}
+	fillString := func(field string, dst *string, value string) {
+		if *dst == "" && value != "" {
+			*dst = value
+			set[field] = value
+		}
+	}
+	fillInt := func(field string, dst *int, value int) {
+		if *dst == 0 && value != 0 {
+			*dst = value
+			set[field] = value
+		}
+	}
+	fillString("description", &book.Description, submitted.Description)
+	if book.ISBN13 == "" && book.ISBN10 == "" && submitted.ISBN13 != "" {
+		fillString("isbn13", &book.ISBN13, submitted.ISBN13)
+		fillString("isbn10", &book.ISBN10, submitted.ISBN10)
+	}
+	fillString("language", &book.Language, submitted.Language)
+	fillString("format", &book.Format, submitted.Format)
+	fillString("publisher", &book.Publisher, submitted.Publisher)
+	fillString("coverImage", &book.CoverImage, submitted.CoverImage)
+	fillInt("publishYear", &book.PublishYear, submitted.PublishYear)
+	fillInt("pageCount", &book.PageCount, submitted.PageCount)
+	// ชื่อเรื่องและคำอธิบายภาษาอื่นเพิ่มเฉพาะภาษาที่หนังสือยังไม่มี
+	// เติมลง map ใหม่ map เดิมยังเป็นของ book ก่อนแก้ที่ใช้เทียบในประวัติ
+	fillLocalized := func(field string, dst *map[string]string, values map[string]string) {
+		filled := make(map[string]string, len(*dst)+len(values))
+		for locale, value := range *dst {
+			filled[locale] = value
+		}
+		changed := false
+		for locale, value := range values {
+			if filled[locale] != "" || value == "" {
+				continue
+			}
+			filled[locale] = value
+			changed = true
+		}
+		if changed {
+			*dst = filled
+			set[field] = filled
+		}
+	}
+	fillLocalized("titles", &book.Titles, submitted.Titles)
+	fillLocalized("descriptions", &book.Descriptions, submitted.Descriptions)
+	if len(set) > 0 {
+		book.UpdatedAt = time.Now()
+		set["updatedAt"] = book.UpdatedAt
+	}
+	return book, set
+}
+
+// RejectSubmission ปฏิเสธหนังสือที่เสนอ ต้องระบุเหตุผลซึ่งจะแจ้งให้ผู้เสนอทราบ
+//
+//	POST /api/admin/submissions/:id/reject
+//	{"reason": "ข้อมูลไม่ครบ"}
+func RejectSubmission(c *gin.Context) {
+	input, ok := bindReviewInput(c)
+	if !ok {
+		return
+	}
+	if input.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+	submission, ok := pendingSubmission(c)
+	if !ok {
+		return
+	}
+	err := config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+		return closeSubmission(ctx, &submission, models.SubmissionRejected, input.Reason, nil, contextEditor(c))
+	})
+	if err != nil {
+		submissionErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"submission": submission})
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"back/models"
+	"reflect"
+	"testing"
+)
+
+func TestFillMissingFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		book      models.Book
+		submitted models.Book
+		want      models.Book
+		filled    []string
+	}{
+		{
+			name:      "empty fields are filled",
+			book:      models.Book{Title: "Dune"},
+			submitted: models.Book{Title: "Dune!", Description: "Desert planet", PageCount: 412, PublishYear: 1965, Publisher: "Chilton"},
+			want:      models.Book{Title: "Dune", Description: "Desert planet", PageCount: 412, PublishYear: 1965, Publisher: "Chilton"},
+			filled:    []string{"description", "publisher", "publishYear", "pageCount"},
+		},
+		{
+			name:      "existing values are kept",
+			book:      models.Book{Description: "Original", PageCount: 400, Format: "paperback"},
+			submitted: models.Book{Description: "Replacement", PageCount: 412, Format: "ebook"},
+			want:      models.Book{Description: "Original", PageCount: 400, Format: "paperback"},
+		},
+		{
+			name:      "isbns are filled together",
+			book:      models.Book{},
+			submitted: models.Book{ISBN13: "9780441013593", ISBN10: "0441013597"},
+			want:      models.Book{ISBN13: "9780441013593", ISBN10: "0441013597"},
+			filled:    []string{"isbn13", "isbn10"},
+		},
+		{
+			name:      "isbn10 alone does not mix with another isbn13",
+			book:      models.Book{ISBN10: "0441013597"},
+			submitted: models.Book{ISBN13: "9780306406157", ISBN10: "0306406152"},
+			want:      models.Book{ISBN10: "0441013597"},
+		},
+		{
+			name:      "only missing locales are added",
+			book:      models.Book{Titles: map[string]string{"th": "ดูน"}},
+			submitted: models.Book{Titles: map[string]string{"th": "ดูนส์", "ja": "デューン", "ko": ""}},
+			want:      models.Book{Titles: map[string]string{"th": "ดูน", "ja": "デューン"}},
+			filled:    []string{"titles"},
+		},
+		{
+			name:      "nothing to fill",
+			book:      models.Book{Description: "Original"},
+			submitted: models.Book{},
+			want:      models.Book{Description: "Original"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book, set := fillMissingFields(tt.book, tt.submitted)
+			if len(set) > 0 {
+				if book.UpdatedAt.IsZero() || set["updatedAt"] != book.UpdatedAt {
+					t.Fatalf("updatedAt not set: %v", set)
+				}
+				delete(set, "updatedAt")
+			}
+			book.UpdatedAt = tt.want.UpdatedAt
+			if !reflect.DeepEqual(book, tt.want) {
+				t.Fatalf("book = %+v, want %+v", book, tt.want)
+			}
+			if len(set) != len(tt.filled) {
+				t.Fatalf("set = %v, want fields %v", set, tt.filled)
+			}
+			for _, field := range tt.filled {
+				if _, ok := set[field]; !ok {
+					t.Fatalf("set = %v, want fields %v", set, tt.filled)
+				}
+			}
+		})
+	}
+}
+
+func TestFillMissingFieldsKeepsOriginalMaps(t *testing.T) {
+	// MergeSubmission ใช้หนังสือเดิมเป็น before ของประวัติ จึงต้องไม่ถูกแก้ไปด้วย
+	existing := models.Book{Titles: map[string]string{"th": "ดูน"}}
+	merged, _ := fillMissingFields(existing, models.Book{Titles: map[string]string{"ja": "デューン"}})
+	if len(existing.Titles) != 1 {
+		t.Fatalf("original titles were modified: %v", existing.Titles)
+	}
+	if len(merged.Titles) != 2 {
+		t.Fatalf("merged titles = %v", merged.Titles)
+	}
+}
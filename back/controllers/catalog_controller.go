@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"back/catalog"
+	"back/config"
+	"back/history"
+	"back/models"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ขนาดไฟล์ import สูงสุด
+const maxImportSize = 50 << 20
+
+// ImportCatalog รับไฟล์ CSV, JSONL หรือ ONIX 3.0 (multipart field "file" หรือส่งเป็น body ตรง ๆ)
+// แล้วเริ่ม import เป็นงานเบื้องหลัง ตอบกลับทันทีด้วย id ของงานเพื่อใช้ดูความคืบหน้า
+// subject ใน ONIX จับคู่กับ genre/tag ที่มีอยู่แล้วตามชื่อเท่านั้น
+//
+//	POST /api/admin/catalog/import?format=csv|jsonl|onix&dry_run=true
+func ImportCatalog(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid User ID format in context"})
+		return
+	}
+
+	// ไฟล์ที่ใหญ่เกินต้องถูกปฏิเสธทั้งไฟล์ ถ้าตัดทิ้งเฉย ๆ แถวสุดท้ายที่ขาดครึ่งอาจถูก import เหมือนแถวปกติ
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	var body io.Reader
+	fileName := ""
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		fileName = header.Filename
+	} else if tooLarge(err) {
+		importTooLarge(c)
+		return
+	} else {
+		body = c.Request.Body
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = catalog.FormatFromName(fileName)
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, jsonl or onix"})
+		return
+	}
+
+	var rows []catalog.ParsedRow
+	if format == catalog.FormatONIX {
+		mapper, mapErr := catalog.LoadSubjectMapper(c.Request.Context(), "")
+		if mapErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load genres and tags"})
+			return
+		}
+		rows, err = catalog.ParseONIX(body, mapper)
+	} else {
+		rows, err = catalog.Parse(body, format)
+	}
+	if tooLarge(err) {
+		importTooLarge(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File contains no rows"})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	job := models.ImportJob{
+		ID:        primitive.NewObjectID(),
+		Status:    models.JobQueued,
+		Format:    format,
+		FileName:  fileName,
+		DryRun:    dryRun,
+		CreatedBy: userID,
+		Report:    models.ImportReport{Total: len(rows)},
+		CreatedAt: time.Now(),
+	}
+	jobs := config.DB.Database("bookwarm").Collection("import_jobs")
+	if _, err := jobs.InsertOne(context.TODO(), job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create import job"})
+		return
+	}
+
+	go runImportJob(job.ID, rows, dryRun, contextEditor(c))
+
+	c.JSON(http.StatusAccepted, gin.H{"job_id": job.ID.Hex(), "status": job.Status, "total": len(rows)})
+}
+
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+func importTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is larger than " + strconv.Itoa(maxImportSize>>20) + " MB"})
+}
+
+func runImportJob(jobID primitive.ObjectID, rows []catalog.ParsedRow, dryRun bool, editor history.Editor) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+	jobs := config.DB.Database("bookwarm").Collection("import_jobs")
+
+	setJob := func(fields bson.M) {
+		if _, err := jobs.UpdateOne(context.Background(), bson.M{"_id": jobID}, bson.M{"$set": fields}); err != nil {
+			log.Printf("Failed to update import job %s: %v", jobID.Hex(), err)
+		}
+	}
+
+	started := time.Now()
+	setJob(bson.M{"status": models.JobRunning, "startedAt": started})
+
+	report, err := catalog.Import(ctx, rows, catalog.Options{
+		DryRun:   dryRun,
+		Progress: func(report models.ImportReport) { setJob(bson.M{"report": report}) },
+		Editor:   editor,
+	})
+
+	finished := time.Now()
+	fields := bson.M{"status": models.JobDone, "report": report, "finishedAt": finished}
+	if err != nil {
+		fields["status"] = models.JobFailed
+		fields["error"] = err.Error()
+	}
+	setJob(fields)
+	log.Printf("Import job %s finished in %s: %d created, %d updated, %d failed",
+		jobID.Hex(), finished.Sub(started).Round(time.Millisecond), report.Created, report.Updated, report.Failed)
+}
+
+func GetImportJob(c *gin.Context) {
+	jobID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var job models.ImportJob
+	err = config.DB.Database("bookwarm").Collection("import_jobs").FindOne(context.TODO(), bson.M{"_id": jobID}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch import job"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ListImportJobs คืนงาน import ล่าสุด 50 งาน (ไม่รวมรายการ error ของแต่ละแถว)
+func ListImportJobs(c *gin.Context) {
+	opts := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetLimit(50).
+		SetProjection(bson.M{"report.errors": 0})
+	cursor, err := config.DB.Database("bookwarm").Collection("import_jobs").Find(context.TODO(), bson.M{}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch import jobs"})
+		return
+	}
+	jobs := []models.ImportJob{}
+	if err := cursor.All(context.TODO(), &jobs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode import jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// ExportCatalog ส่งออกหนังสือทั้งหมดในรูปแบบเดียวกับที่ ImportCatalog รับ
+//
+//	GET /api/admin/catalog/export?format=csv|jsonl
+func ExportCatalog(c *gin.Context) {
+	format := c.DefaultQuery("format", catalog.FormatCSV)
+	writer, err := catalog.NewWriter(c.Writer, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == catalog.FormatJSONL {
+		contentType = "application/x-ndjson; charset=utf-8"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename=bookwarm-catalog-"+time.Now().Format("20060102")+"."+format)
+	c.Status(http.StatusOK)
+
+	// header ถูกส่งไปแล้ว ถ้าผิดพลาดกลางทางทำได้แค่บันทึก log
+	if _, err := catalog.Export(c.Request.Context(), writer); err != nil {
+		log.Printf("Catalog export failed: %v", err)
+	}
+}
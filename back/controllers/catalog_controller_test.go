@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// oversizedCSV คือ CSV ที่ถูกต้องทุกแถวแต่ใหญ่เกิน maxImportSize
+func oversizedCSV() []byte {
+	row := "A Book That Fills The Upload,Some Author\n"
+	return []byte("title,authors\n" + strings.Repeat(row, maxImportSize/len(row)+1))
+}
+
+func postImport(t *testing.T, body *bytes.Buffer, contentType string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/admin/catalog/import", func(c *gin.Context) {
+		c.Set("userId", primitive.NewObjectID().Hex())
+		ImportCatalog(c)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/catalog/import?format=csv", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImportCatalogRejectsOversizedBody(t *testing.T) {
+	rec := postImport(t, bytes.NewBuffer(oversizedCSV()), "text/csv")
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestImportCatalogRejectsOversizedUpload(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "catalog.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write(oversizedCSV())
+	form.Close()
+
+	rec := postImport(t, &body, form.FormDataContentType())
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413: %s", rec.Code, rec.Body)
+	}
+}
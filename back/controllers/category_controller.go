@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"back/config"
+	"back/i18n"
 	"back/models"
 	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -19,10 +21,26 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
+	if err := validateParent(context.TODO(), "category", primitive.NilObjectID, input.ParentID); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	name, names, err := i18n.Fold(input.Name, input.Names)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Name, input.Names = name, names
+	if err := validateSiblingName(context.TODO(), "category", primitive.NilObjectID, input.ParentID, input.Name); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	input.ID = primitive.NewObjectID()
 	collection := config.DB.Database("bookwarm").Collection("category")
 
-	_, err := collection.InsertOne(context.TODO(), input)
+	_, err = collection.InsertOne(context.TODO(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
 		return
@@ -42,6 +60,7 @@ func GetAllCategory(c *gin.Context) {
 
 	defer cursor.Close(context.TODO())
 
+	locale := contextLocale(c)
 	var categories []models.Category
 	for cursor.Next(context.TODO()) {
 		var category models.Category
@@ -49,6 +68,7 @@ func GetAllCategory(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode category"})
 			return
 		}
+		category.Name = i18n.Pick(category.Names, category.Name, locale)
 		categories = append(categories, category)
 	}
 	c.JSON(http.StatusOK, categories)
@@ -57,7 +77,11 @@ func GetAllCategory(c *gin.Context) {
 func UpdateCategory(c *gin.Context) {
 	categoryID := c.Param("id")
 
-	var input models.Category
+	var input struct {
+		Name     string            `json:"name"`
+		Names    map[string]string `json:"names"`
+		ParentID json.RawMessage   `json:"parentId"`
+	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -69,8 +93,25 @@ func UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	set := bson.M{"update_at": time.Now()}
+	unset := bson.M{}
+	if err := namesUpdate(input.Name, input.Names, set, unset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := parentUpdate(context.TODO(), "category", objectID, input.ParentID, set, unset); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateUpdatedName(context.TODO(), "category", objectID, set, unset); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 	collection := config.DB.Database("bookwarm").Collection("category")
-	update := bson.M{"$set":bson.M{"name": input.Name, "update_at": time.Now()}}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": objectID},update)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
@@ -87,6 +128,17 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
+	// ลบเฉพาะที่ไม่มีลูก ไม่เช่นนั้นลูกหลานจะหลุดไปเป็นระดับบนสุดโดยไม่ตั้งใจ
+	children, err := hasChildren(context.TODO(), "category", objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+	if children {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category has sub-categories, move or delete them first"})
+		return
+	}
+
 	collection := config.DB.Database("bookwarm").Collection("category")
 	_, err = collection.DeleteOne(context.TODO(),bson.M{"_id": objectID})
 	if err != nil {
@@ -3,6 +3,7 @@ package controllers
 import (
 	"back/config"
 	"back/models"
+	"back/search"
 	"context"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"log"
 )
@@ -89,6 +91,7 @@ func CreateClub(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create club"})
 		return
 	}
+	search.IndexClub(club.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Club created successfully", "id": club.ID})
 }
@@ -188,6 +191,15 @@ func JoinClub(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join club"})
 		return
 	}
+	if result.ModifiedCount > 0 {
+		// เวลาเข้าร่วมใช้จัดอันดับคลับที่มาแรง ถ้าบันทึกไม่ได้ก็ไม่ใช่เหตุให้การเข้าร่วมล้มเหลว
+		join := models.ClubJoin{ClubID: clubID, UserID: user.ID, JoinedAt: time.Now()}
+		_, err := config.DB.Database("bookwarm").Collection("club_joins").UpdateOne(context.TODO(),
+			bson.M{"club_id": clubID, "user_id": user.ID}, bson.M{"$setOnInsert": join}, options.Update().SetUpsert(true))
+		if err != nil {
+			log.Printf("Failed to record join of club %s: %v", clubID.Hex(), err)
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Joined club successfully"})
 }
@@ -235,6 +247,10 @@ func LeaveClub(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave club"})
 		return
 	}
+	if _, err := config.DB.Database("bookwarm").Collection("club_joins").DeleteOne(context.TODO(),
+		bson.M{"club_id": clubID, "user_id": user.ID}); err != nil {
+		log.Printf("Failed to remove join of club %s: %v", clubID.Hex(), err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Left club successfully"})
 }
@@ -286,6 +302,7 @@ func UpdateClub(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update club"})
 		return
 	}
+	search.IndexClub(clubID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Club updated successfully", "id": clubID})
 }
@@ -305,6 +322,10 @@ func DeleteClub(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete club"})
 		return
 	}
+	search.RemoveClub(clubID)
+	if _, err := config.DB.Database("bookwarm").Collection("club_joins").DeleteMany(context.TODO(), bson.M{"club_id": clubID}); err != nil {
+		log.Printf("Failed to remove joins of club %s: %v", clubID.Hex(), err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Club deleted successfully"})
 }
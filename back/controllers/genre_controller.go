@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"back/config"
+	"back/i18n"
 	"back/models"
 	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -19,10 +21,26 @@ func CreateGenre(c *gin.Context){
 		return
 	}
 
+	if err := validateParent(context.TODO(), "genre", primitive.NilObjectID, input.ParentID); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	name, names, err := i18n.Fold(input.Name, input.Names)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Name, input.Names = name, names
+	if err := validateSiblingName(context.TODO(), "genre", primitive.NilObjectID, input.ParentID, input.Name); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	input.ID = primitive.NewObjectID()
 	collection := config.DB.Database("bookwarm").Collection("genre")
 
-	_, err := collection.InsertOne(context.TODO(), input)
+	_, err = collection.InsertOne(context.TODO(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,6 +60,7 @@ func GetAllGenre(c *gin.Context){
 
 	defer cursor.Close(context.TODO())
 
+	locale := contextLocale(c)
 	var genres []models.Genre
 	for cursor.Next(context.TODO()){
 		var genre models.Genre
@@ -49,6 +68,7 @@ func GetAllGenre(c *gin.Context){
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		genre.Name = i18n.Pick(genre.Names, genre.Name, locale)
 		genres = append(genres, genre)
 	}
 	c.JSON(http.StatusOK, genres)
@@ -57,7 +77,11 @@ func GetAllGenre(c *gin.Context){
 func UpdateGenre(c *gin.Context){
 	genreID := c.Param("id")
 
-	var input models.Genre
+	var input struct {
+		Name     string            `json:"name"`
+		Names    map[string]string `json:"names"`
+		ParentID json.RawMessage   `json:"parentId"`
+	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -69,8 +93,25 @@ func UpdateGenre(c *gin.Context){
 		return
 	}
 
+	set := bson.M{"update_at": time.Now()}
+	unset := bson.M{}
+	if err := namesUpdate(input.Name, input.Names, set, unset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := parentUpdate(context.TODO(), "genre", objectID, input.ParentID, set, unset); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateUpdatedName(context.TODO(), "genre", objectID, set, unset); err != nil {
+		c.JSON(parentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 	collection := config.DB.Database("bookwarm").Collection("genre")
-	update := bson.M{"$set":bson.M{"name": input.Name, "update_at": time.Now()}}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": objectID},update)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update genre"})
@@ -87,6 +128,17 @@ func DeleteGenre(c *gin.Context){
 		return
 	}
 
+	// ลบเฉพาะที่ไม่มีลูก ไม่เช่นนั้นลูกหลานจะหลุดไปเป็นระดับบนสุดโดยไม่ตั้งใจ
+	children, err := hasChildren(context.TODO(), "genre", objectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete genre"})
+		return
+	}
+	if children {
+		c.JSON(http.StatusConflict, gin.H{"error": "Genre has sub-genres, move or delete them first"})
+		return
+	}
+
 	collection := config.DB.Database("bookwarm").Collection("genre")
 	_, err = collection.DeleteOne(context.TODO(),bson.M{"_id":objectID})
 	if err != nil {
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"back/config"
+	"back/i18n"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contextLocale คือภาษาที่ LocaleMiddleware เลือกไว้ ถ้าไม่มี middleware (เช่นใน route ที่ลงทะเบียนเอง)
+// จะเลือกจาก request เอง
+func contextLocale(c *gin.Context) string {
+	if locale, ok := c.Get("locale"); ok {
+		return locale.(string)
+	}
+	return i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+}
+
+// GetLocales คืนภาษาที่รองรับ ภาษาหลักของระบบ และภาษาที่เลือกให้ request นี้
+//
+//	GET /api/locales
+func GetLocales(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"default":   config.DefaultLocale(),
+		"supported": config.SupportedLocales(),
+		"locale":    contextLocale(c),
+	})
+}
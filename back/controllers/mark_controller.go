@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 
@@ -61,24 +62,40 @@ func CreateMark(c *gin.Context) {
 		return
 	}
 
+	// ผู้ใช้มี mark ได้หนึ่งอันต่อหนึ่งงาน ถ้าเคย mark edition อื่นของงานเดียวกันไว้
+	// จะย้าย mark นั้นมาที่ edition นี้แทนการสร้างใหม่
+	_, workID, err := editionIDs(context.TODO(), input.BookID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+	filter := bson.M{"user_id": userID, "book_id": input.BookID}
+	if workID != nil {
+		filter = bson.M{"user_id": userID, "$or": []bson.M{{"book_id": input.BookID}, {"work_id": *workID}}}
+	}
+
 	collection := config.DB.Database("bookwarm").Collection("marks")
 	var existingMark models.Mark
-	err = collection.FindOne(context.TODO(), bson.M{
-		"user_id": userID,
-		"book_id": input.BookID,
-	}).Decode(&existingMark)
+	err = collection.FindOne(context.TODO(), filter).Decode(&existingMark)
 
 	if err == nil {
-		_, err = collection.UpdateOne(
-			context.TODO(),
-			bson.M{"_id": existingMark.ID},
-			bson.D{
-				{"$set", bson.D{
-					{"status", input.Status},
-					{"updated_at", time.Now()},
-				}},
-			},
-		)
+		// work_id ต้องตรงกับ edition ใหม่ด้วย mark เก่าที่ยังไม่มี work_id จึงเข้า unique index และค้นระดับงานเจอ
+		set := bson.M{
+			"status":     input.Status,
+			"book_id":    input.BookID,
+			"updated_at": time.Now(),
+		}
+		update := bson.M{"$set": set}
+		if workID != nil {
+			set["work_id"] = *workID
+		} else {
+			update["$unset"] = bson.M{"work_id": ""}
+		}
+		_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": existingMark.ID}, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mark"})
 			return
@@ -94,6 +111,7 @@ func CreateMark(c *gin.Context) {
 		ID:        primitive.NewObjectID(),
 		UserID:    userID,
 		BookID:    input.BookID,
+		WorkID:    workID,
 		Status:    input.Status,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -186,8 +204,12 @@ func GetMarksByUser(c *gin.Context) {
 				"as":           "book",
 			},
 		},
+		// ไม่ซ่อน mark ที่หนังสือถูกลบไปแล้ว ให้ client แสดงว่าหนังสือไม่มีแล้ว
 		{
-			"$unwind": "$book",
+			"$unwind": bson.M{"path": "$book", "preserveNullAndEmptyArrays": true},
+		},
+		{
+			"$set": bson.M{"book_missing": bson.M{"$not": []interface{}{"$book"}}},
 		},
 	}
 
@@ -227,12 +249,15 @@ func GetMarkByUserAndBook(c *gin.Context) {
 		return
 	}
 
+	// คืน mark ของ edition ไหนก็ได้ในงานเดียวกัน book_id ของ mark บอกว่าอ่าน edition ไหน
+	filter := bson.M{"user_id": userID, "book_id": bookID}
+	if _, workID, err := editionIDs(context.TODO(), bookID); err == nil && workID != nil {
+		filter = bson.M{"user_id": userID, "$or": []bson.M{{"book_id": bookID}, {"work_id": *workID}}}
+	}
+
 	collection := config.DB.Database("bookwarm").Collection("marks")
 	var mark models.Mark
-	err = collection.FindOne(context.TODO(), bson.M{
-		"user_id": userID,
-		"book_id": bookID,
-	}).Decode(&mark)
+	err = collection.FindOne(context.TODO(), filter).Decode(&mark)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Mark not found for this user and book"})
 		return
@@ -407,7 +432,10 @@ func GetMarksByUserID(c *gin.Context) {
 			},
 		},
 		{
-			"$unwind": "$book", 
+			"$unwind": bson.M{"path": "$book", "preserveNullAndEmptyArrays": true},
+		},
+		{
+			"$set": bson.M{"book_missing": bson.M{"$not": []interface{}{"$book"}}},
 		},
 		
 	}
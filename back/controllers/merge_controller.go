@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"back/catalog"
+	"back/config"
+	"back/models"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mergeTaxonomy รวมข้อมูล :id เข้ากับ targetId ใน body (ดู catalog.Merge)
+func mergeTaxonomy(c *gin.Context, kind string) {
+	sourceID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + kind + " ID"})
+		return
+	}
+	var input struct {
+		TargetID string `json:"targetId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "targetId is required"})
+		return
+	}
+	targetID, err := primitive.ObjectIDFromHex(input.TargetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid targetId"})
+		return
+	}
+
+	report, err := catalog.Merge(context.TODO(), kind, sourceID, targetID, contextEditor(c))
+	switch err {
+	case nil:
+	case catalog.ErrMergeSelf:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case catalog.ErrMergeSourceMissing, catalog.ErrMergeTargetMissing:
+		c.JSON(http.StatusNotFound, gin.H{"error": kind + " " + err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge " + kind})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// MergeAuthor รวมนักเขียนที่ซ้ำกัน หนังสือทุกเล่มของ :id ย้ายไปเป็นของ targetId และชื่อเดิมกลายเป็นนามปากกา
+//
+//	POST /api/admin/authors/:id/merge
+//	{"targetId": "..."}
+func MergeAuthor(c *gin.Context) { mergeTaxonomy(c, catalog.TaxonomyAuthor) }
+
+// MergeTag รวมแท็กที่ซ้ำกัน
+//
+//	POST /api/admin/tags/:id/merge
+func MergeTag(c *gin.Context) { mergeTaxonomy(c, catalog.TaxonomyTag) }
+
+// MergeGenre รวมประเภทที่ซ้ำกัน
+//
+//	POST /api/admin/genres/:id/merge
+func MergeGenre(c *gin.Context) { mergeTaxonomy(c, catalog.TaxonomyGenre) }
+
+// MergeCategory รวมหมวดที่ซ้ำกัน
+//
+//	POST /api/admin/categories/:id/merge
+func MergeCategory(c *gin.Context) { mergeTaxonomy(c, catalog.TaxonomyCategory) }
+
+// GetAuditLog คืนการกระทำของผู้ดูแลที่บันทึกไว้ ใหม่สุดก่อน
+//
+//	GET /api/admin/audit?action=merge&type=tag&entity=<id>&page=1&limit=20
+func GetAuditLog(c *gin.Context) {
+	page, limit := parsePagination(c)
+	filter := bson.M{}
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+	if entityType := c.Query("type"); entityType != "" {
+		filter["entity_type"] = entityType
+	}
+	if entity := c.Query("entity"); entity != "" {
+		id, err := primitive.ObjectIDFromHex(entity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+			return
+		}
+		// ทั้งเป้าหมายและข้อมูลที่ถูกรวมเข้าไป
+		filter["$or"] = []bson.M{{"entity_id": id}, {"merge.source_id": id}}
+	}
+
+	ctx := context.TODO()
+	collection := config.DB.Database("bookwarm").Collection("audit_log")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "total": total, "page": page, "limit": limit})
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func notificationCollection() *mongo.Collection {
+	return config.DB.Database("bookwarm").Collection("notifications")
+}
+
+// notify เพิ่มการแจ้งเตือนให้ผู้ใช้ ใช้ ctx ของ transaction ได้
+func notify(ctx context.Context, notification models.Notification) error {
+	notification.ID = primitive.NewObjectID()
+	notification.Read = false
+	notification.CreatedAt = time.Now()
+	_, err := notificationCollection().InsertOne(ctx, notification)
+	return err
+}
+
+// GetNotifications คืนการแจ้งเตือนของผู้ใช้ ใหม่สุดก่อน พร้อมจำนวนที่ยังไม่ได้อ่าน
+//
+//	GET /api/notifications?unread=true&page=1&limit=20
+func GetNotifications(c *gin.Context) {
+	userID := contextUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	page, limit := parsePagination(c)
+	filter := bson.M{"user_id": *userID}
+	if unread, _ := strconv.ParseBool(c.Query("unread")); unread {
+		filter["read"] = false
+	}
+
+	ctx := context.TODO()
+	total, err := notificationCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+	unreadCount, err := notificationCollection().CountDocuments(ctx, bson.M{"user_id": *userID, "read": false})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+	cursor, err := notificationCollection().Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread":        unreadCount,
+		"total":         total,
+		"page":          page,
+		"limit":         limit,
+	})
+}
+
+// MarkNotificationRead ทำเครื่องหมายว่าอ่านแล้ว เฉพาะการแจ้งเตือนของผู้ใช้เอง
+//
+//	POST /api/notifications/:id/read
+func MarkNotificationRead(c *gin.Context) {
+	userID := contextUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+	result, err := notificationCollection().UpdateOne(context.TODO(),
+		bson.M{"_id": id, "user_id": *userID}, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsRead ทำเครื่องหมายว่าอ่านแล้วทุกรายการ
+//
+//	POST /api/notifications/read-all
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID := contextUserID(c)
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	result, err := notificationCollection().UpdateMany(context.TODO(),
+		bson.M{"user_id": *userID, "read": false}, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read", "updated": result.ModifiedCount})
+}
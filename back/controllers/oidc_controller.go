@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"back/config"
+	"back/models"
+	"back/utils"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+func GetOIDCProviders(c *gin.Context) {
+	names := []string{}
+	for name := range config.OIDCProviders() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.JSON(http.StatusOK, gin.H{"providers": names})
+}
+
+func OIDCLogin(c *gin.Context) {
+	provider, ok := config.OIDCProviders()[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	doc, err := utils.DiscoverOIDC(provider.IssuerURL)
+	if err != nil {
+		log.Printf("OIDC discovery failed for %s: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Login provider is unavailable"})
+		return
+	}
+
+	state, err := utils.RandomURLString(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	nonce, err := utils.RandomURLString(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	verifier, err := utils.RandomURLString(48)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	err = oidcUsers.SaveState(context.TODO(), models.OIDCState{
+		State:        state,
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, utils.OIDCAuthURL(provider, doc, state, nonce, verifier))
+}
+
+func OIDCCallback(c *gin.Context) {
+	provider, ok := config.OIDCProviders()[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown login provider"})
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login was rejected by provider: " + providerErr})
+		return
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	// state ใช้ได้ครั้งเดียว ลบทิ้งทันทีที่อ่าน
+	saved, err := oidcUsers.TakeState(context.TODO(), state, provider.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+		return
+	}
+
+	doc, err := utils.DiscoverOIDC(provider.IssuerURL)
+	if err != nil {
+		log.Printf("OIDC discovery failed for %s: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Login provider is unavailable"})
+		return
+	}
+
+	rawIDToken, err := utils.ExchangeOIDCCode(provider, doc, code, saved.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC code exchange failed for %s: %v", provider.Name, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to exchange authorization code"})
+		return
+	}
+
+	claims, err := utils.VerifyIDToken(provider, doc, rawIDToken, saved.Nonce)
+	if err != nil {
+		log.Printf("OIDC id_token rejected for %s: %v", provider.Name, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid identity token"})
+		return
+	}
+
+	user, err := findOrLinkOIDCUser(provider.Name, claims)
+	if err != nil {
+		if err == errOIDCEmailNotVerified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email is not verified by the login provider"})
+			return
+		}
+		log.Printf("OIDC user lookup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+
+	token, err := utils.CreateToken(user.ID, user.Email, user.DisplayName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	if redirect := config.OIDCSuccessRedirect(); redirect != "" {
+		c.Redirect(http.StatusFound, redirect+"#token="+url.QueryEscape(token))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "User login successfully",
+		"token":           token,
+		"displayname":     user.DisplayName,
+		"profile_img_url": user.ProfilePic,
+	})
+}
+
+var errOIDCEmailNotVerified = errors.New("email not verified")
+
+// findOrLinkOIDCUser หา user จาก identity ที่เคยผูกไว้ ถ้าไม่เจอจะผูกกับ user เดิมที่ใช้อีเมลเดียวกัน
+// (เฉพาะอีเมลที่ provider ยืนยันแล้ว) หรือสร้าง user ใหม่ที่ไม่มีรหัสผ่าน
+func findOrLinkOIDCUser(provider string, claims *utils.OIDCClaims) (*models.User, error) {
+	ctx := context.TODO()
+	user, err := oidcUsers.UserByIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errOIDCEmailNotVerified
+	}
+
+	identity := models.Identity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		LinkedAt: time.Now(),
+	}
+
+	user, err = oidcUsers.UserByEmail(ctx, claims.Email)
+	if err == nil {
+		if err := oidcUsers.LinkIdentity(ctx, user.ID, identity); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	displayName := claims.Name
+	if displayName == "" {
+		displayName = claims.Email
+	}
+	user = &models.User{
+		Email:       claims.Email,
+		DisplayName: displayName,
+		ProfilePic:  claims.Picture,
+		Identities:  []models.Identity{identity},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := oidcUsers.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
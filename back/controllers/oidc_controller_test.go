@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"back/models"
+	"back/utils/oidctest"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryOIDCStore คือ oidcStore ในหน่วยความจำสำหรับ test
+type memoryOIDCStore struct {
+	mu     sync.Mutex
+	states map[string]models.OIDCState
+	users  []*models.User
+}
+
+func newMemoryOIDCStore() *memoryOIDCStore {
+	return &memoryOIDCStore{states: map[string]models.OIDCState{}}
+}
+
+func (s *memoryOIDCStore) SaveState(_ context.Context, state models.OIDCState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.State] = state
+	return nil
+}
+
+func (s *memoryOIDCStore) TakeState(_ context.Context, state, provider string) (models.OIDCState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved, ok := s.states[state]
+	delete(s.states, state)
+	if !ok || saved.Provider != provider || !saved.ExpiresAt.After(time.Now()) {
+		return models.OIDCState{}, mongo.ErrNoDocuments
+	}
+	return saved, nil
+}
+
+func (s *memoryOIDCStore) UserByIdentity(_ context.Context, provider, subject string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		for _, identity := range user.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				copied := *user
+				return &copied, nil
+			}
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryOIDCStore) UserByEmail(_ context.Context, email string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryOIDCStore) LinkIdentity(_ context.Context, userID primitive.ObjectID, identity models.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.ID == userID {
+			user.Identities = append(user.Identities, identity)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (s *memoryOIDCStore) CreateUser(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user.ID = primitive.NewObjectID()
+	copied := *user
+	s.users = append(s.users, &copied)
+	return nil
+}
+
+const oidcCallbackURL = "http://bookwarm.test/api/auth/oidc/mock/callback"
+
+// setupOIDC เปิด issuer จำลอง ตั้ง provider "mock" ให้ชี้ไปที่ issuer และใช้ store ในหน่วยความจำ
+func setupOIDC(t *testing.T) (*gin.Engine, *oidctest.Issuer, *memoryOIDCStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	server, issuer := oidctest.NewServer(t)
+	t.Setenv("OIDC_PROVIDERS", "mock")
+	t.Setenv("OIDC_MOCK_ISSUER", server.URL)
+	t.Setenv("OIDC_MOCK_CLIENT_ID", "bookwarm")
+	t.Setenv("OIDC_MOCK_REDIRECT_URL", oidcCallbackURL)
+	t.Setenv("OIDC_SUCCESS_REDIRECT", "")
+
+	store := newMemoryOIDCStore()
+	previous := oidcUsers
+	oidcUsers = store
+	t.Cleanup(func() { oidcUsers = previous })
+
+	router := gin.New()
+	router.GET("/api/auth/oidc/:provider/login", OIDCLogin)
+	router.GET("/api/auth/oidc/:provider/callback", OIDCCallback)
+	return router, issuer, store
+}
+
+// startLogin เรียก /login แล้วตาม redirect ไปที่ issuer คืน URL ของ callback ที่ issuer ส่งกลับมา
+func startLogin(t *testing.T, router *gin.Engine) *url.URL {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status = %d: %s", rec.Code, rec.Body)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d", resp.StatusCode)
+	}
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback
+}
+
+func callback(router *gin.Engine, callback *url.URL) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil))
+	return rec
+}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	router, _, store := setupOIDC(t)
+
+	rec := callback(router, startLogin(t, router))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		DisplayName string `json:"displayname"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Token == "" || body.DisplayName != "Mock Reader" {
+		t.Fatalf("unexpected response %s", rec.Body)
+	}
+	if len(store.users) != 1 || store.users[0].Email != "reader@example.com" || len(store.users[0].Identities) != 1 {
+		t.Fatalf("users = %+v", store.users)
+	}
+
+	// login ครั้งที่สองเจอ user เดิมจาก identity ไม่สร้างหรือผูกซ้ำ
+	if rec := callback(router, startLogin(t, router)); rec.Code != http.StatusOK {
+		t.Fatalf("second callback status = %d: %s", rec.Code, rec.Body)
+	}
+	if len(store.users) != 1 || len(store.users[0].Identities) != 1 {
+		t.Fatalf("users after second login = %+v", store.users)
+	}
+}
+
+// setupExistingUser เตรียมผู้ใช้ที่สมัครด้วยอีเมลเดียวกับบัญชีของ issuer ไว้ก่อน
+func setupExistingUser(t *testing.T, emailVerified bool) (*gin.Engine, *memoryOIDCStore) {
+	t.Helper()
+	router, issuer, store := setupOIDC(t)
+	issuer.EmailVerified = emailVerified
+	store.CreateUser(context.Background(), &models.User{Email: "reader@example.com", DisplayName: "Existing Reader"})
+	return router, store
+}
+
+func TestOIDCLoginLinksVerifiedEmail(t *testing.T) {
+	router, store := setupExistingUser(t, true)
+
+	rec := callback(router, startLogin(t, router))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", rec.Code, rec.Body)
+	}
+	if len(store.users) != 1 || len(store.users[0].Identities) != 1 {
+		t.Fatalf("identity was not linked to the existing user: %+v", store.users)
+	}
+}
+
+func TestOIDCLoginRefusesUnverifiedEmail(t *testing.T) {
+	router, store := setupExistingUser(t, false)
+
+	rec := callback(router, startLogin(t, router))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("callback status = %d, want 403: %s", rec.Code, rec.Body)
+	}
+	if len(store.users) != 1 || len(store.users[0].Identities) != 0 {
+		t.Fatalf("unverified email took over the account: %+v", store.users)
+	}
+}
+
+// การตรวจ claim แต่ละตัวอยู่ใน utils ที่นี่ตรวจแค่ว่า token ที่ไม่ผ่านตอบ 401 และไม่สร้างผู้ใช้
+func TestOIDCCallbackRejectsInvalidToken(t *testing.T) {
+	router, issuer, store := setupOIDC(t)
+	issuer.Claims = func(c jwt.MapClaims) { c["nonce"] = "replayed" }
+
+	if rec := callback(router, startLogin(t, router)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("callback status = %d, want 401: %s", rec.Code, rec.Body)
+	}
+	if len(store.users) != 0 {
+		t.Fatalf("user created from a rejected token: %+v", store.users)
+	}
+}
+
+func TestOIDCCallbackStateIsSingleUse(t *testing.T) {
+	router, _, _ := setupOIDC(t)
+	target := startLogin(t, router)
+	if rec := callback(router, target); rec.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := callback(router, target); rec.Code != http.StatusBadRequest {
+		t.Fatalf("replayed callback status = %d, want 400", rec.Code)
+	}
+}
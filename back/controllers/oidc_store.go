@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// oidcStore เก็บ state ระหว่าง redirect และหา/ผูก/สร้าง user ของ social login
+// แยกออกจาก MongoDB เพื่อให้ test เดิน flow ทั้งหมดกับ issuer จำลองได้โดยไม่ต้องมีฐานข้อมูล
+// ไม่เจอข้อมูลคืน mongo.ErrNoDocuments
+type oidcStore interface {
+	SaveState(ctx context.Context, state models.OIDCState) error
+	// TakeState คืนและลบ state ที่ยังไม่หมดอายุ state จึงใช้ได้ครั้งเดียว
+	TakeState(ctx context.Context, state, provider string) (models.OIDCState, error)
+	UserByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	UserByEmail(ctx context.Context, email string) (*models.User, error)
+	LinkIdentity(ctx context.Context, userID primitive.ObjectID, identity models.Identity) error
+	CreateUser(ctx context.Context, user *models.User) error
+}
+
+var oidcUsers oidcStore = mongoOIDCStore{}
+
+type mongoOIDCStore struct{}
+
+func (mongoOIDCStore) SaveState(ctx context.Context, state models.OIDCState) error {
+	_, err := config.DB.Database("bookwarm").Collection("oidc_states").InsertOne(ctx, state)
+	return err
+}
+
+func (mongoOIDCStore) TakeState(ctx context.Context, state, provider string) (models.OIDCState, error) {
+	var saved models.OIDCState
+	err := config.DB.Database("bookwarm").Collection("oidc_states").FindOneAndDelete(ctx, bson.M{
+		"state":      state,
+		"provider":   provider,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&saved)
+	return saved, err
+}
+
+func (mongoOIDCStore) UserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	var user models.User
+	err := config.DB.Database("bookwarm").Collection("users").FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}},
+	}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (mongoOIDCStore) UserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := config.DB.Database("bookwarm").Collection("users").FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (mongoOIDCStore) LinkIdentity(ctx context.Context, userID primitive.ObjectID, identity models.Identity) error {
+	_, err := config.DB.Database("bookwarm").Collection("users").UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$push": bson.M{"identities": identity},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	return err
+}
+
+func (mongoOIDCStore) CreateUser(ctx context.Context, user *models.User) error {
+	res, err := config.DB.Database("bookwarm").Collection("users").InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+	user.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"back/config"
 	"back/models"
+	"back/ratings"
 	"context"
 	"net/http"
 	"time"
@@ -94,13 +95,20 @@ func CreateReview(c *gin.Context) {
 		ReviewDate:   time.Now(),
 	}
 
-	insertResult, err := reviewCollection.InsertOne(context.TODO(), review)
+	// บันทึกรีวิวกับอัปเดตคะแนนรวมของหนังสือพร้อมกัน ไม่ให้ค่าคลาดกันถ้าอย่างใดอย่างหนึ่งล้มเหลว
+	err = config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+		if _, err := reviewCollection.InsertOne(ctx, review); err != nil {
+			return err
+		}
+		return ratings.Apply(ctx, bookID, 0, review.Rating)
+	})
 	if err != nil {
+		log.Printf("Failed to save review: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
 		return
 	}
 
-	createdReviewID := insertResult.InsertedID.(primitive.ObjectID)
+	createdReviewID := review.ID
 	enrichedReview, err := GetReviewByID(createdReviewID)
 	if err != nil {
 		log.Printf("Failed to fetch enriched review: %v", err)
@@ -186,8 +194,19 @@ func GetAllReviews(c *gin.Context) {
 		return
 	}
 
+	// รีวิวรวมทุก edition ของงานเดียวกัน เว้นแต่ขอเฉพาะ edition นี้ด้วย ?edition_only=true
+	bookIDs := []primitive.ObjectID{bookID}
+	if c.Query("edition_only") != "true" {
+		bookIDs, _, err = editionIDs(context.TODO(), bookID)
+		if err != nil {
+			log.Printf("Error loading editions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
 	pipeline := mongo.Pipeline{
-		bson.D{{Key: "$match", Value: bson.M{"book_id": bookID}}},
+		bson.D{{Key: "$match", Value: bson.M{"book_id": bson.M{"$in": bookIDs}}}},
 
 		// Join กับ users collection
 		bson.D{{Key: "$lookup", Value: bson.M{
@@ -227,8 +246,7 @@ func GetAllReviews(c *gin.Context) {
 	defer cursor.Close(context.TODO())
 
 	var reviews []bson.M
-	var totalRating int
-	
+
 	for cursor.Next(context.TODO()) {
 		var review bson.M
 		if err := cursor.Decode(&review); err != nil {
@@ -237,9 +255,6 @@ func GetAllReviews(c *gin.Context) {
 		}
 		log.Printf("Review found: %+v", review)
 		reviews = append(reviews, review)
-		if rating, ok := review["rating"].(int32); ok {
-			totalRating += int(rating)
-		}
 	}
 
 	if err := cursor.Err(); err != nil {
@@ -248,10 +263,12 @@ func GetAllReviews(c *gin.Context) {
 		return
 	}
 
-	// คำนวณค่าเฉลี่ย rating
-	var average float64
-	if len(reviews) > 0 {
-		average = float64(totalRating) / float64(len(reviews))
+	// คะแนนรวมอ่านจากค่าที่เก็บไว้ในหนังสือ (ดู package ratings) ไม่ต้องคำนวณจากรีวิวทุกครั้ง
+	stats, err := ratings.Combined(context.TODO(), bookIDs)
+	if err != nil {
+		log.Printf("Error loading rating stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
 	}
 
 	log.Printf("Successfully fetched %d reviews for book ID: %s", len(reviews), bookIDParam)
@@ -259,9 +276,10 @@ func GetAllReviews(c *gin.Context) {
 	log.Printf("Reviews being sent to frontend (first 5): %+v", reviews[:min(len(reviews), 5)])
 
 	c.JSON(http.StatusOK, gin.H{
-		"reviews":        reviews,
-		"average_rating": average,
-		"total_reviews":  len(reviews),
+		"reviews":          reviews,
+		"average_rating":   stats.Avg,
+		"total_reviews":    len(reviews),
+		"rating_histogram": stats.Histogram,
 	})
 }
 
@@ -308,18 +326,31 @@ func UpdateReview(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
 	update := bson.M{
 		"$set": bson.M{
 			"rating":     input.Rating,
 			"comment":    input.Comment,
-			"updated_at": time.Now(),
+			"updated_at": now,
 		},
 	}
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	if err := collection.FindOneAndUpdate(c, bson.M{"_id": reviewID}, update, opts).Decode(&review); err != nil {
+	// อ่านคะแนนเดิมใน transaction เดียวกับที่อัปเดต เพื่อให้ปรับคะแนนรวมจากค่าที่ถูกต้อง
+	err = config.WithTransaction(c, func(ctx context.Context) error {
+		var before models.Review
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+		if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": reviewID}, update, opts).Decode(&before); err != nil {
+			return err
+		}
+		return ratings.Apply(ctx, before.BookID, before.Rating, input.Rating)
+	})
+	if err != nil {
+		log.Printf("Failed to update review %s: %v", reviewID.Hex(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed"})
 		return
 	}
+	review.Rating = input.Rating
+	review.Comment = input.Comment
+	review.UpdatedAt = now
 	enrichedReview, err := GetReviewByID(reviewID)
 	if err != nil {
 		log.Printf("Failed to fetch enriched review: %v", err)
@@ -364,8 +395,15 @@ func DeleteReview(c *gin.Context) {
 		return
 	}
 
-	_, err = collection.DeleteOne(c, bson.M{"_id": reviewID})
+	err = config.WithTransaction(c, func(ctx context.Context) error {
+		var deleted models.Review
+		if err := collection.FindOneAndDelete(ctx, bson.M{"_id": reviewID}).Decode(&deleted); err != nil {
+			return err
+		}
+		return ratings.Apply(ctx, deleted.BookID, deleted.Rating, 0)
+	})
 	if err != nil {
+		log.Printf("Failed to delete review %s: %v", reviewID.Hex(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete failed"})
 		return
 	}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"back/search"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rebuilding กันไม่ให้ rebuild ดัชนีซ้อนกันหลายรอบ
+var rebuilding sync.Mutex
+
+// RebuildSearchIndex สร้างดัชนีค้นหาและ autocomplete ใหม่จากฐานข้อมูลโดยไม่ต้องหยุด server
+// (แทนการรัน cmd/reindex ซึ่ง server ที่ทำงานอยู่จะไม่เห็นไฟล์ใหม่)
+func RebuildSearchIndex(c *gin.Context) {
+	if search.Default == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Search index is not loaded"})
+		return
+	}
+	if !rebuilding.TryLock() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Search index is already being rebuilt"})
+		return
+	}
+	defer rebuilding.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	start := time.Now()
+	if err := search.Rebuild(ctx, search.Default); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rebuild search index"})
+		return
+	}
+	if err := search.RefreshSuggestions(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh suggestions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"documents": search.Default.Len(),
+		"took_ms":   time.Since(start).Milliseconds(),
+	})
+}
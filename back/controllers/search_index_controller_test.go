@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"back/search"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRebuildSearchIndexGuards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/admin/search/reindex", RebuildSearchIndex)
+	rebuild := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/admin/search/reindex", nil))
+		return rec.Code
+	}
+
+	previous := search.Default
+	t.Cleanup(func() { search.Default = previous })
+
+	search.Default = nil
+	if code := rebuild(); code != http.StatusServiceUnavailable {
+		t.Fatalf("without an index status = %d, want 503", code)
+	}
+
+	search.Default = search.New("")
+	rebuilding.Lock()
+	code := rebuild()
+	rebuilding.Unlock()
+	if code != http.StatusConflict {
+		t.Fatalf("during a rebuild status = %d, want 409", code)
+	}
+}
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"back/config"
+	"back/history"
+	"back/models"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	errSeriesNotFound       = errors.New("series not found")
+	errSeriesNumberInvalid  = errors.New("seriesNumber must be greater than 0")
+	errSeriesNumberNoSeries = errors.New("seriesNumber requires seriesId")
+	errSeriesNumberTaken    = errors.New("another book already has this seriesNumber in the series")
+)
+
+// seriesErrorStatus แปลง error จาก validateSeriesPlacement เป็น HTTP status
+func seriesErrorStatus(err error) int {
+	switch err {
+	case errSeriesNotFound, errSeriesNumberInvalid, errSeriesNumberNoSeries:
+		return http.StatusBadRequest
+	case errSeriesNumberTaken:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// validateSeriesPlacement ตรวจว่าซีรีส์มีอยู่จริง และเลขเล่มไม่ซ้ำกับหนังสือเล่มอื่นในซีรีส์เดียวกัน
+func validateSeriesPlacement(ctx context.Context, bookID primitive.ObjectID, seriesID *primitive.ObjectID, number *float64) error {
+	if seriesID == nil {
+		if number != nil {
+			return errSeriesNumberNoSeries
+		}
+		return nil
+	}
+
+	db := config.DB.Database("bookwarm")
+	count, err := db.Collection("series").CountDocuments(ctx, bson.M{"_id": *seriesID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errSeriesNotFound
+	}
+
+	if number == nil {
+		return nil
+	}
+	if *number <= 0 || math.IsNaN(*number) || math.IsInf(*number, 0) {
+		return errSeriesNumberInvalid
+	}
+	count, err = db.Collection("books").CountDocuments(ctx, bson.M{
+		"_id":          bson.M{"$ne": bookID},
+		"seriesId":     *seriesID,
+		"seriesNumber": *number,
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errSeriesNumberTaken
+	}
+	return nil
+}
+
+// seriesOrderStages เรียงหนังสือตามเลขเล่ม เล่มที่ยังไม่มีเลขอยู่ท้ายสุดเรียงตามปีที่พิมพ์
+func seriesOrderStages() []bson.M {
+	return []bson.M{
+		{"$addFields": bson.M{"seriesOrder": bson.M{"$ifNull": []interface{}{"$seriesNumber", math.MaxInt32}}}},
+		{"$sort": bson.D{{Key: "seriesOrder", Value: 1}, {Key: "publishYear", Value: 1}, {Key: "_id", Value: 1}}},
+		{"$unset": "seriesOrder"},
+	}
+}
+
+type seriesVolume struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id"`
+	Title        string              `json:"title" bson:"title"`
+	CoverImage   string              `json:"coverImage" bson:"coverImage"`
+	SeriesNumber *float64            `json:"seriesNumber,omitempty" bson:"seriesNumber,omitempty"`
+	WorkID       *primitive.ObjectID `json:"-" bson:"workId,omitempty"`
+	MarkStatus   string              `json:"markStatus,omitempty" bson:"-"`
+}
+
+// seriesInfo สร้างข้อมูลซีรีส์ที่แนบไปกับหนังสือ: รายการเล่มตามลำดับ ตำแหน่งของเล่มนี้
+// และเล่มถัดไปที่ควรอ่าน ถ้ารู้ว่าผู้ใช้คือใคร "เล่มถัดไป" คือเล่มแรกที่ยังไม่ได้อ่าน
+// หลังเล่มที่อ่านไปไกลที่สุด ถ้ายังไม่เคยอ่านเล่มไหนหรือไม่ได้ login คือเล่มที่ต่อจากเล่มนี้
+func seriesInfo(ctx context.Context, seriesID, bookID primitive.ObjectID, userID *primitive.ObjectID) (gin.H, error) {
+	db := config.DB.Database("bookwarm")
+
+	var series models.Series
+	if err := db.Collection("series").FindOne(ctx, bson.M{"_id": seriesID}).Decode(&series); err != nil {
+		return nil, err
+	}
+
+	pipeline := append([]bson.M{{"$match": bson.M{"seriesId": seriesID}}}, seriesOrderStages()...)
+	pipeline = append(pipeline, bson.M{"$project": bson.M{"title": 1, "coverImage": 1, "seriesNumber": 1, "workId": 1}})
+	cursor, err := db.Collection("books").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	volumes := []seriesVolume{}
+	if err := cursor.All(ctx, &volumes); err != nil {
+		return nil, err
+	}
+
+	current := -1
+	for i, volume := range volumes {
+		if volume.ID == bookID {
+			current = i
+		}
+	}
+
+	start := current + 1
+	if userID != nil && len(volumes) > 0 {
+		// mark ผูกกับ work ด้วย เล่มที่อ่านใน edition อื่นของงานเดียวกันจึงนับว่าอ่านแล้ว
+		ids := make([]primitive.ObjectID, len(volumes))
+		var workIDs []primitive.ObjectID
+		for i, volume := range volumes {
+			ids[i] = volume.ID
+			if volume.WorkID != nil {
+				workIDs = append(workIDs, *volume.WorkID)
+			}
+		}
+		read := []bson.M{{"book_id": bson.M{"$in": ids}}}
+		if len(workIDs) > 0 {
+			read = append(read, bson.M{"work_id": bson.M{"$in": workIDs}})
+		}
+		markCursor, err := db.Collection("marks").Find(ctx, bson.M{"user_id": *userID, "$or": read})
+		if err != nil {
+			return nil, err
+		}
+		var marks []models.Mark
+		if err := markCursor.All(ctx, &marks); err != nil {
+			return nil, err
+		}
+		status := map[primitive.ObjectID]string{}
+		workStatus := map[primitive.ObjectID]string{}
+		for _, mark := range marks {
+			status[mark.BookID] = mark.Status
+			if mark.WorkID != nil {
+				workStatus[*mark.WorkID] = mark.Status
+			}
+		}
+
+		furthestRead := -1
+		for i := range volumes {
+			volumes[i].MarkStatus = status[volumes[i].ID]
+			if volumes[i].MarkStatus == "" && volumes[i].WorkID != nil {
+				volumes[i].MarkStatus = workStatus[*volumes[i].WorkID]
+			}
+			if volumes[i].MarkStatus == "read" {
+				furthestRead = i
+			}
+		}
+		if furthestRead >= 0 {
+			start = furthestRead + 1
+		}
+	}
+
+	var next *seriesVolume
+	for i := start; i >= 0 && i < len(volumes); i++ {
+		if volumes[i].MarkStatus != "read" {
+			next = &volumes[i]
+			break
+		}
+	}
+
+	info := gin.H{
+		"id":          series.ID,
+		"name":        series.Name,
+		"description": series.Description,
+		"volumes":     volumes,
+		"total":       len(volumes),
+		"next":        next,
+	}
+	if current >= 0 {
+		info["position"] = current + 1
+	}
+	return info, nil
+}
+
+// contextUserID คืน id ของผู้ใช้ที่ login อยู่ หรือ nil ถ้าไม่มี (ใช้กับ route ที่ login หรือไม่ก็ได้)
+func contextUserID(c *gin.Context) *primitive.ObjectID {
+	raw, exists := c.Get("userId")
+	if !exists {
+		return nil
+	}
+	id, err := primitive.ObjectIDFromHex(raw.(string))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+func CreateSeries(c *gin.Context) {
+	var input models.Series
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.ID = primitive.NewObjectID()
+	input.CreatedAt = time.Now()
+	input.UpdatedAt = time.Now()
+
+	collection := config.DB.Database("bookwarm").Collection("series")
+	if _, err := collection.InsertOne(context.TODO(), input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, input)
+}
+
+func GetAllSeries(c *gin.Context) {
+	collection := config.DB.Database("bookwarm").Collection("series")
+	pipeline := []bson.M{
+		{"$lookup": bson.M{
+			"from":         "books",
+			"localField":   "_id",
+			"foreignField": "seriesId",
+			"as":           "books",
+		}},
+		{"$addFields": bson.M{"bookCount": bson.M{"$size": "$books"}}},
+		{"$unset": "books"},
+		{"$sort": bson.M{"name": 1}},
+	}
+
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	series := []bson.M{}
+	if err := cursor.All(context.TODO(), &series); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetSeriesByID คืนข้อมูลซีรีส์พร้อมหนังสือทุกเล่มเรียงตามเลขเล่ม
+func GetSeriesByID(c *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	db := config.DB.Database("bookwarm")
+	var series models.Series
+	if err := db.Collection("series").FindOne(context.TODO(), bson.M{"_id": seriesID}).Decode(&series); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return
+	}
+
+	pipeline := append([]bson.M{{"$match": bson.M{"seriesId": seriesID}}}, seriesOrderStages()...)
+	pipeline = append(pipeline, bookLookupStages()...)
+	cursor, err := db.Collection("books").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series books"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	books := []bson.M{}
+	if err := cursor.All(context.TODO(), &books); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode series books"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series, "books": books})
+}
+
+func UpdateSeries(c *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var input models.Series
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := config.DB.Database("bookwarm").Collection("series")
+	update := bson.M{"$set": bson.M{
+		"name":        input.Name,
+		"description": input.Description,
+		"updatedAt":   time.Now(),
+	}}
+	var updated models.Series
+	err = collection.FindOneAndUpdate(context.TODO(), bson.M{"_id": seriesID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteSeries ลบซีรีส์ หนังสือในซีรีส์ยังอยู่แต่จะไม่ผูกกับซีรีส์ใดอีก
+func DeleteSeries(c *gin.Context) {
+	seriesID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	err = history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			result, err := config.DB.Database("bookwarm").Collection("series").DeleteOne(ctx, bson.M{"_id": seriesID})
+			if err != nil {
+				return err
+			}
+			if result.DeletedCount == 0 {
+				return errSeriesNotFound
+			}
+			_, err = updateBooks(ctx,
+				bson.M{"seriesId": seriesID},
+				bson.M{"$unset": bson.M{"seriesId": "", "seriesNumber": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+				history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)})
+			return err
+		})
+	})
+	if err == errSeriesNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series deleted successfully"})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"back/config"
+	"back/i18n"
 	"back/models"
 	"context"
 	"net/http"
@@ -19,10 +20,17 @@ func CreateTag(c *gin.Context){
 		return
 	}
 
+	name, names, err := i18n.Fold(input.Name, input.Names)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Name, input.Names = name, names
+
 	input.ID = primitive.NewObjectID()
 	collection := config.DB.Database("bookwarm").Collection("tag")
 
-	_, err := collection.InsertOne(context.TODO(), input)
+	_, err = collection.InsertOne(context.TODO(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,6 +50,7 @@ func GetAllTag(c *gin.Context){
 
 	defer cursor.Close(context.TODO())
 
+	locale := contextLocale(c)
 	var tags []models.Tag
 	for cursor.Next(context.TODO()){
 		var tag models.Tag
@@ -49,6 +58,7 @@ func GetAllTag(c *gin.Context){
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		tag.Name = i18n.Pick(tag.Names, tag.Name, locale)
 		tags = append(tags, tag)
 	}
 	c.JSON(http.StatusOK, tags)
@@ -69,8 +79,17 @@ func UpdateTag(c *gin.Context){
 		return
 	}
 
+	set := bson.M{"update_at": time.Now()}
+	unset := bson.M{}
+	if err := namesUpdate(input.Name, input.Names, set, unset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	collection := config.DB.Database("bookwarm").Collection("tag")
-	update := bson.M{"$set":bson.M{"name": input.Name, "update_at": time.Now()}}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": objectID},update)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"back/config"
+	"back/i18n"
+	"back/taxonomy"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	errParentNotFound   = errors.New("parent not found")
+	errParentCycle      = errors.New("cannot move under itself or its descendants")
+	errDuplicateSibling = errors.New("another entry at this level already has this name")
+)
+
+// validateParent ตรวจว่า parent มีอยู่ และการให้ id อยู่ใต้ parent ไม่ทำให้เกิดวงวน
+// id เป็น NilObjectID ตอนสร้างใหม่
+func validateParent(ctx context.Context, collection string, id primitive.ObjectID, parentID *primitive.ObjectID) error {
+	if parentID == nil {
+		return nil
+	}
+	tree, err := taxonomy.Load(ctx, collection)
+	if err != nil {
+		return err
+	}
+	if tree.Node(*parentID) == nil {
+		return errParentNotFound
+	}
+	if !id.IsZero() && tree.IsDescendant(*parentID, id) {
+		return errParentCycle
+	}
+	return nil
+}
+
+// validateSiblingName กันชื่อที่ได้ slug ซ้ำกับพี่น้องในระดับ parentID ซึ่งจะทำให้ slug path ไม่คงที่
+// id เป็น NilObjectID ตอนสร้างใหม่
+func validateSiblingName(ctx context.Context, collection string, id primitive.ObjectID, parentID *primitive.ObjectID, name string) error {
+	tree, err := taxonomy.Load(ctx, collection)
+	if err != nil {
+		return err
+	}
+	if tree.Sibling(parentID, name, id) != nil {
+		return errDuplicateSibling
+	}
+	return nil
+}
+
+// validateUpdatedName ตรวจชื่อซ้ำกับพี่น้องหลังใช้ $set/$unset จาก namesUpdate และ parentUpdate
+// ไม่ได้ย้ายคือยังอยู่ใต้ parent เดิม
+func validateUpdatedName(ctx context.Context, collection string, id primitive.ObjectID, set, unset bson.M) error {
+	tree, err := taxonomy.Load(ctx, collection)
+	if err != nil {
+		return err
+	}
+	node := tree.Node(id)
+	if node == nil {
+		return nil
+	}
+	parentID := node.ParentID
+	if moved, ok := set["parent_id"].(primitive.ObjectID); ok {
+		parentID = &moved
+	} else if _, ok := unset["parent_id"]; ok {
+		parentID = nil
+	}
+	name, _ := set["name"].(string)
+	if tree.Sibling(parentID, name, id) != nil {
+		return errDuplicateSibling
+	}
+	return nil
+}
+
+func parentErrorStatus(err error) int {
+	switch err {
+	case errParentNotFound, errParentCycle:
+		return http.StatusBadRequest
+	case errDuplicateSibling:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// parentUpdate แปลง parentId ที่ส่งมาตอนแก้ไขเป็น $set/$unset
+// ไม่ส่ง parentId คือไม่ย้าย (client รุ่นเก่าส่งแค่ชื่อ) ส่ง null คือย้ายไประดับบนสุด
+func parentUpdate(ctx context.Context, collection string, id primitive.ObjectID, raw json.RawMessage, set, unset bson.M) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		unset["parent_id"] = ""
+		return nil
+	}
+	var parentID primitive.ObjectID
+	if err := json.Unmarshal(raw, &parentID); err != nil {
+		return errParentNotFound
+	}
+	if err := validateParent(ctx, collection, id, &parentID); err != nil {
+		return err
+	}
+	set["parent_id"] = parentID
+	return nil
+}
+
+// namesUpdate แปลง name/names ที่ส่งมาตอนแก้ไขเป็น $set/$unset ชื่อภาษาหลักใน names ใช้แทน name (ดู i18n.Fold)
+// ไม่ส่ง names คือคงชื่อภาษาอื่นไว้ ส่ง {} คือลบทั้งหมด
+func namesUpdate(name string, names map[string]string, set, unset bson.M) error {
+	name, folded, err := i18n.Fold(name, names)
+	if err != nil {
+		return err
+	}
+	set["name"] = name
+	switch {
+	case names == nil:
+	case folded == nil:
+		unset["names"] = ""
+	default:
+		set["names"] = folded
+	}
+	return nil
+}
+
+// hasChildren บอกว่ามีหมวด/ประเภทย่อยอยู่ใต้ id หรือไม่
+func hasChildren(ctx context.Context, collection string, id primitive.ObjectID) (bool, error) {
+	count, err := config.DB.Database("bookwarm").Collection(collection).CountDocuments(ctx, bson.M{"parent_id": id})
+	return count > 0, err
+}
+
+// expandTaxonomy คืน ids พร้อมลูกหลานทั้งหมด ใช้กรองหนังสือ เช่น กรอง "นิยาย" ได้ "แฟนตาซี" ด้วย
+func expandTaxonomy(ctx context.Context, collection string, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	tree, err := taxonomy.Load(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Descendants(ids), nil
+}
+
+func getTaxonomyTree(c *gin.Context, collection string) {
+	tree, err := taxonomy.Load(context.TODO(), collection)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch " + collection + " tree"})
+		return
+	}
+	tree.Localize(contextLocale(c))
+	roots := tree.Roots
+	if roots == nil {
+		roots = []*taxonomy.Node{}
+	}
+	c.JSON(http.StatusOK, roots)
+}
+
+func getTaxonomyByPath(c *gin.Context, collection string) {
+	tree, err := taxonomy.Load(context.TODO(), collection)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch " + collection})
+		return
+	}
+	tree.Localize(contextLocale(c))
+	node := tree.ByPath(c.Param("path"))
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": collection + " not found"})
+		return
+	}
+	ancestors := tree.Ancestors(node.ID)
+	breadcrumbs := make([]gin.H, 0, len(ancestors))
+	for _, ancestor := range ancestors {
+		breadcrumbs = append(breadcrumbs, gin.H{"id": ancestor.ID, "name": ancestor.Name, "path": ancestor.Path})
+	}
+	c.JSON(http.StatusOK, gin.H{"node": node, "ancestors": breadcrumbs})
+}
+
+// GetCategoryTree คืนหมวดทั้งหมดเป็นต้นไม้ พร้อม slug และ path ของแต่ละหมวด
+//
+//	GET /api/categories/tree
+func GetCategoryTree(c *gin.Context) { getTaxonomyTree(c, "category") }
+
+// GetCategoryByPath คืนหมวดตาม slug path พร้อมหมวดย่อยและหมวดแม่ไล่ขึ้นไป
+//
+//	GET /api/categories/path/fiction/fantasy
+func GetCategoryByPath(c *gin.Context) { getTaxonomyByPath(c, "category") }
+
+// GetGenreTree คืนประเภททั้งหมดเป็นต้นไม้
+//
+//	GET /api/genres/tree
+func GetGenreTree(c *gin.Context) { getTaxonomyTree(c, "genre") }
+
+// GetGenreByPath คืนประเภทตาม slug path
+//
+//	GET /api/genres/path/fantasy/epic-fantasy
+func GetGenreByPath(c *gin.Context) { getTaxonomyByPath(c, "genre") }
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"back/config"
+	"back/trending"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultTrendingLimit = 10
+
+// trendingSnapshot อ่าน window และ limit จาก query แล้วคืนอันดับล่าสุดของช่วงเวลานั้น
+// ถ้าผิดพลาดจะตอบ error ไปแล้วและคืน ok เป็น false
+func trendingSnapshot(c *gin.Context) (snapshot *trending.Snapshot, window string, limit int, ok bool) {
+	window = c.DefaultQuery("window", trending.DefaultWindow)
+	if !trending.ValidWindow(window) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be one of 24h, 7d, 30d"})
+		return nil, "", 0, false
+	}
+	limit = defaultTrendingLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxRecommendLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+			return nil, "", 0, false
+		}
+		limit = n
+	}
+	snapshot = trending.Current()
+	if snapshot == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Trending rankings are not ready yet, please try again shortly"})
+		return nil, "", 0, false
+	}
+	return snapshot, window, limit, true
+}
+
+func trendStats(entry trending.Entry) gin.H {
+	return gin.H{
+		"recent":   entry.Recent,
+		"previous": entry.Previous,
+		"velocity": math.Round(entry.Velocity*100) / 100,
+		"score":    math.Round(entry.Score*100) / 100,
+	}
+}
+
+// GetTrendingBooks คืนหนังสือที่มาแรงจาก mark, รีวิว และโพสต์ที่อ้างถึงหนังสือในช่วงเวลาล่าสุด
+// อันดับคำนวณใหม่เบื้องหลังทุก TRENDING_REFRESH (ดู package trending)
+//
+//	GET /api/books/trending?window=24h|7d|30d&limit=10
+func GetTrendingBooks(c *gin.Context) {
+	snapshot, window, limit, ok := trendingSnapshot(c)
+	if !ok {
+		return
+	}
+	entries := snapshot.Books[window]
+
+	bookIDs := make([]primitive.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		bookIDs = append(bookIDs, entry.ID)
+	}
+	byID, err := recommendedBookDocs(c.Request.Context(), bookIDs, contextLocale(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending books"})
+		return
+	}
+	// หนังสือที่ถูกลบหลังคำนวณอันดับถูกข้ามไป จึงเลือกจนได้ครบ limit
+	books := []bson.M{}
+	for _, entry := range entries {
+		if len(books) >= limit {
+			break
+		}
+		book, ok := byID[entry.ID]
+		if !ok {
+			continue
+		}
+		book["trend"] = trendStats(entry)
+		books = append(books, book)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books":       books,
+		"window":      window,
+		"computed_at": snapshot.ComputedAt,
+	})
+}
+
+// GetTrendingClubs คืนคลับที่มาแรงจากโพสต์และสมาชิกที่เข้าร่วมใหม่ในช่วงเวลาล่าสุด
+//
+//	GET /api/club/trending?window=24h|7d|30d&limit=10
+func GetTrendingClubs(c *gin.Context) {
+	snapshot, window, limit, ok := trendingSnapshot(c)
+	if !ok {
+		return
+	}
+	entries := snapshot.Clubs[window]
+	ctx := c.Request.Context()
+
+	clubIDs := make([]primitive.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		clubIDs = append(clubIDs, entry.ID)
+	}
+	cursor, err := config.DB.Database("bookwarm").Collection("clubs").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"_id": bson.M{"$in": clubIDs}}},
+		{"$project": bson.M{
+			"name":         1,
+			"description":  1,
+			"cover_image":  1,
+			"owner_id":     1,
+			"member_count": bson.M{"$size": bson.M{"$ifNull": []interface{}{"$members", []interface{}{}}}},
+			"created_at":   1,
+		}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending clubs"})
+		return
+	}
+	var found []bson.M
+	if err := cursor.All(ctx, &found); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding trending clubs"})
+		return
+	}
+	byID := map[primitive.ObjectID]bson.M{}
+	for _, club := range found {
+		if oid, ok := club["_id"].(primitive.ObjectID); ok {
+			club["_id"] = oid.Hex()
+			byID[oid] = club
+		}
+	}
+
+	clubs := []bson.M{}
+	for _, entry := range entries {
+		if len(clubs) >= limit {
+			break
+		}
+		club, ok := byID[entry.ID]
+		if !ok {
+			continue
+		}
+		club["trend"] = trendStats(entry)
+		clubs = append(clubs, club)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"clubs":       clubs,
+		"window":      window,
+		"computed_at": snapshot.ComputedAt,
+	})
+}
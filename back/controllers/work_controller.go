@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"back/catalog"
+	"back/config"
+	"back/history"
+	"back/models"
+	"back/ratings"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errWorkNotFound = errors.New("work not found")
+
+// validateEdition ตรวจว่า work ที่อ้างถึงมีอยู่จริงและ format เป็นค่าที่รองรับ
+func validateEdition(ctx context.Context, book *models.Book) error {
+	if book.Format != "" && !models.IsEditionFormat(book.Format) {
+		return errors.New("format must be one of " + strings.Join(models.EditionFormats, ", "))
+	}
+	if book.WorkID == nil {
+		return nil
+	}
+	count, err := config.DB.Database("bookwarm").Collection("works").CountDocuments(ctx, bson.M{"_id": *book.WorkID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errWorkNotFound
+	}
+	return nil
+}
+
+// editionIDs คืน id ของทุก edition ในงานเดียวกับหนังสือเล่มนี้ (รวมตัวเอง)
+// หนังสือที่ไม่ได้อยู่ใน work ใดถือเป็น work ที่มี edition เดียว
+func editionIDs(ctx context.Context, bookID primitive.ObjectID) ([]primitive.ObjectID, *primitive.ObjectID, error) {
+	collection := config.DB.Database("bookwarm").Collection("books")
+	var book models.Book
+	err := collection.FindOne(ctx, bson.M{"_id": bookID}, options.FindOne().SetProjection(bson.M{"workId": 1})).Decode(&book)
+	if err != nil {
+		return nil, nil, err
+	}
+	if book.WorkID == nil {
+		return []primitive.ObjectID{bookID}, nil, nil
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"workId": *book.WorkID}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, nil, err
+	}
+	var editions []models.Book
+	if err := cursor.All(ctx, &editions); err != nil {
+		return nil, nil, err
+	}
+	ids := make([]primitive.ObjectID, len(editions))
+	for i, edition := range editions {
+		ids[i] = edition.ID
+	}
+	return ids, book.WorkID, nil
+}
+
+// workRating คืนคะแนนรวมของทุก edition ใน work
+func workRating(ctx context.Context, workID primitive.ObjectID) (ratings.Stats, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection("books").Find(ctx, bson.M{"workId": workID}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return ratings.Stats{}, err
+	}
+	var editions []models.Book
+	if err := cursor.All(ctx, &editions); err != nil {
+		return ratings.Stats{}, err
+	}
+	ids := make([]primitive.ObjectID, len(editions))
+	for i, edition := range editions {
+		ids[i] = edition.ID
+	}
+	return ratings.Combined(ctx, ids)
+}
+
+// workSummary คือข้อมูล work ที่แนบไปกับหนังสือ: edition อื่น ๆ และคะแนนรวมของทุก edition
+func workSummary(ctx context.Context, workID primitive.ObjectID) (gin.H, error) {
+	db := config.DB.Database("bookwarm")
+	var work models.Work
+	if err := db.Collection("works").FindOne(ctx, bson.M{"_id": workID}).Decode(&work); err != nil {
+		return nil, err
+	}
+
+	projection := bson.M{"title": 1, "language": 1, "format": 1, "publisher": 1, "isbn13": 1, "coverImage": 1, "publishYear": 1}
+	cursor, err := db.Collection("books").Find(ctx, bson.M{"workId": workID},
+		options.Find().SetProjection(projection).SetSort(bson.D{{Key: "publishYear", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	editions := []bson.M{}
+	if err := cursor.All(ctx, &editions); err != nil {
+		return nil, err
+	}
+
+	stats, err := workRating(ctx, workID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"id":               work.ID,
+		"title":            work.Title,
+		"originalLanguage": work.OriginalLanguage,
+		"editions":         editions,
+		"avg_rating":       stats.Avg,
+		"review_count":     stats.Count,
+		"rating_histogram": stats.Histogram,
+	}, nil
+}
+
+func CreateWork(c *gin.Context) {
+	var input struct {
+		models.Work
+		EditionIDs []primitive.ObjectID `json:"editionIds"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	work := input.Work
+	work.ID = primitive.NewObjectID()
+	work.CreatedAt = time.Now()
+	work.UpdatedAt = time.Now()
+
+	// รวมหนังสือที่มีอยู่แล้วเป็น edition ของ work นี้ได้ตั้งแต่ตอนสร้าง
+	err := history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			if _, err := config.DB.Database("bookwarm").Collection("works").InsertOne(ctx, work); err != nil {
+				return err
+			}
+			if len(input.EditionIDs) == 0 {
+				return nil
+			}
+			ids, err := updateBooks(ctx,
+				bson.M{"_id": bson.M{"$in": input.EditionIDs}},
+				bson.M{"$set": bson.M{"workId": work.ID, "updatedAt": time.Now()}},
+				history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)})
+			if err != nil {
+				return err
+			}
+			return catalog.SyncMarkWork(ctx, ids, &work.ID)
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create work"})
+		return
+	}
+
+	c.JSON(http.StatusOK, work)
+}
+
+func GetAllWorks(c *gin.Context) {
+	pipeline := []bson.M{
+		{"$lookup": bson.M{
+			"from":         "books",
+			"localField":   "_id",
+			"foreignField": "workId",
+			"as":           "editions",
+		}},
+		{"$addFields": bson.M{"editionCount": bson.M{"$size": "$editions"}}},
+		{"$unset": "editions"},
+		{"$sort": bson.M{"title": 1}},
+	}
+	cursor, err := config.DB.Database("bookwarm").Collection("works").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch works"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	works := []bson.M{}
+	if err := cursor.All(context.TODO(), &works); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode works"})
+		return
+	}
+	c.JSON(http.StatusOK, works)
+}
+
+// GetWorkByID คืน work พร้อมทุก edition และคะแนนรีวิวรวม
+func GetWorkByID(c *gin.Context) {
+	workID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work ID"})
+		return
+	}
+
+	db := config.DB.Database("bookwarm")
+	var work models.Work
+	if err := db.Collection("works").FindOne(context.TODO(), bson.M{"_id": workID}).Decode(&work); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Work not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch work"})
+		return
+	}
+
+	pipeline := append([]bson.M{
+		{"$match": bson.M{"workId": workID}},
+		{"$sort": bson.D{{Key: "publishYear", Value: 1}, {Key: "_id", Value: 1}}},
+	}, bookLookupStages()...)
+	cursor, err := db.Collection("books").Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch editions"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+	editions := []bson.M{}
+	if err := cursor.All(context.TODO(), &editions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode editions"})
+		return
+	}
+
+	stats, err := workRating(context.TODO(), workID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate ratings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"work":             work,
+		"editions":         editions,
+		"avg_rating":       stats.Avg,
+		"review_count":     stats.Count,
+		"rating_histogram": stats.Histogram,
+	})
+}
+
+func UpdateWork(c *gin.Context) {
+	workID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work ID"})
+		return
+	}
+
+	var input models.Work
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := bson.M{"$set": bson.M{
+		"title":            input.Title,
+		"originalLanguage": input.OriginalLanguage,
+		"description":      input.Description,
+		"updatedAt":        time.Now(),
+	}}
+	var updated models.Work
+	err = config.DB.Database("bookwarm").Collection("works").FindOneAndUpdate(context.TODO(), bson.M{"_id": workID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update work"})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteWork ลบ work แต่ไม่ลบหนังสือ edition ต่าง ๆ กลับไปเป็นหนังสือเดี่ยว
+func DeleteWork(c *gin.Context) {
+	workID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work ID"})
+		return
+	}
+
+	db := config.DB.Database("bookwarm")
+	err = history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			result, err := db.Collection("works").DeleteOne(ctx, bson.M{"_id": workID})
+			if err != nil {
+				return err
+			}
+			if result.DeletedCount == 0 {
+				return errWorkNotFound
+			}
+			if _, err := updateBooks(ctx,
+				bson.M{"workId": workID},
+				bson.M{"$unset": bson.M{"workId": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+				history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)}); err != nil {
+				return err
+			}
+			_, err = db.Collection("marks").UpdateMany(ctx, bson.M{"work_id": workID}, bson.M{"$unset": bson.M{"work_id": ""}})
+			return err
+		})
+	})
+	if err == errWorkNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete work"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Work deleted successfully"})
+}
+
+// AddEdition ผูกหนังสือที่มีอยู่เข้ากับ work
+//
+//	POST /api/works/:id/editions {"bookId": "..."}
+func AddEdition(c *gin.Context) {
+	workID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work ID"})
+		return
+	}
+	var input struct {
+		BookID primitive.ObjectID `json:"bookId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	book := models.Book{WorkID: &workID}
+	if err := validateEdition(context.TODO(), &book); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work not found"})
+		return
+	}
+
+	err = history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			ids, err := updateBooks(ctx,
+				bson.M{"_id": input.BookID},
+				bson.M{"$set": bson.M{"workId": workID, "updatedAt": time.Now()}},
+				history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)})
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return mongo.ErrNoDocuments
+			}
+			return catalog.SyncMarkWork(ctx, ids, &workID)
+		})
+	})
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to attach edition"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Edition added successfully"})
+}
+
+// RemoveEdition แยกหนังสือออกจาก work โดยไม่ลบหนังสือ
+func RemoveEdition(c *gin.Context) {
+	workID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid work ID"})
+		return
+	}
+	bookID, err := primitive.ObjectIDFromHex(c.Param("bookId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	err = history.Retry(func() error {
+		return config.WithTransaction(context.TODO(), func(ctx context.Context) error {
+			ids, err := updateBooks(ctx,
+				bson.M{"_id": bookID, "workId": workID},
+				bson.M{"$unset": bson.M{"workId": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+				history.Entry{Action: models.RevisionUpdate, Editor: contextEditor(c)})
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return mongo.ErrNoDocuments
+			}
+			return catalog.SyncMarkWork(ctx, ids, nil)
+		})
+	})
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Edition not found in this work"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove edition"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Edition removed successfully"})
+}
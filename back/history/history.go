@@ -0,0 +1,197 @@
+// Package history เก็บประวัติการแก้ไขหนังสือ ทุกครั้งที่หนังสือถูกสร้างหรือแก้
+// ต้องเรียก Record ใน transaction เดียวกับการเขียน เพื่อบันทึก revision พร้อมผู้แก้และฟิลด์ที่เปลี่ยน
+package history
+
+import (
+	"back/config"
+	"back/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EditableFields คือฟิลด์ของหนังสือที่ผู้ใช้แก้ได้ ชื่อเดียวกันทั้ง JSON และ BSON
+// คะแนน, coverSizes และเวลาถูกคำนวณเองจึงไม่อยู่ในประวัติ
+var EditableFields = []string{
+	"title", "description", "titles", "descriptions", "authorId", "contributors", "seriesId", "seriesNumber",
+	"isbn13", "isbn10", "workId", "language", "format", "publisher",
+	"category_id", "genres", "tagIds", "publishYear", "pageCount", "coverImage",
+}
+
+// Editor คือผู้ที่แก้หนังสือ ID เป็น nil สำหรับงานของระบบ (เช่น import จาก command line)
+type Editor struct {
+	ID   *primitive.ObjectID
+	Name string
+}
+
+// Entry อธิบายการแก้ไขที่กำลังบันทึก
+type Entry struct {
+	Action       string // models.RevisionCreate, RevisionUpdate, ...
+	Editor       Editor
+	RevertedFrom int
+}
+
+func collection() *mongo.Collection {
+	return config.DB.Database("bookwarm").Collection("book_revisions")
+}
+
+// Snapshot คืนค่าของ EditableFields ของหนังสือในรูป JSON เดียวกับที่ API ตอบ
+func Snapshot(book models.Book) (map[string]interface{}, error) {
+	data, err := json.Marshal(book)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	snapshot := map[string]interface{}{}
+	for _, field := range EditableFields {
+		if value, ok := all[field]; ok {
+			snapshot[field] = value
+		}
+	}
+	return snapshot, nil
+}
+
+// Diff คืนฟิลด์ที่ค่าต่างกันระหว่าง snapshot สองชุด เรียงตาม EditableFields
+func Diff(before, after map[string]interface{}) []models.FieldChange {
+	changes := []models.FieldChange{}
+	for _, field := range EditableFields {
+		oldJSON, _ := json.Marshal(before[field])
+		newJSON, _ := json.Marshal(after[field])
+		if !bytes.Equal(oldJSON, newJSON) {
+			changes = append(changes, models.FieldChange{Field: field, Old: before[field], New: after[field]})
+		}
+	}
+	return changes
+}
+
+// Record บันทึก revision ของ after โดยเทียบกับ before (nil สำหรับหนังสือใหม่)
+// ถ้าไม่มีฟิลด์ใดเปลี่ยนจะไม่บันทึกและคืน nil
+// หนังสือที่มีอยู่ก่อนเริ่มเก็บประวัติจะได้ revision "baseline" ของ before ก่อน เพื่อให้ย้อนกลับได้
+func Record(ctx context.Context, before *models.Book, after models.Book, entry Entry) (*models.BookRevision, error) {
+	afterSnapshot, err := Snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+	beforeSnapshot := map[string]interface{}{}
+	if before != nil {
+		if beforeSnapshot, err = Snapshot(*before); err != nil {
+			return nil, err
+		}
+	}
+	changes := Diff(beforeSnapshot, afterSnapshot)
+	if len(changes) == 0 && before != nil {
+		return nil, nil
+	}
+
+	// นอก transaction ลองใหม่ที่นี่ได้เลย ใน transaction ที่เจอ error แล้วต้องให้ผู้เรียกเริ่มใหม่ทั้งก้อน (ดู Retry)
+	attempts := 1
+	if mongo.SessionFromContext(ctx) == nil {
+		attempts = maxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		revision, err := insertRevision(ctx, before, beforeSnapshot, after, afterSnapshot, changes, entry)
+		if err == nil || attempt >= attempts || !IsRevisionConflict(err) {
+			return revision, err
+		}
+	}
+}
+
+// insertRevision บันทึก revision ถัดจากเลขล่าสุดของหนังสือ (และ baseline ถ้ายังไม่มี revision เลย)
+func insertRevision(ctx context.Context, before *models.Book, beforeSnapshot map[string]interface{}, after models.Book, afterSnapshot map[string]interface{}, changes []models.FieldChange, entry Entry) (*models.BookRevision, error) {
+	var last models.BookRevision
+	err := collection().FindOne(ctx, bson.M{"book_id": after.ID},
+		options.FindOne().SetSort(bson.D{{Key: "number", Value: -1}}).SetProjection(bson.M{"number": 1})).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == mongo.ErrNoDocuments && before != nil {
+		baseline := models.BookRevision{
+			BookID:    after.ID,
+			Number:    1,
+			Action:    models.RevisionBaseline,
+			Changes:   []models.FieldChange{},
+			Snapshot:  beforeSnapshot,
+			CreatedAt: before.UpdatedAt,
+		}
+		if _, err := collection().InsertOne(ctx, baseline); err != nil {
+			return nil, err
+		}
+		last.Number = 1
+	}
+
+	revision := models.BookRevision{
+		ID:           primitive.NewObjectID(),
+		BookID:       after.ID,
+		Number:       last.Number + 1,
+		Action:       entry.Action,
+		EditorID:     entry.Editor.ID,
+		EditorName:   entry.Editor.Name,
+		RevertedFrom: entry.RevertedFrom,
+		Changes:      changes,
+		Snapshot:     afterSnapshot,
+		CreatedAt:    time.Now(),
+	}
+	// unique index (book_id, number) กันการแก้พร้อมกันสองครั้งได้เลขเดียวกัน
+	if _, err := collection().InsertOne(ctx, revision); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// maxAttempts คือจำนวนครั้งที่ลองบันทึกใหม่เมื่อเลข revision ชนกัน
+const maxAttempts = 3
+
+// IsRevisionConflict บอกว่า err เกิดจากเลข revision ชนกับการแก้หนังสือเล่มเดียวกันที่เกิดพร้อมกัน
+func IsRevisionConflict(err error) bool {
+	return config.IsDuplicateKey(err, config.IndexRevisionNumber)
+}
+
+// Retry เรียก fn ใหม่เมื่อ Record ใน fn ชนเลข revision กับการแก้ที่เกิดพร้อมกัน
+// fn ควรเป็น config.WithTransaction ทั้งก้อน เพราะ transaction ที่เจอ error ถูกยกเลิกไปแล้ว
+func Retry(fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err = fn(); !IsRevisionConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// List คืน revision ของหนังสือ ใหม่สุดก่อน ไม่รวม snapshot
+func List(ctx context.Context, bookID primitive.ObjectID, skip, limit int64) ([]models.BookRevision, int64, error) {
+	filter := bson.M{"book_id": bookID}
+	total, err := collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	cursor, err := collection().Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "number", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(limit).
+		SetProjection(bson.M{"snapshot": 0}))
+	if err != nil {
+		return nil, 0, err
+	}
+	revisions := []models.BookRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
+
+// Get คืน revision หมายเลข number ของหนังสือ
+func Get(ctx context.Context, bookID primitive.ObjectID, number int) (models.BookRevision, error) {
+	var revision models.BookRevision
+	err := collection().FindOne(ctx, bson.M{"book_id": bookID, "number": number}).Decode(&revision)
+	return revision, err
+}
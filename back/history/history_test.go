@@ -0,0 +1,110 @@
+package history
+
+import (
+	"back/config"
+	"back/models"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errRevisionConflict = mongo.WriteException{WriteErrors: []mongo.WriteError{{
+	Code:    11000,
+	Message: "E11000 duplicate key error collection: bookwarm.book_revisions index: " + config.IndexRevisionNumber + " dup key: { : 1 }",
+}}}
+
+func TestRetry(t *testing.T) {
+	errOther := errors.New("boom")
+	tests := []struct {
+		name      string
+		results   []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"conflict then success", []error{errRevisionConflict, nil}, 2, nil},
+		{"other errors are not retried", []error{errOther}, 1, errOther},
+		{"gives up after maxAttempts", []error{errRevisionConflict, errRevisionConflict, errRevisionConflict, nil}, maxAttempts, errRevisionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(func() error {
+				calls++
+				return tt.results[calls-1]
+			})
+			if calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) && !(IsRevisionConflict(err) && IsRevisionConflict(tt.wantErr)) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSnapshotKeepsOnlyEditableFields(t *testing.T) {
+	series := primitive.NewObjectID()
+	book := models.Book{
+		ID:          primitive.NewObjectID(),
+		Title:       "The Two Towers",
+		Titles:      map[string]string{"th": "หอคอยคู่"},
+		SeriesID:    &series,
+		PageCount:   352,
+		CoverSizes:  []models.ImageVariant{{Width: 600}},
+		AvgRating:   4.5,
+		ReviewCount: 10,
+		UpdatedAt:   time.Now(),
+	}
+
+	snapshot, err := Snapshot(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ค่าเป็นรูปเดียวกับ JSON ของ API: id เป็น hex และตัวเลขเป็น float64
+	if snapshot["title"] != "The Two Towers" || snapshot["seriesId"] != series.Hex() || snapshot["pageCount"] != float64(352) {
+		t.Errorf("snapshot = %#v", snapshot)
+	}
+	if titles, _ := snapshot["titles"].(map[string]interface{}); titles["th"] != "หอคอยคู่" {
+		t.Errorf("titles = %#v", snapshot["titles"])
+	}
+	for _, field := range []string{"id", "coverSizes", "avg_rating", "review_count", "updatedAt", "isbn13"} {
+		if value, ok := snapshot[field]; ok {
+			t.Errorf("snapshot[%q] = %#v, want it left out", field, value)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{
+		"title":       "Dune",
+		"description": "Arrakis.",
+		"titles":      map[string]interface{}{"th": "ดูน", "ja": "デューン"},
+		"isbn13":      "9780441013593",
+		"pageCount":   float64(412),
+	}
+	after := map[string]interface{}{
+		"title":       "Dune Messiah",
+		"description": "Arrakis.",
+		"titles":      map[string]interface{}{"ja": "デューン", "th": "ดูน"},
+		"pageCount":   float64(256),
+		"publisher":   "Ace",
+	}
+	// เรียงตาม EditableFields ไม่ใช่ตามลำดับใน map
+	want := []models.FieldChange{
+		{Field: "title", Old: "Dune", New: "Dune Messiah"},
+		{Field: "isbn13", Old: "9780441013593", New: nil},
+		{Field: "publisher", Old: nil, New: "Ace"},
+		{Field: "pageCount", Old: float64(412), New: float64(256)},
+	}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff = %+v\nwant %+v", got, want)
+	}
+	if got := Diff(after, after); len(got) != 0 {
+		t.Fatalf("Diff of the same snapshot = %+v", got)
+	}
+}
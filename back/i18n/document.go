@@ -0,0 +1,93 @@
+package i18n
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// localizedFields จับคู่ map หลายภาษากับฟิลด์เดิมที่ Localize เขียนทับ
+var localizedFields = map[string]string{
+	"titles":       "title",
+	"descriptions": "description",
+	"names":        "name",
+	"bios":         "bio",
+}
+
+// Localize เขียนทับ title/name/description/bio ในเอกสารผลลัพธ์ด้วยข้อความภาษา locale (ดู Pick)
+// ไล่ลงไปในเอกสารย่อยด้วย เช่น author และ genres ที่ได้จาก $lookup ส่วน map หลายภาษายังอยู่ในผลลัพธ์
+func Localize(doc interface{}, locale string) {
+	switch v := doc.(type) {
+	case bson.M:
+		localizeMap(v, locale)
+	case map[string]interface{}:
+		localizeMap(v, locale)
+	case bson.D:
+		localizeD(v, locale)
+	case []bson.M:
+		for _, item := range v {
+			localizeMap(item, locale)
+		}
+	case primitive.A:
+		for _, item := range v {
+			Localize(item, locale)
+		}
+	case []interface{}:
+		for _, item := range v {
+			Localize(item, locale)
+		}
+	}
+}
+
+func localizeMap(doc map[string]interface{}, locale string) {
+	for key, value := range doc {
+		if field, ok := localizedFields[key]; ok {
+			if values := stringMap(value); values != nil {
+				plain, _ := doc[field].(string)
+				doc[field] = Pick(values, plain, locale)
+			}
+			continue
+		}
+		Localize(value, locale)
+	}
+}
+
+func localizeD(doc bson.D, locale string) {
+	for _, elem := range doc {
+		field, ok := localizedFields[elem.Key]
+		if !ok {
+			Localize(elem.Value, locale)
+			continue
+		}
+		values := stringMap(elem.Value)
+		if values == nil {
+			continue
+		}
+		for i := range doc {
+			if doc[i].Key == field {
+				plain, _ := doc[i].Value.(string)
+				doc[i].Value = Pick(values, plain, locale)
+			}
+		}
+	}
+}
+
+func stringMap(value interface{}) map[string]string {
+	var raw map[string]interface{}
+	switch v := value.(type) {
+	case bson.M:
+		raw = v
+	case map[string]interface{}:
+		raw = v
+	case bson.D:
+		raw = v.Map()
+	default:
+		return nil
+	}
+	out := make(map[string]string, len(raw))
+	for key, text := range raw {
+		if s, ok := text.(string); ok {
+			out[key] = s
+		}
+	}
+	return out
+}
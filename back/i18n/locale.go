@@ -0,0 +1,156 @@
+// Package i18n เลือกภาษาของชื่อและคำอธิบาย
+// หนังสือ นักเขียน หมวด ประเภท และแท็กเก็บข้อความภาษาหลัก (config.DefaultLocale) ไว้ที่ฟิลด์เดิม
+// เช่น title/name ส่วนภาษาอื่นเก็บเป็น map ตาม locale เช่น titles: {"en": "The Little Prince"}
+// client รุ่นเก่าที่อ่านแค่ฟิลด์เดิมจึงยังทำงานได้
+package i18n
+
+import (
+	"back/config"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Canonical ทำ locale ให้อยู่ในรูปเดียวกัน เช่น "en_US" เป็น "en-us"
+func Canonical(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// base คือภาษาหลักของ locale เช่น "en-us" เป็น "en"
+func base(tag string) string {
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// match คืนภาษาที่รองรับซึ่งตรงกับ tag ตรงตัว หรือมีภาษาหลักเดียวกัน เช่น "en-gb" ได้ "en"
+func match(tag string, supported []string) (string, bool) {
+	tag = Canonical(tag)
+	if tag == "" {
+		return "", false
+	}
+	for _, locale := range supported {
+		if locale == tag {
+			return locale, true
+		}
+	}
+	for _, locale := range supported {
+		if base(locale) == base(tag) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate เลือกภาษาของคำตอบ ?lang= มาก่อน แล้วจึงไล่ Accept-Language ตามค่า q
+// ถ้าไม่ตรงกับภาษาที่รองรับเลยใช้ config.DefaultLocale
+func Negotiate(lang, acceptLanguage string) string {
+	supported := config.SupportedLocales()
+	if locale, ok := match(lang, supported); ok {
+		return locale
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale, ok := match(tag, supported); ok {
+			return locale
+		}
+	}
+	return supported[0]
+}
+
+// parseAcceptLanguage คืน tag ใน Accept-Language เรียงตาม q มากไปน้อย ตัด "*" และ q=0 ทิ้ง
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		tag := strings.TrimSpace(params[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	out := make([]string, len(tags))
+	for i, tag := range tags {
+		out[i] = tag.tag
+	}
+	return out
+}
+
+// Pick เลือกข้อความสำหรับ locale โดยไล่ลำดับ
+//  1. values[locale] ตรงตัว
+//  2. ภาษาหลักเดียวกัน เช่นขอ "en-gb" แต่มีแค่ "en"
+//  3. fallback คือฟิลด์เดิมซึ่งเป็นภาษาหลักของระบบ
+//  4. ภาษาอื่นที่มี เรียงตามชื่อ locale เพื่อให้ได้ผลเดิมทุกครั้ง
+func Pick(values map[string]string, fallback, locale string) string {
+	if value := values[locale]; value != "" {
+		return value
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if base(key) == base(locale) && values[key] != "" {
+			return values[key]
+		}
+	}
+	if fallback != "" {
+		return fallback
+	}
+	for _, key := range keys {
+		if values[key] != "" {
+			return values[key]
+		}
+	}
+	return ""
+}
+
+// Fold ตรวจ locale ใน values ตัดช่องว่างและข้อความว่างทิ้ง แล้วย้ายข้อความภาษาหลักไปไว้ที่ฟิลด์เดิม
+// ข้อความใน values[DefaultLocale] ใช้แทน plain ถ้ามี (เช่น PATCH แค่ titles.th)
+// map ที่คืนจึงไม่มีภาษาหลัก และเป็น nil ถ้าไม่เหลือภาษาอื่น
+func Fold(plain string, values map[string]string) (string, map[string]string, error) {
+	defaultLocale := config.DefaultLocale()
+	var out map[string]string
+	for key, value := range values {
+		locale := Canonical(key)
+		if !tagPattern.MatchString(locale) {
+			return plain, nil, fmt.Errorf("invalid locale %q", key)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if locale == defaultLocale {
+			plain = value
+			continue
+		}
+		if out == nil {
+			out = map[string]string{}
+		}
+		out[locale] = value
+	}
+	return plain, out, nil
+}
@@ -2,9 +2,18 @@ package main
 
 import (
 	"back/config"
+	"back/middleware"
+	"back/recommend"
 	"back/routes"
+	"back/search"
+	"back/trending"
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -18,13 +27,14 @@ func main() {
 	
 	corsConfig := cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Language"},
 		AllowCredentials: true,
 	}
 	
 	router.Use(cors.New(corsConfig))
+	router.Use(middleware.LocaleMiddleware())
 
 	// สร้างโฟลเดอร์ uploads ถ้ายังไม่มี
 	if err := os.MkdirAll("uploads", 0755); err != nil {
@@ -32,6 +42,31 @@ func main() {
 	}
 
 	config.ConnectDB()
+	config.EnsureIndexes()
+
+	// โหลดดัชนีค้นหา ถ้ายังไม่เคยสร้างให้ build จากฐานข้อมูลเบื้องหลัง
+	index, err := search.Open(config.SearchIndexPath())
+	if err != nil {
+		fmt.Printf("Error loading search index, rebuilding: %v\n", err)
+		index = search.New(config.SearchIndexPath())
+	}
+	search.Default = index
+	// การแก้ดัชนีระหว่างทำงานบันทึกลงไฟล์เป็นรอบ และครั้งสุดท้ายตอนปิด server
+	shutdown, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go index.FlushEvery(shutdown, config.SearchFlushInterval())
+	if index.Len() == 0 {
+		go func() {
+			if err := search.Rebuild(context.Background(), index); err != nil {
+				fmt.Printf("Error building search index: %v\n", err)
+			}
+		}()
+	}
+	go search.RefreshSuggestionsEvery(context.Background(), config.AutocompleteRefreshInterval())
+
+	// model ของคำแนะนำเฉพาะบุคคล build ครั้งแรกเบื้องหลัง ระหว่างนั้น /api/books/for-me ตอบ 503
+	go recommend.RefreshEvery(context.Background(), config.RecommendRefreshInterval())
+	go trending.RefreshEvery(context.Background(), config.TrendingRefreshInterval())
 
 	// Setup routes
 	routes.AuthRoutes(router)
@@ -40,12 +75,35 @@ func main() {
 	routes.TagRoutes(router)
 	routes.AuthorRoutes(router)
 	routes.BookRoutes(router)
+	routes.SeriesRoutes(router)
+	routes.WorkRoutes(router)
 	routes.ReviewRoutes(router)
 	routes.MarkRoutes(router)
 	routes.ClubRoutes(router)
 	routes.PostRoutes(router)
 	routes.CommentRoutes(router)
 	routes.ReplyRoutes(router)
+	routes.AutocompleteRoutes(router)
+	routes.LocaleRoutes(router)
+	routes.NotificationRoutes(router)
+	routes.AdminRoutes(router)
 
-	router.Run(":8080")
+	server := &http.Server{Addr: ":8080", Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error starting server: %v\n", err)
+			stop()
+		}
+	}()
+
+	<-shutdown.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Printf("Error shutting down server: %v\n", err)
+	}
+	// บันทึกอีกครั้งหลัง request ที่ค้างอยู่ทำงานเสร็จ
+	if err := index.Flush(); err != nil {
+		fmt.Printf("Error saving search index: %v\n", err)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,170 @@
+// Package media ตรวจ แปลง และบันทึกรูปที่ผู้ใช้อัปโหลดลงโฟลเดอร์ uploads
+package media
+
+import (
+	"back/models"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// UploadDir คือโฟลเดอร์ที่ server เปิดเป็น /uploads (ดู main.go)
+var UploadDir = "uploads"
+
+const (
+	// MaxImageSize คือขนาดไฟล์รูปสูงสุดที่รับ
+	MaxImageSize = 10 << 20
+	// MaxImagePixels กันรูปที่ไฟล์เล็กแต่ขยายแล้วกินหน่วยความจำมหาศาล (decompression bomb)
+	MaxImagePixels = 40_000_000
+	jpegQuality    = 85
+)
+
+var (
+	ErrImageTooLarge    = errors.New("image is larger than 10MB")
+	ErrUnsupportedImage = errors.New("image must be JPEG, PNG, GIF or WebP")
+	ErrImageDimensions  = errors.New("image dimensions are too large")
+)
+
+// ชนิดไฟล์ที่รับ ตรวจจากเนื้อไฟล์ไม่ใช่จากนามสกุลหรือ Content-Type ที่ client ส่งมา
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Variant คือขนาดของรูปที่สร้าง รูปจะถูกย่อให้กว้างไม่เกิน Width โดยไม่ขยายรูปเล็ก
+type Variant struct {
+	Name  string
+	Width int
+}
+
+// CoverVariants คือขนาดปกหนังสือที่หน้าเว็บใช้ (รายการ, หน้ารายละเอียด, เต็มจอ)
+var CoverVariants = []Variant{
+	{Name: "small", Width: 160},
+	{Name: "medium", Width: 400},
+	{Name: "large", Width: 800},
+}
+
+// AuthorPhotoVariants คือขนาดรูปนักเขียน (avatar ในรายการ, หน้าโปรไฟล์, เต็มจอ)
+var AuthorPhotoVariants = []Variant{
+	{Name: "small", Width: 96},
+	{Name: "medium", Width: 256},
+	{Name: "large", Width: 512},
+}
+
+// Decode ตรวจชนิดไฟล์จาก magic bytes และขนาดก่อนถอดรหัสรูป
+func Decode(data []byte) (image.Image, error) {
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedImage
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxImagePixels {
+		return nil, ErrImageDimensions
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	return img, nil
+}
+
+// resize ย่อรูปและวางบนพื้นขาว เพราะ JPEG ไม่มีความโปร่งใส (PNG ใสจะกลายเป็นพื้นดำ)
+func resize(src image.Image, maxWidth int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxWidth {
+		height = max(1, height*maxWidth/width)
+		width = maxWidth
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
+
+// SaveVariants เข้ารหัสรูปใหม่ทุกขนาดเป็น JPEG และ WebP แล้วบันทึกใน UploadDir/dir
+// การเข้ารหัสใหม่ทำให้ EXIF และ metadata อื่นในไฟล์ต้นฉบับหายไปด้วย
+// WebP เป็นแบบ lossless (ยังไม่มี encoder แบบ lossy ที่เป็น Go ล้วน) จึงอาจใหญ่กว่า JPEG
+func SaveVariants(img image.Image, dir, prefix string, variants []Variant) ([]models.ImageVariant, error) {
+	if err := os.MkdirAll(filepath.Join(UploadDir, dir), 0755); err != nil {
+		return nil, err
+	}
+	// ใส่เวลาในชื่อไฟล์เพื่อให้ browser/CDN ไม่ใช้รูปเก่าที่ cache ไว้
+	base := fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+
+	var saved []models.ImageVariant
+	for _, variant := range variants {
+		resized := resize(img, variant.Width)
+		out := models.ImageVariant{Name: variant.Name, Width: resized.Bounds().Dx(), Height: resized.Bounds().Dy()}
+
+		var jpegBuf, webpBuf bytes.Buffer
+		if err := jpeg.Encode(&jpegBuf, resized, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			Remove(saved)
+			return nil, err
+		}
+		if err := nativewebp.Encode(&webpBuf, resized, nil); err != nil {
+			Remove(saved)
+			return nil, err
+		}
+		for _, file := range []struct {
+			url  *string
+			ext  string
+			data []byte
+		}{{&out.JPEG, "jpg", jpegBuf.Bytes()}, {&out.WebP, "webp", webpBuf.Bytes()}} {
+			name := path(dir, base+"_"+variant.Name+"."+file.ext)
+			if err := os.WriteFile(filepath.Join(UploadDir, filepath.FromSlash(name)), file.data, 0644); err != nil {
+				Remove(append(saved, out))
+				return nil, err
+			}
+			*file.url = "/uploads/" + name
+		}
+		saved = append(saved, out)
+	}
+	return saved, nil
+}
+
+// Remove ลบไฟล์ของรูปทุกขนาด ไฟล์ที่ไม่มีอยู่แล้วจะถูกข้าม
+func Remove(images []models.ImageVariant) {
+	for _, img := range images {
+		RemoveFile(img.JPEG)
+		RemoveFile(img.WebP)
+	}
+}
+
+// RemoveFile ลบไฟล์ของ URL แบบ /uploads/... คืน true ถ้าลบได้ URL ภายนอกจะถูกข้าม
+func RemoveFile(url string) bool {
+	name, ok := strings.CutPrefix(url, "/uploads/")
+	if !ok || name == "" || strings.Contains(name, "..") {
+		return false
+	}
+	return os.Remove(filepath.Join(UploadDir, filepath.FromSlash(name))) == nil
+}
+
+func path(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
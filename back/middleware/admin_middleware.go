@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AdminMiddleware ต้องใช้ต่อจาก JWTAuthMiddleware อ่าน role จากฐานข้อมูลทุกครั้ง
+// เพื่อให้การถอดสิทธิ์ admin มีผลทันทีโดยไม่ต้องรอ token หมดอายุ
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDRaw, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			c.Abort()
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(userIDRaw.(string))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid User ID format in context"})
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		err = config.DB.Database("bookwarm").Collection("users").
+			FindOne(context.TODO(), bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"role": 1})).
+			Decode(&user)
+		if err != nil || user.Role != models.UserRoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
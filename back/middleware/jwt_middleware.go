@@ -54,3 +54,32 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 
 	}
 }
+
+// OptionalJWTAuthMiddleware ใช้กับ route สาธารณะที่แสดงข้อมูลเพิ่มเติมเมื่อผู้ใช้ login อยู่
+// ถ้ามี token ที่ถูกต้องจะตั้งค่า user/userId/displayName เหมือน JWTAuthMiddleware
+// ถ้าไม่มีหรือ token ไม่ถูกต้องจะปล่อยผ่านโดยไม่ตั้งค่าอะไร
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" || tokenString == c.GetHeader("Authorization") {
+			c.Next()
+			return
+		}
+
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		})
+		if err == nil && token.Valid {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				email, emailOK := claims["email"].(string)
+				userID, idOK := claims["id"].(string)
+				if emailOK && idOK {
+					c.Set("user", email)
+					c.Set("userId", userID)
+					c.Set("displayName", claims["displayname"])
+				}
+			}
+		}
+		c.Next()
+	}
+}
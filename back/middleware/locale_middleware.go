@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"back/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware เลือกภาษาของคำตอบจาก ?lang= หรือ Accept-Language (ดู i18n.Negotiate)
+// แล้วเก็บไว้ที่ "locale" ใน context และบอก client ผ่าน Content-Language
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+		c.Set("locale", locale)
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// การกระทำของผู้ดูแลที่บันทึกใน audit log
+const (
+	AuditMerge = "merge"
+)
+
+// MergeReport สรุปการรวมผู้แต่ง/แท็ก/ประเภท/หมวดที่ซ้ำกัน (ดู catalog.Merge)
+type MergeReport struct {
+	Type               string             `json:"type" bson:"type"` // author, tag, genre หรือ category
+	SourceID           primitive.ObjectID `json:"sourceId" bson:"source_id"`
+	SourceName         string             `json:"sourceName" bson:"source_name"`
+	TargetID           primitive.ObjectID `json:"targetId" bson:"target_id"`
+	TargetName         string             `json:"targetName" bson:"target_name"`
+	Aliases            []string           `json:"aliases" bson:"aliases"` // alias ของเป้าหมายหลังรวม
+	BooksUpdated       int                `json:"booksUpdated" bson:"books_updated"`
+	SubmissionsUpdated int                `json:"submissionsUpdated" bson:"submissions_updated"`
+}
+
+// AuditEntry คือการกระทำหนึ่งครั้งของผู้ดูแลที่แก้ข้อมูลหลายที่พร้อมกัน
+type AuditEntry struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Action     string              `json:"action" bson:"action"`
+	EntityType string              `json:"entityType" bson:"entity_type"`
+	EntityID   primitive.ObjectID  `json:"entityId" bson:"entity_id"`
+	ActorID    *primitive.ObjectID `json:"actorId,omitempty" bson:"actor_id,omitempty"`
+	ActorName  string              `json:"actorName,omitempty" bson:"actor_name,omitempty"`
+	Merge      *MergeReport        `json:"merge,omitempty" bson:"merge,omitempty"`
+	CreatedAt  time.Time           `json:"createdAt" bson:"created_at"`
+}
@@ -1,10 +1,24 @@
 package models
 
-import(
+import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Author struct {
-	ID 		primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name 	string `json:"name" bson:"name"`
-}
\ No newline at end of file
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Names       map[string]string  `json:"names,omitempty" bson:"names,omitempty"`     // ชื่อภาษาอื่นตาม locale ภาษาหลักอยู่ที่ Name (ดู package i18n)
+	Aliases     []string           `json:"aliases,omitempty" bson:"aliases,omitempty"` // นามปากกาหรือชื่ออื่น ค้นหาได้เหมือนชื่อหลัก
+	Bio         string             `json:"bio,omitempty" bson:"bio,omitempty"`
+	Bios        map[string]string  `json:"bios,omitempty" bson:"bios,omitempty"`
+	Photo       string             `json:"photo,omitempty" bson:"photo,omitempty"`
+	PhotoSizes  []ImageVariant     `json:"photoSizes,omitempty" bson:"photoSizes,omitempty"` // มีเฉพาะรูปที่อัปโหลดผ่าน POST /api/authors/:id/photo
+	BirthDate   string             `json:"birthDate,omitempty" bson:"birthDate,omitempty"`   // YYYY, YYYY-MM หรือ YYYY-MM-DD เพราะหลายคนรู้แค่ปี
+	DeathDate   string             `json:"deathDate,omitempty" bson:"deathDate,omitempty"`
+	Nationality string             `json:"nationality,omitempty" bson:"nationality,omitempty"`
+	Website     string             `json:"website,omitempty" bson:"website,omitempty"`
+	CreatedAt   *time.Time         `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt   *time.Time         `json:"updatedAt,omitempty" bson:"update_at,omitempty"`
+}
@@ -7,18 +7,46 @@ import (
 )
 
 type Book struct {
-	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
-	Title       string               `json:"title" bson:"title"`
-	Description string               `json:"description" bson:"description"`
-	AuthorID    primitive.ObjectID   `json:"authorId" bson:"authorId"`
-	SeriesID    *primitive.ObjectID  `json:"seriesId,omitempty" bson:"seriesId,omitempty"`
-	CategoryID  primitive.ObjectID   `json:"category_id" bson:"category_id"`
-	Genres      []primitive.ObjectID `json:"genres" bson:"genres"`
-	TagIDs      []primitive.ObjectID `json:"tagIds" bson:"tagIds"`
-	PublishYear int                  `json:"publishYear" bson:"publishYear"`
-	PageCount   int                  `json:"pageCount" bson:"pageCount"`
-	Rating      float64              `json:"rating" bson:"rating"`
-	CoverImage  string               `json:"coverImage" bson:"coverImage"`
-	CreatedAt   time.Time            `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time            `json:"updatedAt" bson:"updatedAt"`
+	ID           primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Title        string               `json:"title" bson:"title"`
+	Description  string               `json:"description" bson:"description"`
+	Titles       map[string]string    `json:"titles,omitempty" bson:"titles,omitempty"` // ชื่อเรื่องภาษาอื่นตาม locale ภาษาหลักอยู่ที่ Title (ดู package i18n)
+	Descriptions map[string]string    `json:"descriptions,omitempty" bson:"descriptions,omitempty"`
+	ISBN13       string               `json:"isbn13,omitempty" bson:"isbn13,omitempty"`
+	ISBN10       string               `json:"isbn10,omitempty" bson:"isbn10,omitempty"`
+	WorkID       *primitive.ObjectID  `json:"workId,omitempty" bson:"workId,omitempty"`
+	Language     string               `json:"language,omitempty" bson:"language,omitempty"`
+	Format       string               `json:"format,omitempty" bson:"format,omitempty"`
+	Publisher    string               `json:"publisher,omitempty" bson:"publisher,omitempty"`
+	AuthorID     primitive.ObjectID   `json:"authorId" bson:"authorId"` // ผู้แต่งหลัก (คนแรกที่มีบทบาท author ใน Contributors)
+	Contributors []Contributor        `json:"contributors,omitempty" bson:"contributors,omitempty"`
+	SeriesID     *primitive.ObjectID  `json:"seriesId,omitempty" bson:"seriesId,omitempty"`
+	SeriesNumber *float64             `json:"seriesNumber,omitempty" bson:"seriesNumber,omitempty"` // เล่มที่ในซีรีส์ เป็นทศนิยมได้ เช่น 2.5
+	CategoryID   primitive.ObjectID   `json:"category_id" bson:"category_id"`
+	Genres       []primitive.ObjectID `json:"genres" bson:"genres"`
+	TagIDs       []primitive.ObjectID `json:"tagIds" bson:"tagIds"`
+	PublishYear  int                  `json:"publishYear" bson:"publishYear"`
+	PageCount    int                  `json:"pageCount" bson:"pageCount"`
+	Rating       float64              `json:"rating" bson:"rating"` // เท่ากับ AvgRating เก็บไว้ให้ client รุ่นเก่า
+	AvgRating    float64              `json:"avg_rating" bson:"avg_rating"`
+	ReviewCount  int                  `json:"review_count" bson:"review_count"`
+	RatingSum    int                  `json:"-" bson:"rating_sum"`
+	RatingCounts map[string]int       `json:"rating_histogram" bson:"rating_histogram"` // จำนวนรีวิวต่อดาว "1" ถึง "5" ดู package ratings
+	CoverImage   string               `json:"coverImage" bson:"coverImage"`
+	CoverSizes   []ImageVariant       `json:"coverSizes,omitempty" bson:"coverSizes,omitempty"` // มีเฉพาะปกที่อัปโหลดผ่าน POST /api/books/:id/cover
+	CreatedAt    time.Time            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time            `json:"updatedAt" bson:"updatedAt"`
+}
+
+// BookDeleteReport สรุปข้อมูลที่ได้รับผลเมื่อลบหนังสือ (ดู catalog.DeleteBook)
+type BookDeleteReport struct {
+	BookID          string `json:"bookId"`
+	Title           string `json:"title,omitempty"`
+	ReviewsDeleted  int    `json:"reviewsDeleted"`
+	ReviewsArchived int    `json:"reviewsArchived"`
+	MarksDeleted    int    `json:"marksDeleted"`
+	MarksArchived   int    `json:"marksArchived"`
+	MarksMoved      int    `json:"marksMoved"` // ย้ายไป edition อื่นของงานเดียวกัน
+	PostsUnlinked   int    `json:"postsUnlinked"`
+	FilesRemoved    int    `json:"filesRemoved"`
 }
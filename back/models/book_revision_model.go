@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// การกระทำที่ทำให้เกิด revision
+const (
+	RevisionBaseline = "baseline" // สภาพของหนังสือที่มีอยู่ก่อนเริ่มเก็บประวัติ
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionImport   = "import"
+	RevisionRevert   = "revert"
+	RevisionMerge    = "merge" // ผู้แต่ง/แท็ก/ประเภท/หมวดที่อ้างถึงถูกรวมกับอันอื่น
+)
+
+// FieldChange คือค่าของฟิลด์หนึ่งก่อนและหลังการแก้ไข (nil คือไม่มีค่า)
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	Old   interface{} `json:"old" bson:"old"`
+	New   interface{} `json:"new" bson:"new"`
+}
+
+// BookRevision คือการแก้ไขหนังสือหนึ่งครั้ง Snapshot คือค่าของทุกฟิลด์ที่แก้ได้หลังการแก้ไข
+// (ในรูป JSON เดียวกับที่ API ตอบ) ใช้ย้อนกลับไปที่ revision นี้
+type BookRevision struct {
+	ID           primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	BookID       primitive.ObjectID     `json:"bookId" bson:"book_id"`
+	Number       int                    `json:"number" bson:"number"`
+	Action       string                 `json:"action" bson:"action"`
+	EditorID     *primitive.ObjectID    `json:"editorId,omitempty" bson:"editor_id,omitempty"`
+	EditorName   string                 `json:"editorName,omitempty" bson:"editor_name,omitempty"`
+	RevertedFrom int                    `json:"revertedFrom,omitempty" bson:"reverted_from,omitempty"` // revision ที่ย้อนกลับไป
+	Changes      []FieldChange          `json:"changes" bson:"changes"`
+	Snapshot     map[string]interface{} `json:"snapshot,omitempty" bson:"snapshot"`
+	CreatedAt    time.Time              `json:"createdAt" bson:"created_at"`
+}
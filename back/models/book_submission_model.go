@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// สถานะของหนังสือที่ผู้อ่านส่งเข้ามา
+const (
+	SubmissionPending  = "pending"
+	SubmissionApproved = "approved" // เพิ่มเป็นหนังสือเล่มใหม่แล้ว
+	SubmissionMerged   = "merged"   // ซ้ำกับหนังสือที่มีอยู่ ข้อมูลที่ขาดถูกเติมให้เล่มนั้น
+	SubmissionRejected = "rejected"
+)
+
+// BookSubmission คือหนังสือที่ผู้อ่านเสนอให้เพิ่ม รอผู้ดูแลตรวจก่อนจะเข้า collection books
+// Book.ID ถูกจองไว้ตั้งแต่ส่ง และเป็น id ของหนังสือเมื่ออนุมัติ
+type BookSubmission struct {
+	ID            primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Book          Book                 `json:"book" bson:"book"`
+	Note          string               `json:"note,omitempty" bson:"note,omitempty"` // ข้อความถึงผู้ดูแล เช่น ที่มาของข้อมูล
+	Status        string               `json:"status" bson:"status"`
+	SubmittedBy   primitive.ObjectID   `json:"submittedBy" bson:"submitted_by"`
+	SubmitterName string               `json:"submitterName" bson:"submitter_name"`
+	DuplicateIDs  []primitive.ObjectID `json:"duplicateIds,omitempty" bson:"duplicate_ids,omitempty"` // หนังสือที่น่าจะซ้ำ ณ ตอนส่ง
+	ReviewedBy    *primitive.ObjectID  `json:"reviewedBy,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewerName  string               `json:"reviewerName,omitempty" bson:"reviewer_name,omitempty"`
+	Reason        string               `json:"reason,omitempty" bson:"reason,omitempty"`
+	BookID        *primitive.ObjectID  `json:"bookId,omitempty" bson:"book_id,omitempty"` // หนังสือที่สร้างหรือรวมเข้าไป
+	CreatedAt     time.Time            `json:"createdAt" bson:"created_at"`
+	ReviewedAt    *time.Time           `json:"reviewedAt,omitempty" bson:"reviewed_at,omitempty"`
+}
@@ -7,7 +7,8 @@ import(
 
 type Series struct {
 	ID 			primitive.ObjectID 	`json:"id" bson:"_id,omitempty"`
-	Name 		string 				`json:"name" bson:"name"`
+	Name 		string 				`json:"name" bson:"name" binding:"required"`
+	Description string 				`json:"description" bson:"description"`
 	CreatedAt 	time.Time 			`json:"createdAt" bson:"createdAt"`
 	UpdatedAt 	time.Time 			`json:"updatedAt" bson:"updatedAt"`
-}
\ No newline at end of file
+}
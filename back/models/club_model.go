@@ -15,3 +15,11 @@ type Club struct {
 	CreatedAt   time.Time            `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time            `json:"updated_at" bson:"updated_at"`
 }
+
+// ClubJoin บันทึกเวลาที่ผู้ใช้เข้าร่วมคลับ (Members ไม่มีเวลา) ใช้จัดอันดับคลับที่มาแรง
+// ถูกลบเมื่อผู้ใช้ออกจากคลับ
+type ClubJoin struct {
+	ClubID   primitive.ObjectID `json:"club_id" bson:"club_id"`
+	UserID   primitive.ObjectID `json:"user_id" bson:"user_id"`
+	JoinedAt time.Time          `json:"joined_at" bson:"joined_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// บทบาทของผู้มีส่วนร่วมในหนังสือ
+const (
+	RoleAuthor      = "author"
+	RoleCoAuthor    = "co-author"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+	RoleEditor      = "editor"
+)
+
+var ContributorRoles = []string{RoleAuthor, RoleCoAuthor, RoleTranslator, RoleIllustrator, RoleEditor}
+
+// Contributor คือบุคคลหนึ่งคนที่มีส่วนร่วมในหนังสือ อ้างถึงเอกสารใน collection author
+type Contributor struct {
+	AuthorID primitive.ObjectID `json:"authorId" bson:"authorId"`
+	Role     string             `json:"role" bson:"role"`
+}
+
+func IsContributorRole(role string) bool {
+	for _, r := range ContributorRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
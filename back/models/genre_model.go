@@ -7,4 +7,7 @@ import(
 type Genre struct {
 	ID   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Name string             `json:"name" bson:"name"`
+	Names map[string]string  `json:"names,omitempty" bson:"names,omitempty"` // ชื่อภาษาอื่นตาม locale (ดู package i18n)
+	Aliases []string           `json:"aliases,omitempty" bson:"aliases,omitempty"` // ชื่อเดิมของประเภทที่ถูกรวมเข้ามา
+	ParentID *primitive.ObjectID `json:"parentId,omitempty" bson:"parent_id,omitempty"` // ประเภทแม่ ไม่มีคือระดับบนสุด (ดู package taxonomy)
 }
\ No newline at end of file
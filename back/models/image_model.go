@@ -0,0 +1,10 @@
+package models
+
+// ImageVariant คือรูปหนึ่งขนาดที่ระบบย่อและบันทึกไว้ URL เป็น path ใต้ /uploads
+type ImageVariant struct {
+	Name   string `json:"name" bson:"name"` // small, medium, large
+	Width  int    `json:"width" bson:"width"`
+	Height int    `json:"height" bson:"height"`
+	JPEG   string `json:"jpeg" bson:"jpeg"`
+	WebP   string `json:"webp" bson:"webp"`
+}
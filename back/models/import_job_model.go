@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// สถานะของงาน import
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// ImportRowError คือข้อผิดพลาดของแถวหนึ่งในไฟล์ import (Line นับจาก 1 รวมบรรทัดหัวตารางของ CSV)
+type ImportRowError struct {
+	Line  int    `json:"line" bson:"line"`
+	Title string `json:"title,omitempty" bson:"title,omitempty"`
+	Error string `json:"error" bson:"error"`
+}
+
+// ImportReport สรุปผลการ import ใช้ทั้งตอนกำลังทำงาน (ความคืบหน้า) และเมื่อเสร็จ
+type ImportReport struct {
+	Total     int                 `json:"total" bson:"total"`
+	Processed int                 `json:"processed" bson:"processed"`
+	Created   int                 `json:"created" bson:"created"`
+	Updated   int                 `json:"updated" bson:"updated"`
+	Failed    int                 `json:"failed" bson:"failed"`
+	NewRefs   map[string][]string `json:"newRefs,omitempty" bson:"newRefs,omitempty"` // ชื่อผู้แต่ง/หมวดหมู่/... ที่สร้างใหม่ แยกตาม collection
+	Errors    []ImportRowError    `json:"errors,omitempty" bson:"errors,omitempty"`
+	// MarksCreated คือจำนวน mark "read" ที่สร้างให้ผู้ใช้ตอน import คลังส่วนตัว (เช่น Calibre)
+	MarksCreated int `json:"marksCreated,omitempty" bson:"marksCreated,omitempty"`
+}
+
+type ImportJob struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Status     string             `json:"status" bson:"status"`
+	Format     string             `json:"format" bson:"format"`
+	FileName   string             `json:"fileName,omitempty" bson:"fileName,omitempty"`
+	DryRun     bool               `json:"dryRun" bson:"dryRun"`
+	CreatedBy  primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	Report     ImportReport       `json:"report" bson:"report"`
+	Error      string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	StartedAt  *time.Time         `json:"startedAt,omitempty" bson:"startedAt,omitempty"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ชนิดของการแจ้งเตือน
+const (
+	NotifySubmissionApproved = "submission_approved"
+	NotifySubmissionMerged   = "submission_merged"
+	NotifySubmissionRejected = "submission_rejected"
+)
+
+type Notification struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID       primitive.ObjectID  `json:"userId" bson:"user_id"`
+	Type         string              `json:"type" bson:"type"`
+	Message      string              `json:"message" bson:"message"`
+	Reason       string              `json:"reason,omitempty" bson:"reason,omitempty"`
+	BookID       *primitive.ObjectID `json:"bookId,omitempty" bson:"book_id,omitempty"`
+	SubmissionID *primitive.ObjectID `json:"submissionId,omitempty" bson:"submission_id,omitempty"`
+	Read         bool                `json:"read" bson:"read"`
+	CreatedAt    time.Time           `json:"createdAt" bson:"created_at"`
+}
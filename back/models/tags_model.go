@@ -7,4 +7,6 @@ import (
 type Tag struct {
 	ID 		primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Name 	string             `json:"name" bson:"name"`
+	Names 	map[string]string  `json:"names,omitempty" bson:"names,omitempty"` // ชื่อภาษาอื่นตาม locale (ดู package i18n)
+	Aliases []string           `json:"aliases,omitempty" bson:"aliases,omitempty"` // ชื่อเดิมของแท็กที่ถูกรวมเข้ามา
 }
\ No newline at end of file
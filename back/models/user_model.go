@@ -14,6 +14,18 @@ type User struct {
 	ProfilePic  string             `bson:"profile_img_url"`
 	BgImgURL    string             `bson:"bg_img_url"`
 	Bio         string             `bson:"bio"`
+	Role        string             `bson:"role,omitempty"` // "admin" หรือว่าง (ผู้ใช้ทั่วไป)
+	Identities  []Identity         `bson:"identities,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at"`
 }
+
+const UserRoleAdmin = "admin"
+
+// Identity คือบัญชีของผู้ให้บริการ OIDC ที่ผูกกับ user (provider + subject)
+type Identity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"subject"`
+	Email    string    `bson:"email" json:"email"`
+	LinkedAt time.Time `bson:"linked_at" json:"linked_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// รูปแบบของ edition
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
+var EditionFormats = []string{FormatHardcover, FormatPaperback, FormatEbook, FormatAudiobook}
+
+// Work คือตัวงานเขียนที่ไม่ขึ้นกับฉบับพิมพ์ หนังสือ (Book) แต่ละเล่มที่มี WorkID เดียวกัน
+// คือ edition ต่าง ๆ ของงานนี้ เช่น ปกแข็ง ปกอ่อน ebook หรือฉบับแปล
+type Work struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Title            string             `json:"title" bson:"title" binding:"required"`
+	OriginalLanguage string             `json:"originalLanguage,omitempty" bson:"originalLanguage,omitempty"`
+	Description      string             `json:"description,omitempty" bson:"description,omitempty"`
+	ExternalID       string             `json:"externalId,omitempty" bson:"externalId,omitempty"` // id จากแหล่งที่ import มา เช่น "openlibrary:/works/OL45883W"
+	CreatedAt        time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+func IsEditionFormat(format string) bool {
+	for _, f := range EditionFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
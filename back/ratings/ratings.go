@@ -0,0 +1,244 @@
+// Package ratings ดูแลคะแนนรวมของหนังสือที่เก็บไว้ในเอกสาร books
+// (avg_rating, review_count, rating_sum, rating_histogram) ให้ตรงกับ reviews
+// ทุกครั้งที่รีวิวถูกสร้าง แก้ หรือลบ ต้องเรียก Apply ใน transaction เดียวกัน
+package ratings
+
+import (
+	"back/config"
+	"context"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Stats คือคะแนนรวมของหนังสือหนึ่งเล่มหรือหลายเล่มรวมกัน (เช่น ทุก edition ของ work)
+type Stats struct {
+	Avg       float64        `json:"avg_rating"`
+	Count     int            `json:"review_count"`
+	Histogram map[string]int `json:"rating_histogram"` // จำนวนรีวิวต่อดาว "1" ถึง "5"
+}
+
+// EmptyHistogram คืน histogram ที่ทุกดาวเป็น 0
+func EmptyHistogram() map[string]int {
+	return map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+}
+
+// Apply ปรับคะแนนรวมของหนังสือเมื่อคะแนนรีวิวหนึ่งอันเปลี่ยนจาก oldRating เป็น newRating
+// ใช้ 0 แทน "ไม่มีรีวิว" เช่น Apply(ctx, id, 0, 4) คือรีวิวใหม่ Apply(ctx, id, 4, 0) คือลบรีวิว
+// ต้องเรียกหลังเขียนรีวิวแล้วใน transaction เดียวกัน
+func Apply(ctx context.Context, bookID primitive.ObjectID, oldRating, newRating int) error {
+	if oldRating == newRating {
+		return nil
+	}
+	books := config.DB.Database("bookwarm").Collection("books")
+
+	// หนังสือที่ยังไม่เคยมีคะแนนรวม (สร้างก่อนมีฟิลด์เหล่านี้หรือมาจาก import) อาจมีรีวิวเก่าอยู่แล้ว
+	// ถ้าเริ่มจาก 0 แล้วลบออกจะได้ค่าติดลบ จึงคำนวณใหม่จาก reviews ซึ่งรวมรีวิวที่เพิ่งเขียนไปแล้ว
+	err := books.FindOne(ctx, bson.M{"_id": bookID, "rating_histogram": bson.M{"$exists": true}},
+		options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err == mongo.ErrNoDocuments {
+		_, err = Recompute(ctx, []primitive.ObjectID{bookID})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	countDelta := 0
+	if oldRating == 0 {
+		countDelta = 1
+	} else if newRating == 0 {
+		countDelta = -1
+	}
+
+	add := func(field string, delta int) bson.M {
+		return bson.M{"$add": []interface{}{bson.M{"$ifNull": []interface{}{"$" + field, 0}}, delta}}
+	}
+	counts := bson.M{
+		"review_count": add("review_count", countDelta),
+		"rating_sum":   add("rating_sum", newRating-oldRating),
+	}
+	if oldRating > 0 {
+		key := "rating_histogram." + strconv.Itoa(oldRating)
+		counts[key] = add(key, -1)
+	}
+	if newRating > 0 {
+		key := "rating_histogram." + strconv.Itoa(newRating)
+		counts[key] = add(key, 1)
+	}
+
+	// ใช้ update แบบ pipeline เพื่อคำนวณค่าเฉลี่ยจากค่าที่เพิ่งอัปเดตในคำสั่งเดียว
+	pipeline := []bson.M{
+		{"$set": bson.M{"rating_histogram": bson.M{"$mergeObjects": []interface{}{
+			EmptyHistogram(), bson.M{"$ifNull": []interface{}{"$rating_histogram", bson.M{}}},
+		}}}},
+		{"$set": counts},
+		{"$set": bson.M{"avg_rating": bson.M{"$cond": []interface{}{
+			bson.M{"$gt": []interface{}{"$review_count", 0}},
+			bson.M{"$divide": []interface{}{"$rating_sum", "$review_count"}},
+			0,
+		}}}},
+		// rating เดิมเป็นค่าที่ client ส่งมาเอง ตอนนี้ให้เท่ากับ avg_rating เพื่อ client รุ่นเก่า
+		{"$set": bson.M{"rating": "$avg_rating"}},
+	}
+	_, err = books.UpdateOne(ctx, bson.M{"_id": bookID}, pipeline)
+	return err
+}
+
+// Combined รวมคะแนนของหนังสือหลายเล่มจากค่าที่เก็บไว้ ไม่ต้องอ่านรีวิวทั้งหมด
+func Combined(ctx context.Context, bookIDs []primitive.ObjectID) (Stats, error) {
+	stats := Stats{Histogram: EmptyHistogram()}
+	cursor, err := config.DB.Database("bookwarm").Collection("books").Find(ctx,
+		bson.M{"_id": bson.M{"$in": bookIDs}},
+		options.Find().SetProjection(bson.M{"review_count": 1, "rating_sum": 1, "rating_histogram": 1}))
+	if err != nil {
+		return stats, err
+	}
+	var books []struct {
+		ReviewCount int            `bson:"review_count"`
+		RatingSum   int            `bson:"rating_sum"`
+		Histogram   map[string]int `bson:"rating_histogram"`
+	}
+	if err := cursor.All(ctx, &books); err != nil {
+		return stats, err
+	}
+	sum := 0
+	for _, book := range books {
+		stats.Count += book.ReviewCount
+		sum += book.RatingSum
+		for star, n := range book.Histogram {
+			stats.Histogram[star] += n
+		}
+	}
+	if stats.Count > 0 {
+		stats.Avg = float64(sum) / float64(stats.Count)
+	}
+	return stats, nil
+}
+
+// bookStats คือคะแนนรวมตามที่เก็บในเอกสาร books (avg_rating ไม่ปัดเศษ ให้ผู้แสดงผลปัดเอง)
+type bookStats struct {
+	AvgRating   float64        `bson:"avg_rating"`
+	ReviewCount int            `bson:"review_count"`
+	RatingSum   int            `bson:"rating_sum"`
+	Histogram   map[string]int `bson:"rating_histogram"`
+}
+
+// Recompute คำนวณคะแนนรวมใหม่จาก reviews ทั้งหมด ใช้แก้ค่าที่คลาดเคลื่อน
+// bookIDs เป็น nil หมายถึงทุกเล่ม คืนจำนวนเล่มที่ค่าที่เก็บไว้ไม่ตรงและถูกแก้
+func Recompute(ctx context.Context, bookIDs []primitive.ObjectID) (int, error) {
+	db := config.DB.Database("bookwarm")
+
+	reviewFilter := bson.M{"rating": bson.M{"$gte": 1, "$lte": 5}}
+	bookFilter := bson.M{}
+	if bookIDs != nil {
+		reviewFilter["book_id"] = bson.M{"$in": bookIDs}
+		bookFilter["_id"] = bson.M{"$in": bookIDs}
+	}
+	cursor, err := db.Collection("reviews").Aggregate(ctx, []bson.M{
+		{"$match": reviewFilter},
+		{"$group": bson.M{
+			"_id":   bson.M{"book": "$book_id", "rating": "$rating"},
+			"count": bson.M{"$sum": 1},
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var groups []struct {
+		ID struct {
+			Book   primitive.ObjectID `bson:"book"`
+			Rating int                `bson:"rating"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return 0, err
+	}
+
+	expected := map[primitive.ObjectID]*bookStats{}
+	for _, group := range groups {
+		s := expected[group.ID.Book]
+		if s == nil {
+			s = &bookStats{Histogram: EmptyHistogram()}
+			expected[group.ID.Book] = s
+		}
+		s.ReviewCount += group.Count
+		s.RatingSum += group.ID.Rating * group.Count
+		s.Histogram[strconv.Itoa(group.ID.Rating)] += group.Count
+	}
+
+	books, err := db.Collection("books").Find(ctx, bookFilter,
+		options.Find().SetProjection(bson.M{"avg_rating": 1, "review_count": 1, "rating_sum": 1, "rating_histogram": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer books.Close(ctx)
+
+	fixed := 0
+	var writes []mongo.WriteModel
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		_, err := db.Collection("books").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		writes = writes[:0]
+		return err
+	}
+	for books.Next(ctx) {
+		var current struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			bookStats `bson:",inline"`
+		}
+		if err := books.Decode(&current); err != nil {
+			return fixed, err
+		}
+		want := expected[current.ID]
+		if want == nil {
+			want = &bookStats{Histogram: EmptyHistogram()}
+		}
+		if want.ReviewCount > 0 {
+			want.AvgRating = float64(want.RatingSum) / float64(want.ReviewCount)
+		}
+		if sameStats(current.bookStats, *want) {
+			continue
+		}
+
+		fixed++
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": current.ID}).SetUpdate(bson.M{"$set": bson.M{
+			"avg_rating":       want.AvgRating,
+			"rating":           want.AvgRating,
+			"review_count":     want.ReviewCount,
+			"rating_sum":       want.RatingSum,
+			"rating_histogram": want.Histogram,
+		}}))
+		if len(writes) >= 500 {
+			if err := flush(); err != nil {
+				return fixed, err
+			}
+		}
+	}
+	if err := books.Err(); err != nil {
+		return fixed, err
+	}
+	return fixed, flush()
+}
+
+func sameStats(current, want bookStats) bool {
+	if current.AvgRating != want.AvgRating || current.ReviewCount != want.ReviewCount || current.RatingSum != want.RatingSum {
+		return false
+	}
+	// เอกสารที่ไม่มี histogram เลยถือว่าไม่ตรง แม้จำนวนรีวิวเป็น 0
+	if len(current.Histogram) != len(want.Histogram) {
+		return false
+	}
+	for star, n := range want.Histogram {
+		if current.Histogram[star] != n {
+			return false
+		}
+	}
+	return true
+}
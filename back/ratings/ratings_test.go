@@ -0,0 +1,24 @@
+package ratings
+
+import "testing"
+
+func TestSameStats(t *testing.T) {
+	histogram := EmptyHistogram()
+	histogram["4"], histogram["5"] = 1, 1
+	want := bookStats{AvgRating: 4.5, ReviewCount: 2, RatingSum: 9, Histogram: histogram}
+
+	current := want
+	current.Histogram = map[string]int{"1": 0, "2": 0, "3": 0, "4": 1, "5": 1}
+	if !sameStats(current, want) {
+		t.Fatal("identical stats do not match")
+	}
+	current.Histogram["4"], current.Histogram["5"] = 2, 0
+	if sameStats(current, want) {
+		t.Fatal("stale histogram matched")
+	}
+
+	// Apply พึ่ง Recompute ให้เขียนคะแนนรวมของหนังสือที่ยังไม่มี histogram เสมอ แม้จะไม่มีรีวิวเหลือแล้ว
+	if sameStats(bookStats{}, bookStats{Histogram: EmptyHistogram()}) {
+		t.Fatal("a book without a histogram matched an empty one")
+	}
+}
@@ -0,0 +1,293 @@
+package recommend
+
+import (
+	"back/config"
+	"context"
+	"math"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// น้ำหนักของหนังสือที่ผู้ใช้ mark ไว้ตามสถานะ หนังสือที่อ่านไม่จบทำให้ความชอบลดลง
+var statusWeights = map[string]float64{
+	"read":           1,
+	"now reading":    0.8,
+	"want to read":   0.5,
+	"did not finish": -0.5,
+}
+
+// น้ำหนักของแต่ละชนิด feature ต่อคะแนน content tag มักกว้างและซ้ำซ้อนจึงได้น้อยกว่า
+// หมวดและซีรีส์ไม่นับ (หมวดกว้างเกินไป ส่วนซีรีส์ที่อ่านอยู่ผู้ใช้รู้อยู่แล้ว)
+var featureWeights = map[string]float64{"genre": 1, "author": 1, "tag": 0.5}
+
+// contentWeight คือสัดส่วนของคะแนน content เทียบกับคะแนน collaborative filtering
+const contentWeight = 0.5
+
+// ชนิดของเหตุผลที่แนะนำ
+const (
+	ReasonBook    = "book" // อ่านคู่กับหนังสือที่ผู้ใช้อ่าน
+	ReasonGenre   = "genre"
+	ReasonTag     = "tag"
+	ReasonAuthor  = "author"
+	ReasonPopular = "popular" // ผู้ใช้ยังไม่มีประวัติพอ
+)
+
+// Reason อธิบายว่าทำไมหนังสือถูกแนะนำ ID และ Name คือหนังสือ genre tag หรือผู้แต่งที่เป็นเหตุผล
+type Reason struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Text string `json:"text"`
+}
+
+// Recommendation คือหนังสือที่แนะนำหนึ่งเล่ม BookID คือ edition ที่ใช้แสดงผล
+type Recommendation struct {
+	BookID primitive.ObjectID
+	Score  float64
+	Reason Reason
+}
+
+// seed คือหนังสือที่ผู้ใช้เคย mark หรือรีวิว
+type seed struct {
+	weight float64
+	status string // ว่างถ้ารีวิวโดยไม่ได้ mark
+}
+
+// userSeeds อ่าน marks และรีวิวของผู้ใช้ รีวิวผูกกับผู้ใช้ด้วย displayname (reviewer_name)
+// คะแนนรีวิวปรับน้ำหนักจาก -1 (1 ดาว) ถึง +1 (5 ดาว) รีวิวโดยไม่ได้ mark ถือว่าอ่านแล้ว
+func (m *Model) userSeeds(ctx context.Context, userID primitive.ObjectID) (map[primitive.ObjectID]*seed, error) {
+	db := config.DB.Database("bookwarm")
+	seeds := map[primitive.ObjectID]*seed{}
+
+	cursor, err := db.Collection("marks").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	var marks []struct {
+		BookID primitive.ObjectID `bson:"book_id"`
+		Status string             `bson:"status"`
+	}
+	if err := cursor.All(ctx, &marks); err != nil {
+		return nil, err
+	}
+	for _, mark := range marks {
+		if key, ok := m.bookItem[mark.BookID]; ok {
+			seeds[key] = &seed{weight: statusWeights[mark.Status], status: mark.Status}
+		}
+	}
+
+	var user struct {
+		DisplayName string `bson:"displayname"`
+	}
+	err = db.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err == mongo.ErrNoDocuments || (err == nil && user.DisplayName == "") {
+		return seeds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cursor, err = db.Collection("reviews").Find(ctx, bson.M{"reviewer_name": user.DisplayName},
+		options.Find().SetProjection(bson.M{"book_id": 1, "rating": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var reviews []struct {
+		BookID primitive.ObjectID `bson:"book_id"`
+		Rating int                `bson:"rating"`
+	}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		key, ok := m.bookItem[review.BookID]
+		if !ok || review.Rating < 1 || review.Rating > 5 {
+			continue
+		}
+		s := seeds[key]
+		if s == nil {
+			s = &seed{weight: statusWeights["read"]}
+			seeds[key] = s
+		}
+		s.weight += float64(review.Rating-3) / 2
+	}
+	return seeds, nil
+}
+
+// ForUser คืนหนังสือที่แนะนำให้ผู้ใช้ไม่เกิน limit เล่ม ไม่รวมเล่มที่ผู้ใช้ mark หรือรีวิวแล้ว
+//
+//	score = Σ น้ำหนักของ seed × similarity (จาก Neighbors)
+//	      + contentWeight × Σ ความชอบต่อ feature ของเล่มนั้น / sqrt(จำนวน feature)
+//
+// ความชอบต่อ genre, tag และผู้แต่งคือผลรวมน้ำหนักของ seed ที่มี feature นั้นหารด้วยผลรวมน้ำหนักทั้งหมด
+// ถ้าได้ไม่ครบ limit เล่ม (เช่น ผู้ใช้ใหม่) จะเติมด้วยหนังสือยอดนิยม
+// personalized เป็น false ถ้าผู้ใช้ยังไม่มีประวัติเลย
+func (m *Model) ForUser(ctx context.Context, userID primitive.ObjectID, limit int) ([]Recommendation, bool, error) {
+	seeds, err := m.userSeeds(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	return m.rank(seeds, limit), len(seeds) > 0, nil
+}
+
+func (m *Model) rank(seeds map[primitive.ObjectID]*seed, limit int) []Recommendation {
+	var recs []Recommendation
+	affinity := map[Feature]float64{}
+	total := 0.0
+	for key, s := range seeds {
+		total += math.Abs(s.weight)
+		for _, f := range m.Items[key].Features {
+			affinity[f] += s.weight
+		}
+	}
+	if total > 0 {
+		for f := range affinity {
+			affinity[f] /= total
+		}
+	}
+
+	type candidate struct {
+		cf, content         float64
+		bestCF              float64
+		bestSeed            primitive.ObjectID
+		bestFeatureScore    float64
+		bestFeature         Feature
+		hasSeed, hasFeature bool
+	}
+	candidates := map[primitive.ObjectID]*candidate{}
+	get := func(key primitive.ObjectID) *candidate {
+		c := candidates[key]
+		if c == nil {
+			c = &candidate{}
+			candidates[key] = c
+		}
+		return c
+	}
+
+	for key, s := range seeds {
+		if s.weight <= 0 {
+			continue
+		}
+		for _, n := range m.Neighbors[key] {
+			if _, marked := seeds[n.Item]; marked {
+				continue
+			}
+			c := get(n.Item)
+			contribution := s.weight * n.Score
+			c.cf += contribution
+			if contribution > c.bestCF {
+				c.bestCF, c.bestSeed, c.hasSeed = contribution, key, true
+			}
+		}
+	}
+	if len(affinity) > 0 {
+		for key, it := range m.Items {
+			if _, marked := seeds[key]; marked {
+				continue
+			}
+			weighted := 0
+			for _, f := range it.Features {
+				if featureWeights[f.Kind] > 0 {
+					weighted++
+				}
+			}
+			if weighted == 0 {
+				continue
+			}
+			norm := math.Sqrt(float64(weighted))
+			var content, best float64
+			var bestFeature Feature
+			for _, f := range it.Features {
+				score := affinity[f] * featureWeights[f.Kind] / norm
+				content += score
+				if score > best {
+					best, bestFeature = score, f
+				}
+			}
+			if content <= 0 {
+				continue
+			}
+			c := get(key)
+			c.content = content
+			c.bestFeatureScore, c.bestFeature, c.hasFeature = best, bestFeature, best > 0
+		}
+	}
+
+	for key, c := range candidates {
+		score := c.cf + contentWeight*c.content
+		if score <= 0 {
+			continue
+		}
+		rec := Recommendation{BookID: m.Items[key].Book, Score: score}
+		if c.hasSeed && c.bestCF >= contentWeight*c.bestFeatureScore {
+			rec.Reason = m.bookReason(c.bestSeed, seeds[c.bestSeed].status)
+		} else if c.hasFeature {
+			rec.Reason = m.featureReason(c.bestFeature)
+		}
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].BookID.Hex() < recs[j].BookID.Hex()
+	})
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+
+	if len(recs) < limit {
+		picked := map[primitive.ObjectID]bool{}
+		for _, rec := range recs {
+			picked[rec.BookID] = true
+		}
+		for _, key := range m.Popular {
+			if len(recs) >= limit {
+				break
+			}
+			it := m.Items[key]
+			if _, marked := seeds[key]; marked || picked[it.Book] || it.ReviewCount == 0 {
+				continue
+			}
+			recs = append(recs, Recommendation{
+				BookID: it.Book,
+				Score:  0,
+				Reason: Reason{Type: ReasonPopular, Text: "Popular with readers"},
+			})
+		}
+	}
+	return recs
+}
+
+func (m *Model) bookReason(key primitive.ObjectID, status string) Reason {
+	it := m.Items[key]
+	reason := Reason{Type: ReasonBook, ID: it.Book.Hex(), Name: it.Title}
+	switch status {
+	case "now reading":
+		reason.Text = "Because you're reading " + it.Title
+	case "want to read":
+		reason.Text = "Because you want to read " + it.Title
+	case "":
+		reason.Text = "Because you rated " + it.Title
+	default:
+		reason.Text = "Because you read " + it.Title
+	}
+	return reason
+}
+
+func (m *Model) featureReason(f Feature) Reason {
+	name := m.Names[f.ID]
+	reason := Reason{Type: f.Kind, ID: f.ID.Hex(), Name: name}
+	switch f.Kind {
+	case ReasonAuthor:
+		reason.Text = "Because you read books by " + name
+	case ReasonTag:
+		reason.Text = "Because you like books tagged " + name
+	default:
+		reason.Text = "Because you like " + name
+	}
+	return reason
+}
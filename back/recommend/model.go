@@ -0,0 +1,348 @@
+// Package recommend สร้างคำแนะนำหนังสือเฉพาะบุคคลจากประวัติการอ่าน
+//
+// Build อ่าน marks ของผู้ใช้ทุกคนเพื่อหาหนังสือที่มักถูกอ่านคู่กัน (item-item collaborative filtering)
+// และเก็บ genre, tag, ผู้แต่งของหนังสือทุกเล่มไว้ในหน่วยความจำ งานนี้หนักจึงรันเป็นรอบด้วย RefreshEvery
+// ส่วน ForUser ใช้ model ล่าสุดคำนวณคะแนนของผู้ใช้หนึ่งคนตอน request ซึ่งเร็วพอ
+// รายการหนังสือคล้ายกันของทุกเล่ม (SimilarBooks) คำนวณไว้ล่วงหน้าใน Build
+//
+// หนังสือทุก edition ของ work เดียวกันถือเป็น item เดียว (key คือ workId หรือ _id ถ้าไม่มี work)
+package recommend
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxNeighbors คือจำนวนหนังสือคล้ายกันที่เก็บไว้ต่อ item
+	maxNeighbors = 50
+	// minCoReaders คือจำนวนผู้อ่านร่วมขั้นต่ำ คู่ที่มีคนอ่านร่วมคนเดียวมักเป็นเรื่องบังเอิญ
+	minCoReaders = 2
+	// maxItemsPerUser จำกัดจำนวน mark ต่อผู้ใช้ที่นำมาคิด (ล่าสุดก่อน) เพราะจำนวนคู่โตเป็นกำลังสอง
+	maxItemsPerUser = 300
+)
+
+// สถานะ mark ที่ถือว่าผู้ใช้สนใจหนังสือเล่มนั้น ("did not finish" ไม่นับ)
+var positiveStatuses = []string{"read", "now reading", "want to read"}
+
+// Feature คือ genre, tag, ผู้แต่ง, หมวด หรือซีรีส์ของหนังสือ
+type Feature struct {
+	Kind string // "genre", "tag", "author", "category" หรือ "series"
+	ID   primitive.ObjectID
+}
+
+// Neighbor คือ item ที่ถูกอ่านคู่กันพร้อมค่า cosine similarity
+type Neighbor struct {
+	Item  primitive.ObjectID
+	Score float64
+}
+
+// Item คือหนังสือหนึ่งงาน Book คือ edition ที่ใช้แสดงผล (edition ที่มีรีวิวมากที่สุด)
+type Item struct {
+	Book        primitive.ObjectID
+	Title       string
+	Features    []Feature
+	ReviewCount int
+	Popularity  float64 // Bayesian average ของทุก edition ใช้เรียงเมื่อผู้ใช้ยังไม่มีประวัติ
+}
+
+// Model คือผลของ Build ห้ามแก้หลังสร้างเสร็จเพราะหลาย request อ่านพร้อมกัน
+type Model struct {
+	Items     map[primitive.ObjectID]*Item
+	Neighbors map[primitive.ObjectID][]Neighbor
+	Names     map[primitive.ObjectID]string // ชื่อของ genre, tag, ผู้แต่ง, หมวด และซีรีส์ ใช้ในคำอธิบาย
+	Popular   []primitive.ObjectID          // item ทั้งหมดเรียงตาม Popularity
+	BuiltAt   time.Time
+
+	bookItem map[primitive.ObjectID]primitive.ObjectID
+	postings map[Feature][]primitive.ObjectID // item ทั้งหมดที่มี feature นั้น
+	similar  map[primitive.ObjectID][]similar // ดู similar.go
+}
+
+// ItemOf คืน key ของ item ที่หนังสือเล่มนี้อยู่
+func (m *Model) ItemOf(bookID primitive.ObjectID) (primitive.ObjectID, bool) {
+	key, ok := m.bookItem[bookID]
+	return key, ok
+}
+
+var current atomic.Pointer[Model]
+
+// Current คืน model ล่าสุด หรือ nil ถ้ายัง build ครั้งแรกไม่เสร็จ
+func Current() *Model {
+	return current.Load()
+}
+
+// Build สร้าง model ใหม่จากฐานข้อมูล
+func Build(ctx context.Context) (*Model, error) {
+	db := config.DB.Database("bookwarm")
+	m := &Model{
+		Items:    map[primitive.ObjectID]*Item{},
+		Names:    map[primitive.ObjectID]string{},
+		bookItem: map[primitive.ObjectID]primitive.ObjectID{},
+		postings: map[Feature][]primitive.ObjectID{},
+		similar:  map[primitive.ObjectID][]similar{},
+		BuiltAt:  time.Now(),
+	}
+
+	cursor, err := db.Collection("books").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+		"title": 1, "workId": 1, "authorId": 1, "contributors": 1, "genres": 1, "tagIds": 1,
+		"category_id": 1, "seriesId": 1, "review_count": 1, "rating_sum": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var books []models.Book
+	if err := cursor.All(ctx, &books); err != nil {
+		return nil, err
+	}
+	ratingSums := map[primitive.ObjectID]int{}
+	totalSum, totalCount := 0, 0
+	for _, book := range books {
+		key := book.ID
+		if book.WorkID != nil {
+			key = *book.WorkID
+		}
+		m.bookItem[book.ID] = key
+		it := m.Items[key]
+		if it == nil {
+			it = &Item{Book: book.ID, Title: book.Title}
+			m.Items[key] = it
+		} else if book.ReviewCount > it.ReviewCount {
+			it.Book, it.Title = book.ID, book.Title
+		}
+		it.ReviewCount += book.ReviewCount
+		ratingSums[key] += book.RatingSum
+		totalSum += book.RatingSum
+		totalCount += book.ReviewCount
+		it.Features = mergeFeatures(it.Features, bookFeatures(book))
+	}
+
+	// ใช้สูตรเดียวกับ GET /api/books/recommended
+	priorMean, ok := config.RecommendPriorMean()
+	if !ok {
+		priorMean = 3
+		if totalCount > 0 {
+			priorMean = float64(totalSum) / float64(totalCount)
+		}
+	}
+	priorWeight := config.RecommendPriorWeight()
+	for key, it := range m.Items {
+		if denominator := float64(it.ReviewCount) + priorWeight; denominator > 0 {
+			it.Popularity = (float64(ratingSums[key]) + priorWeight*priorMean) / denominator
+		}
+		m.Popular = append(m.Popular, key)
+	}
+	sort.Slice(m.Popular, func(i, j int) bool {
+		a, b := m.Items[m.Popular[i]], m.Items[m.Popular[j]]
+		if a.Popularity != b.Popularity {
+			return a.Popularity > b.Popularity
+		}
+		if a.ReviewCount != b.ReviewCount {
+			return a.ReviewCount > b.ReviewCount
+		}
+		return a.Book.Hex() < b.Book.Hex()
+	})
+
+	for key, it := range m.Items {
+		for _, f := range it.Features {
+			m.postings[f] = append(m.postings[f], key)
+		}
+	}
+	for _, collection := range []string{"genre", "tag", "author", "category", "series"} {
+		if err := m.loadNames(ctx, collection); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.Neighbors, err = m.coReaders(ctx, positiveStatuses); err != nil {
+		return nil, err
+	}
+	// "readers also enjoyed" นับเฉพาะคนที่อ่านจบแล้ว
+	coRead, err := m.coReaders(ctx, []string{"read"})
+	if err != nil {
+		return nil, err
+	}
+	m.buildSimilar(coRead)
+	return m, nil
+}
+
+func (m *Model) loadNames(ctx context.Context, collection string) error {
+	cursor, err := config.DB.Database("bookwarm").Collection(collection).Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return err
+	}
+	var docs []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		m.Names[doc.ID] = doc.Name
+	}
+	return nil
+}
+
+// bookFeatures คืน genre, tag, ผู้แต่ง (author กับ co-author), หมวด และซีรีส์ของหนังสือ
+func bookFeatures(book models.Book) []Feature {
+	var features []Feature
+	for _, id := range book.Genres {
+		features = append(features, Feature{Kind: "genre", ID: id})
+	}
+	for _, id := range book.TagIDs {
+		features = append(features, Feature{Kind: "tag", ID: id})
+	}
+	if !book.AuthorID.IsZero() {
+		features = append(features, Feature{Kind: "author", ID: book.AuthorID})
+	}
+	for _, contributor := range book.Contributors {
+		if contributor.Role == models.RoleAuthor || contributor.Role == models.RoleCoAuthor {
+			features = append(features, Feature{Kind: "author", ID: contributor.AuthorID})
+		}
+	}
+	if !book.CategoryID.IsZero() {
+		features = append(features, Feature{Kind: "category", ID: book.CategoryID})
+	}
+	if book.SeriesID != nil {
+		features = append(features, Feature{Kind: "series", ID: *book.SeriesID})
+	}
+	return features
+}
+
+func mergeFeatures(existing, more []Feature) []Feature {
+	seen := map[Feature]bool{}
+	for _, f := range existing {
+		seen[f] = true
+	}
+	for _, f := range more {
+		if !seen[f] {
+			seen[f] = true
+			existing = append(existing, f)
+		}
+	}
+	return existing
+}
+
+// coReaders นับจำนวนผู้อ่านร่วมของทุกคู่ item จาก marks ที่มีสถานะใน statuses
+// แล้วคืนคู่ที่ cosine สูงสุดของแต่ละ item
+//
+//	similarity(a, b) = ผู้อ่านร่วม / sqrt(ผู้อ่าน a * ผู้อ่าน b)
+func (m *Model) coReaders(ctx context.Context, statuses []string) (map[primitive.ObjectID][]Neighbor, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection("marks").Find(ctx,
+		bson.M{"status": bson.M{"$in": statuses}},
+		options.Find().
+			SetProjection(bson.M{"user_id": 1, "book_id": 1, "work_id": 1}).
+			SetSort(bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	readers := map[primitive.ObjectID]int{}
+	co := map[primitive.ObjectID]map[primitive.ObjectID]int{}
+	var user primitive.ObjectID
+	var items []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	flush := func() {
+		for i, a := range items {
+			readers[a]++
+			for _, b := range items[i+1:] {
+				if co[a] == nil {
+					co[a] = map[primitive.ObjectID]int{}
+				}
+				if co[b] == nil {
+					co[b] = map[primitive.ObjectID]int{}
+				}
+				co[a][b]++
+				co[b][a]++
+			}
+		}
+		items = items[:0]
+		seen = map[primitive.ObjectID]bool{}
+	}
+	for cursor.Next(ctx) {
+		var mark models.Mark
+		if err := cursor.Decode(&mark); err != nil {
+			return nil, err
+		}
+		if mark.UserID != user {
+			flush()
+			user = mark.UserID
+		}
+		key, ok := m.bookItem[mark.BookID]
+		if !ok {
+			// หนังสือถูกลบไปแล้ว
+			continue
+		}
+		if !seen[key] && len(items) < maxItemsPerUser {
+			seen[key] = true
+			items = append(items, key)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	result := map[primitive.ObjectID][]Neighbor{}
+	for a, counts := range co {
+		var neighbors []Neighbor
+		for b, n := range counts {
+			if n < minCoReaders {
+				continue
+			}
+			neighbors = append(neighbors, Neighbor{Item: b, Score: float64(n) / math.Sqrt(float64(readers[a]*readers[b]))})
+		}
+		sort.Slice(neighbors, func(i, j int) bool {
+			if neighbors[i].Score != neighbors[j].Score {
+				return neighbors[i].Score > neighbors[j].Score
+			}
+			return neighbors[i].Item.Hex() < neighbors[j].Item.Hex()
+		})
+		if len(neighbors) > maxNeighbors {
+			neighbors = neighbors[:maxNeighbors]
+		}
+		if len(neighbors) > 0 {
+			result[a] = neighbors
+		}
+	}
+	return result, nil
+}
+
+// Refresh build model ใหม่แล้วแทนที่ model ที่ใช้อยู่
+func Refresh(ctx context.Context) error {
+	m, err := Build(ctx)
+	if err != nil {
+		return err
+	}
+	current.Store(m)
+	return nil
+}
+
+// RefreshEvery build model ใหม่ทุก interval จนกว่า ctx จะถูกยกเลิก
+func RefreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := Refresh(ctx); err != nil {
+			log.Printf("recommend: failed to build model: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package recommend
+
+import (
+	"back/models"
+	"math"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// น้ำหนักของ feature ที่ตรงกันเมื่อหาหนังสือคล้ายกัน ซีรีส์และผู้แต่งเดียวกันบอกได้มากกว่า genre
+var similarWeights = map[string]float64{"series": 3, "author": 2, "genre": 1, "tag": 0.5, "category": 0.5}
+
+// ลำดับของเหตุผลในผลลัพธ์ ตรงกับน้ำหนักใน similarWeights
+var similarOrder = []string{"series", "author", "genre", "tag", "category"}
+
+const (
+	// maxSimilar คือจำนวนหนังสือคล้ายกันที่เก็บไว้ต่อ item
+	maxSimilar = 50
+	// maxPosting คือจำนวนหนังสือสูงสุดของ feature ที่ใช้หาผู้สมัคร feature ที่ใหญ่กว่านี้ (เช่น หมวดใหญ่)
+	// ยังนับคะแนนอยู่ แต่ไม่ทำให้ต้องเทียบกับหนังสือทั้งหมวด
+	maxPosting = 500
+	// coReadWeight คือสัดส่วนของคะแนนผู้อ่านร่วมเทียบกับคะแนน feature
+	coReadWeight = 0.6
+)
+
+// ReasonReaders คือเหตุผลเมื่อผู้อ่านหนังสือต้นทางอ่านเล่มนี้ด้วย
+const ReasonReaders = "readers"
+
+// similar คือ item ที่คล้ายกัน พร้อมคะแนนผู้อ่านร่วมเพื่อใช้อธิบายผล
+type similar struct {
+	item   primitive.ObjectID
+	score  float64
+	coRead float64
+}
+
+// SimilarBook คือหนังสือที่คล้ายกันหนึ่งเล่ม BookID คือ edition ที่ใช้แสดงผล
+type SimilarBook struct {
+	BookID  primitive.ObjectID
+	Score   float64
+	Reasons []Reason
+}
+
+func featureNorm(features []Feature) float64 {
+	sum := 0.0
+	for _, f := range features {
+		w := similarWeights[f.Kind]
+		sum += w * w
+	}
+	return math.Sqrt(sum)
+}
+
+// contentSimilarity คือ cosine แบบถ่วงน้ำหนักของ feature สองชุด มีค่า 0 ถึง 1
+func contentSimilarity(a, b []Feature) float64 {
+	normA, normB := featureNorm(a), featureNorm(b)
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	inB := map[Feature]bool{}
+	for _, f := range b {
+		inB[f] = true
+	}
+	shared := 0.0
+	for _, f := range a {
+		if inB[f] {
+			w := similarWeights[f.Kind]
+			shared += w * w
+		}
+	}
+	return shared / (normA * normB)
+}
+
+// similarTo จัดอันดับ item ที่คล้ายกับ item key (ซึ่งมี features และผู้อ่านร่วม coRead)
+//
+//	score = coReadWeight × cosine ของผู้อ่านร่วม + (1 - coReadWeight) × contentSimilarity
+func (m *Model) similarTo(key primitive.ObjectID, features []Feature, coRead []Neighbor) []similar {
+	candidates := map[primitive.ObjectID]float64{}
+	for _, n := range coRead {
+		candidates[n.Item] = n.Score
+	}
+	for _, f := range features {
+		if posting := m.postings[f]; len(posting) <= maxPosting {
+			for _, other := range posting {
+				if _, ok := candidates[other]; !ok {
+					candidates[other] = 0
+				}
+			}
+		}
+	}
+	delete(candidates, key)
+
+	var out []similar
+	for other, co := range candidates {
+		score := coReadWeight*co + (1-coReadWeight)*contentSimilarity(features, m.Items[other].Features)
+		if score > 0 {
+			out = append(out, similar{item: other, score: score, coRead: co})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].score != out[j].score {
+			return out[i].score > out[j].score
+		}
+		if a, b := m.Items[out[i].item].ReviewCount, m.Items[out[j].item].ReviewCount; a != b {
+			return a > b
+		}
+		return out[i].item.Hex() < out[j].item.Hex()
+	})
+	if len(out) > maxSimilar {
+		out = out[:maxSimilar]
+	}
+	return out
+}
+
+func (m *Model) buildSimilar(coRead map[primitive.ObjectID][]Neighbor) {
+	for key, it := range m.Items {
+		if list := m.similarTo(key, it.Features, coRead[key]); len(list) > 0 {
+			m.similar[key] = list
+		}
+	}
+}
+
+// SimilarBooks คืนหนังสือที่คล้ายกับ book ไม่เกิน limit เล่ม ใช้รายการที่คำนวณไว้ตอน Build
+// หนังสือที่เพิ่มหลัง Build จะคำนวณจาก feature อย่างเดียวเพราะยังไม่มีข้อมูลผู้อ่าน
+func (m *Model) SimilarBooks(book models.Book, limit int) []SimilarBook {
+	features := bookFeatures(book)
+	key, ok := m.bookItem[book.ID]
+	list := m.similar[key]
+	if ok {
+		features = m.Items[key].Features
+	} else {
+		key = book.ID
+		if book.WorkID != nil {
+			key = *book.WorkID
+		}
+		list = m.similarTo(key, features, nil)
+	}
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	out := make([]SimilarBook, 0, len(list))
+	for _, s := range list {
+		out = append(out, SimilarBook{
+			BookID:  m.Items[s.item].Book,
+			Score:   s.score,
+			Reasons: m.similarReasons(features, m.Items[s.item].Features, s.coRead),
+		})
+	}
+	return out
+}
+
+// similarReasons อธิบายสิ่งที่หนังสือสองเล่มมีเหมือนกัน เรียงจากเหตุผลที่หนักที่สุด
+func (m *Model) similarReasons(a, b []Feature, coRead float64) []Reason {
+	inB := map[Feature]bool{}
+	for _, f := range b {
+		inB[f] = true
+	}
+	reasons := []Reason{}
+	if coRead > 0 {
+		reasons = append(reasons, Reason{Type: ReasonReaders, Text: "Readers of this book also read it"})
+	}
+	for _, kind := range similarOrder {
+		for _, f := range a {
+			if f.Kind != kind || !inB[f] {
+				continue
+			}
+			name := m.Names[f.ID]
+			reason := Reason{Type: kind, ID: f.ID.Hex(), Name: name}
+			switch kind {
+			case "series":
+				reason.Text = "Same series: " + name
+			case ReasonAuthor:
+				reason.Text = "Also by " + name
+			case ReasonTag:
+				reason.Text = "Also tagged " + name
+			default:
+				reason.Text = "Also in " + name
+			}
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"back/controllers"
+	"back/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(router *gin.Engine) {
+	admin := router.Group("/api/admin")
+	admin.Use(middleware.JWTAuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.POST("/catalog/import", controllers.ImportCatalog)
+		admin.GET("/catalog/import", controllers.ListImportJobs)
+		admin.GET("/catalog/import/:id", controllers.GetImportJob)
+		admin.GET("/catalog/export", controllers.ExportCatalog)
+		admin.POST("/books/:id/revert/:revision", controllers.RevertBook)
+		admin.GET("/submissions", controllers.ListSubmissions)
+		admin.POST("/submissions/:id/approve", controllers.ApproveSubmission)
+		admin.POST("/submissions/:id/merge", controllers.MergeSubmission)
+		admin.POST("/submissions/:id/reject", controllers.RejectSubmission)
+		admin.POST("/authors/:id/merge", controllers.MergeAuthor)
+		admin.POST("/tags/:id/merge", controllers.MergeTag)
+		admin.POST("/genres/:id/merge", controllers.MergeGenre)
+		admin.POST("/categories/:id/merge", controllers.MergeCategory)
+		admin.GET("/audit", controllers.GetAuditLog)
+		admin.POST("/search/reindex", controllers.RebuildSearchIndex)
+	}
+}
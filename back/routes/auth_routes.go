@@ -15,6 +15,11 @@ func AuthRoutes(router *gin.Engine) {
 		auth.GET("/profile", middleware.JWTAuthMiddleware(), controllers.Profile) //ตอน test อย่าลืมใส่ token header
 		auth.PUT("/profile", middleware.JWTAuthMiddleware(), controllers.UpdateProfile)
 		auth.GET("/me", middleware.JWTAuthMiddleware(), controllers.GetMe)
+
+		// Social login ผ่าน OpenID Connect (authorization code + PKCE)
+		auth.GET("/oidc/providers", controllers.GetOIDCProviders)
+		auth.GET("/oidc/:provider/login", controllers.OIDCLogin)
+		auth.GET("/oidc/:provider/callback", controllers.OIDCCallback)
 	}
 
 	// Add new route for getting other users' profiles
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"back/controllers"
+	"back/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,8 +11,11 @@ func AuthorRoutes(router *gin.Engine){
 	authors := router.Group("/api/authors")
 	{
 		authors.GET("/", controllers.GetAllAuthor)
+		authors.GET("/search", controllers.SearchAuthors)
+		authors.GET("/:id", controllers.GetAuthorByID)
 		authors.POST("/",controllers.CreateAuthor)
 		authors.PUT("/:id", controllers.UpdateAuthor)
 		authors.DELETE("/:id", controllers.DeleteAuthor)
+		authors.POST("/:id/photo", middleware.JWTAuthMiddleware(), controllers.UploadAuthorPhoto)
 	}
 }
\ No newline at end of file
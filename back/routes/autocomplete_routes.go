@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"back/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AutocompleteRoutes(router *gin.Engine) {
+	router.GET("/api/autocomplete", controllers.Autocomplete)
+}
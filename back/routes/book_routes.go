@@ -12,13 +12,23 @@ func BookRoutes(router *gin.Engine) {
 	{
 		// Public routes - ทุกคนเข้าได้ (ไม่ต้อง auth)
 		book.GET("/", controllers.GetAllBooks) 
-		book.GET("/:id", controllers.GetBookByID) 
-		book.GET("/recommended", controllers.GetRecommendedBooks) 
+		book.GET("/:id", middleware.OptionalJWTAuthMiddleware(), controllers.GetBookByID) 
+		book.GET("/recommended", middleware.OptionalJWTAuthMiddleware(), controllers.GetRecommendedBooks) 
+		book.GET("/trending", controllers.GetTrendingBooks)
+		book.GET("/for-me", middleware.JWTAuthMiddleware(), controllers.GetBooksForMe)
+		book.GET("/:id/similar", controllers.GetSimilarBooks)
+		book.GET("/:id/history", controllers.GetBookHistory)
+		book.GET("/submissions", middleware.JWTAuthMiddleware(), controllers.GetMySubmissions)
+		book.POST("/submissions", middleware.JWTAuthMiddleware(), controllers.SubmitBook)
 		book.GET("/search", controllers.SearchBooks) 
+		book.GET("/isbn/:isbn", controllers.GetBookByISBN)
+		book.GET("/duplicates", controllers.CheckDuplicateBooks)
 		
 		// Protected routes - ต้อง login
 		book.Use(middleware.JWTAuthMiddleware()).POST("/", controllers.CreateBook)
 		book.Use(middleware.JWTAuthMiddleware()).PUT("/:id", controllers.UpdateBook)
+		book.Use(middleware.JWTAuthMiddleware()).PATCH("/:id", controllers.PatchBook)
 		book.Use(middleware.JWTAuthMiddleware()).DELETE("/:id", controllers.DeleteBook)
+		book.Use(middleware.JWTAuthMiddleware()).POST("/:id/cover", controllers.UploadBookCover)
 	}
 }
\ No newline at end of file
@@ -10,6 +10,8 @@ func CategoryRoutes(router *gin.Engine){
 	category := router.Group("/api/categories")
 	{
 		category.GET("/", controllers.GetAllCategory)
+		category.GET("/tree", controllers.GetCategoryTree)
+		category.GET("/path/*path", controllers.GetCategoryByPath)
 		category.POST("/",controllers.CreateCategory)
 		category.PUT("/:id", controllers.UpdateCategory)
 		category.DELETE("/:id", controllers.DeleteCategory)
@@ -14,6 +14,7 @@ func ClubRoutes(router *gin.Engine){
 		club.GET("/", controllers.GetAllClubs) // ดูคลับทั้งหมด
 		club.GET("/:id", controllers.GetClubByID) // ดูคลับตาม ID
 		club.GET("/recommended", controllers.GetRecommendedClubs) // ดูคลับแนะนำ
+		club.GET("/trending", controllers.GetTrendingClubs) // ดูคลับที่มาแรง
 		
 		// Protected routes - ต้อง login และเป็นสมาชิก
 		club.Use(middleware.JWTAuthMiddleware()).POST("/", controllers.CreateClub)
@@ -8,6 +8,8 @@ func GenreRoutes(router *gin.Engine){
 	genres := router.Group("/api/genres")
 	{
 		genres.GET("/", controllers.GetAllGenre)
+		genres.GET("/tree", controllers.GetGenreTree)
+		genres.GET("/path/*path", controllers.GetGenreByPath)
 		genres.POST("/",controllers.CreateGenre)
 		genres.PUT("/:id", controllers.UpdateGenre)
 		genres.DELETE("/:id", controllers.DeleteGenre)
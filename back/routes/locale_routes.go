@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"back/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LocaleRoutes(router *gin.Engine) {
+	router.GET("/api/locales", controllers.GetLocales)
+}
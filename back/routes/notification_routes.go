@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"back/controllers"
+	"back/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NotificationRoutes(router *gin.Engine) {
+	notification := router.Group("/api/notifications")
+	notification.Use(middleware.JWTAuthMiddleware())
+	{
+		notification.GET("/", controllers.GetNotifications)
+		notification.POST("/read-all", controllers.MarkAllNotificationsRead)
+		notification.POST("/:id/read", controllers.MarkNotificationRead)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"back/controllers"
+	"back/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SeriesRoutes(router *gin.Engine) {
+	series := router.Group("/api/series")
+	{
+		series.GET("/", controllers.GetAllSeries)
+		series.GET("/:id", controllers.GetSeriesByID)
+
+		series.POST("/", middleware.JWTAuthMiddleware(), controllers.CreateSeries)
+		series.PUT("/:id", middleware.JWTAuthMiddleware(), controllers.UpdateSeries)
+		series.DELETE("/:id", middleware.JWTAuthMiddleware(), controllers.DeleteSeries)
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"back/controllers"
+	"back/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WorkRoutes(router *gin.Engine) {
+	works := router.Group("/api/works")
+	{
+		works.GET("/", controllers.GetAllWorks)
+		works.GET("/:id", controllers.GetWorkByID)
+
+		works.POST("/", middleware.JWTAuthMiddleware(), controllers.CreateWork)
+		works.PUT("/:id", middleware.JWTAuthMiddleware(), controllers.UpdateWork)
+		works.DELETE("/:id", middleware.JWTAuthMiddleware(), controllers.DeleteWork)
+		works.POST("/:id/editions", middleware.JWTAuthMiddleware(), controllers.AddEdition)
+		works.DELETE("/:id/editions/:bookId", middleware.JWTAuthMiddleware(), controllers.RemoveEdition)
+	}
+}
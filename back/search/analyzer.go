@@ -0,0 +1,210 @@
+package search
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/go-porterstemmer"
+)
+
+//go:embed thai_words.txt
+var thaiWordList string
+
+// thaiStopwords ยังใช้ตัดคำอยู่ แต่ไม่นำมาเป็น term ในดัชนี
+var thaiStopwords = map[string]bool{
+	"กับ": true, "และ": true, "หรือ": true, "ของ": true, "ใน": true, "บน": true,
+	"ที่": true, "ไป": true, "มา": true, "จาก": true, "ถึง": true, "เพื่อ": true,
+	"แห่ง": true, "ว่า": true, "ได้": true, "มี": true, "เป็น": true, "อยู่": true,
+	"คือ": true, "จะ": true, "ให้": true, "ก็": true, "แต่": true, "นี้": true, "นั้น": true,
+}
+
+var englishStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	word     bool
+}
+
+var thaiDict = buildThaiDict(thaiWordList)
+
+func buildThaiDict(list string) *trieNode {
+	root := &trieNode{children: map[rune]*trieNode{}}
+	for _, line := range strings.Split(list, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		node := root
+		for _, r := range word {
+			next, ok := node.children[r]
+			if !ok {
+				next = &trieNode{children: map[rune]*trieNode{}}
+				node.children[r] = next
+			}
+			node = next
+		}
+		node.word = true
+	}
+	return root
+}
+
+func isThai(r rune) bool {
+	return r >= 0x0E01 && r <= 0x0E5B
+}
+
+// isThaiCombining คือสระบน/ล่างและวรรณยุกต์ที่ต้องอยู่ติดกับพยัญชนะตัวก่อนหน้า
+func isThaiCombining(r rune) bool {
+	return r == 0x0E31 || (r >= 0x0E34 && r <= 0x0E3A) || (r >= 0x0E47 && r <= 0x0E4E)
+}
+
+// isThaiLeadingVowel คือ เ แ โ ใ ไ ซึ่งเขียนก่อนพยัญชนะ
+func isThaiLeadingVowel(r rune) bool {
+	return r >= 0x0E40 && r <= 0x0E44
+}
+
+// thaiClusters แบ่งข้อความไทยเป็นกลุ่มอักขระที่แยกจากกันไม่ได้ (สระนำ + พยัญชนะ + เครื่องหมาย)
+func thaiClusters(run []rune) []string {
+	var clusters []string
+	for i := 0; i < len(run); {
+		j := i
+		for j < len(run) && isThaiLeadingVowel(run[j]) {
+			j++
+		}
+		if j < len(run) {
+			j++
+		}
+		for j < len(run) && isThaiCombining(run[j]) {
+			j++
+		}
+		clusters = append(clusters, string(run[i:j]))
+		i = j
+	}
+	return clusters
+}
+
+// segmentThai ตัดคำแบบ longest matching จากพจนานุกรม ช่วงที่ไม่รู้จักจะถูกข้ามไป
+// (ยังค้นเจอได้ผ่าน bigram) และจะไม่ตัดคำกลางกลุ่มอักขระ
+func segmentThai(run []rune) []string {
+	boundary := map[int]bool{0: true, len(run): true}
+	pos := 0
+	for _, cluster := range thaiClusters(run) {
+		pos += len([]rune(cluster))
+		boundary[pos] = true
+	}
+
+	var words []string
+	for i := 0; i < len(run); {
+		end := -1
+		node := thaiDict
+		for j := i; j < len(run); j++ {
+			next, ok := node.children[run[j]]
+			if !ok {
+				break
+			}
+			node = next
+			if node.word && boundary[j+1] {
+				end = j + 1
+			}
+		}
+		if end < 0 {
+			i++
+			for i < len(run) && !boundary[i] {
+				i++
+			}
+			continue
+		}
+		words = append(words, string(run[i:end]))
+		i = end
+	}
+	return words
+}
+
+func thaiBigrams(run []rune) []string {
+	clusters := thaiClusters(run)
+	if len(clusters) == 1 {
+		return clusters
+	}
+	bigrams := make([]string, 0, len(clusters)-1)
+	for i := 0; i+1 < len(clusters); i++ {
+		bigrams = append(bigrams, clusters[i]+clusters[i+1])
+	}
+	return bigrams
+}
+
+// token คือ term หนึ่งตัวที่ได้จากการวิเคราะห์ข้อความ
+// gram เป็น true สำหรับ bigram ของภาษาไทย ซึ่งใช้รับประกันว่าค้นคำย่อยเจอ
+type token struct {
+	term string
+	gram bool
+}
+
+func analyze(text string) []token {
+	var tokens []token
+	runes := []rune(strings.ToLower(text))
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isThai(r):
+			j := i
+			for j < len(runes) && isThai(runes[j]) {
+				j++
+			}
+			run := runes[i:j]
+			for _, word := range segmentThai(run) {
+				if !thaiStopwords[word] {
+					tokens = append(tokens, token{term: word})
+				}
+			}
+			for _, gram := range thaiBigrams(run) {
+				tokens = append(tokens, token{term: "~" + gram, gram: true})
+			}
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && !isThai(runes[j]) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			word := string(runes[i:j])
+			if !englishStopwords[word] {
+				tokens = append(tokens, token{term: porterstemmer.StemString(word)})
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// Terms คืน term ทั้งหมดของข้อความตามลำดับที่พบ (ใช้ทั้งตอนสร้างดัชนีและตอนค้นหา)
+func Terms(text string) []string {
+	tokens := analyze(text)
+	terms := make([]string, len(tokens))
+	for i, t := range tokens {
+		terms[i] = t.term
+	}
+	return terms
+}
+
+// queryTerms แยก term ที่ต้องมีในเอกสาร (คำภาษาอังกฤษ และ bigram ภาษาไทย)
+// ออกจาก term ที่ใช้เพิ่มคะแนนอย่างเดียว (คำไทยจากพจนานุกรม)
+func queryTerms(text string) (required, boost []string) {
+	seen := map[string]bool{}
+	for _, t := range analyze(text) {
+		if seen[t.term] {
+			continue
+		}
+		seen[t.term] = true
+		if !t.gram && isThai([]rune(t.term)[0]) {
+			boost = append(boost, t.term)
+		} else {
+			required = append(required, t.term)
+		}
+	}
+	return required, boost
+}
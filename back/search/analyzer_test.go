@@ -0,0 +1,47 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegmentThai(t *testing.T) {
+	cases := map[string][]string{
+		"ผมอ่านหนังสือ": {"ผม", "อ่าน", "หนังสือ"},
+		"นักเขียน":      {"นักเขียน"},   // คำที่ยาวที่สุดชนะ "นัก" + "เขียน"
+		"ผมฮฮฮอ่าน":     {"ผม", "อ่าน"}, // ช่วงที่ไม่รู้จักถูกข้าม
+		"แมวเ":          {"แมว"},        // ไม่ตัดกลางกลุ่มอักขระ
+	}
+	for text, want := range cases {
+		if got := segmentThai([]rune(text)); !reflect.DeepEqual(got, want) {
+			t.Errorf("segmentThai(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestTermsStemsEnglishAndDropsStopwords(t *testing.T) {
+	if got, want := Terms("The Running Dogs of War"), []string{"run", "dog", "war"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Terms = %q, want %q", got, want)
+	}
+}
+
+// ภาษาไทยเขียนติดกัน ค้นคำในพจนานุกรมหรือคำที่ไม่รู้จักที่อยู่กลางประโยคต้องเจอ
+func TestSearchFindsThaiWordsInsideText(t *testing.T) {
+	idx := New("")
+	idx.Put(TypeBook, "1", Field{Text: "ผมอ่านหนังสือเกี่ยวกับแมวในบ้าน", Weight: 3})
+	idx.Put(TypeBook, "2", Field{Text: "นักเขียนหนุ่ม", Weight: 3})
+
+	for _, query := range []string{"หนังสือ", "แมว", "เกี่ยวกับ", "นักเขียน"} {
+		hits, ok := idx.Search(TypeBook, query, 10)
+		if !ok || len(hits) == 0 {
+			t.Errorf("Search(%q) found nothing", query)
+		}
+	}
+	if hits, _ := idx.Search(TypeBook, "นักเขียน", 10); len(hits) != 1 || hits[0].ID != "2" {
+		t.Errorf("Search(นักเขียน) = %+v", hits)
+	}
+	// stopword อย่างเดียวไม่ใช่คำค้นที่ใช้ได้
+	if _, ok := idx.Search(TypeBook, "the", 10); ok {
+		t.Error("a stopword-only query was searchable")
+	}
+}
@@ -0,0 +1,331 @@
+package search
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ความยาว prefix สูงสุดที่เก็บใน edge n-gram (คำค้นที่ยาวกว่านี้จะตรวจกับ key เต็มอีกครั้ง)
+const maxGram = 12
+
+// Suggestion คือผลลัพธ์หนึ่งรายการของ autocomplete
+type Suggestion struct {
+	Type  string  `json:"type"`
+	ID    string  `json:"id"`
+	Label string  `json:"label"`
+	Image string  `json:"image,omitempty"`
+	Score float64 `json:"score"`
+	// Labels คือป้ายชื่อภาษาอื่นตาม locale พิมพ์ภาษาใดก็เจอ และ client เลือกแสดงตามภาษาได้
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type completion struct {
+	suggestion Suggestion
+	keys       []string
+}
+
+// Completer คือดัชนี autocomplete ในหน่วยความจำ จับคู่จาก prefix ของคำ (edge n-gram)
+// และยอมให้พิมพ์ผิดได้เล็กน้อยเมื่อไม่เจอด้วย prefix ตรง ๆ
+type Completer struct {
+	mu      sync.RWMutex
+	entries map[string]*completion
+	grams   map[string]map[string]bool
+	vocab   map[string]map[string]bool
+}
+
+// Suggestions คือ Completer ที่ server ใช้
+var Suggestions = NewCompleter()
+
+func NewCompleter() *Completer {
+	return &Completer{
+		entries: map[string]*completion{},
+		grams:   map[string]map[string]bool{},
+		vocab:   map[string]map[string]bool{},
+	}
+}
+
+// completionKeys คือคำที่ใช้จับคู่ของป้ายชื่อ: ทุกคำที่คั่นด้วยช่องว่าง
+// และทั้งป้ายชื่อแบบไม่มีช่องว่าง (สำหรับพิมพ์ต่อเนื่องตั้งแต่ต้นชื่อ)
+func completionKeys(label string) []string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	seen := map[string]bool{}
+	var keys []string
+	add := func(key string) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	add(strings.Join(strings.Fields(label), ""))
+	for _, word := range strings.FieldsFunc(label, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r)
+	}) {
+		add(word)
+		runes := []rune(word)
+		if len(runes) > 0 && isThai(runes[0]) {
+			// ภาษาไทยไม่เว้นวรรคระหว่างคำ จึงเก็บข้อความตั้งแต่ต้นคำที่ตัดได้แต่ละคำไปจนจบ
+			// เพื่อให้พิมพ์เริ่มจากคำกลางชื่อแล้วยังเจอ
+			rest := word
+			for _, thaiWord := range segmentThai(runes) {
+				if i := strings.Index(rest, thaiWord); i >= 0 {
+					rest = rest[i:]
+					add(rest)
+					rest = rest[len(thaiWord):]
+				}
+			}
+		}
+	}
+	return keys
+}
+
+func entryKey(docType, id string) string {
+	return docType + ":" + id
+}
+
+func prefixes(key string) []string {
+	runes := []rune(key)
+	n := len(runes)
+	if n > maxGram {
+		n = maxGram
+	}
+	out := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, string(runes[:i]))
+	}
+	return out
+}
+
+// Upsert เพิ่มหรือแทนที่รายการ
+func (c *Completer) Upsert(s Suggestion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upsertLocked(s)
+}
+
+func (c *Completer) upsertLocked(s Suggestion) {
+	key := entryKey(s.Type, s.ID)
+	c.removeLocked(key)
+	keys := completionKeys(s.Label)
+	for _, label := range s.Labels {
+		for _, k := range completionKeys(label) {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	entry := &completion{suggestion: s, keys: keys}
+	c.entries[key] = entry
+	for _, k := range entry.keys {
+		owners, ok := c.vocab[k]
+		if !ok {
+			owners = map[string]bool{}
+			c.vocab[k] = owners
+		}
+		owners[key] = true
+		for _, gram := range prefixes(k) {
+			set, ok := c.grams[gram]
+			if !ok {
+				set = map[string]bool{}
+				c.grams[gram] = set
+			}
+			set[key] = true
+		}
+	}
+}
+
+// Delete ลบรายการออก
+func (c *Completer) Delete(docType, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(entryKey(docType, id))
+}
+
+func (c *Completer) removeLocked(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	for _, k := range entry.keys {
+		delete(c.vocab[k], key)
+		if len(c.vocab[k]) == 0 {
+			delete(c.vocab, k)
+		}
+		for _, gram := range prefixes(k) {
+			delete(c.grams[gram], key)
+			if len(c.grams[gram]) == 0 {
+				delete(c.grams, gram)
+			}
+		}
+	}
+	delete(c.entries, key)
+}
+
+// Reset แทนที่รายการทั้งหมด ใช้ตอน refresh จากฐานข้อมูล
+func (c *Completer) Reset(suggestions []Suggestion) {
+	fresh := NewCompleter()
+	for _, s := range suggestions {
+		fresh.upsertLocked(s)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = fresh.entries
+	c.grams = fresh.grams
+	c.vocab = fresh.vocab
+}
+
+// maxTypos คือจำนวนตัวอักษรที่พิมพ์ผิดได้ตามความยาวคำค้น
+func maxTypos(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// prefixDistance คือ edit distance (นับการสลับตัวอักษรติดกันเป็น 1 ครั้ง) ที่น้อยที่สุด
+// ระหว่าง q กับ prefix ใด ๆ ของ key หยุดคำนวณทันทีเมื่อเกิน limit
+func prefixDistance(q, key []rune, limit int) int {
+	before := make([]int, len(key)+1)
+	prev := make([]int, len(key)+1)
+	curr := make([]int, len(key)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(q); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(key); j++ {
+			cost := 1
+			if q[i-1] == key[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && q[i-1] == key[j-2] && q[i-2] == key[j-1] {
+				curr[j] = min(curr[j], before[j-2]+1)
+			}
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		before, prev, curr = prev, curr, before
+	}
+	best := prev[0]
+	for _, d := range prev {
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// matchToken คืนคะแนนของรายการที่ตรงกับคำค้นหนึ่งคำ: 3 = ตรงทั้งคำ, 2 = prefix, 1 = พิมพ์ผิดเล็กน้อย
+func (c *Completer) matchToken(token string) map[string]float64 {
+	matches := map[string]float64{}
+	runes := []rune(token)
+	gram := token
+	if len(runes) > maxGram {
+		gram = string(runes[:maxGram])
+	}
+	for key := range c.grams[gram] {
+		best := 0.0
+		for _, k := range c.entries[key].keys {
+			switch {
+			case k == token:
+				best = 3
+			case strings.HasPrefix(k, token) && best < 2:
+				best = 2
+			}
+		}
+		if best > 0 {
+			matches[key] = best
+		}
+	}
+
+	limit := maxTypos(len(runes))
+	if len(matches) > 0 || limit == 0 {
+		return matches
+	}
+	for k, owners := range c.vocab {
+		kr := []rune(k)
+		if len(kr) > len(runes)+limit {
+			kr = kr[:len(runes)+limit]
+		}
+		if prefixDistance(runes, kr, limit) > limit {
+			continue
+		}
+		for key := range owners {
+			matches[key] = 1
+		}
+	}
+	return matches
+}
+
+// Complete คืนรายการที่ตรงกับทุกคำในคำค้น แบ่งโควตาต่อประเภทตาม perType
+// (ประเภทที่ไม่อยู่ใน perType จะไม่ถูกคืน) และรวมไม่เกิน limit รายการ
+func (c *Completer) Complete(query string, perType map[string]int, limit int) []Suggestion {
+	tokens := strings.Fields(strings.ToLower(query))
+	if len(tokens) == 0 {
+		return []Suggestion{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var scores map[string]float64
+	for _, token := range tokens {
+		matches := c.matchToken(token)
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for key, score := range scores {
+			if extra, ok := matches[key]; ok {
+				scores[key] = score + extra
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	ranked := make([]Suggestion, 0, len(scores))
+	for key, score := range scores {
+		s := c.entries[key].suggestion
+		if _, ok := perType[s.Type]; !ok {
+			continue
+		}
+		s.Score = score
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if len(ranked[i].Label) != len(ranked[j].Label) {
+			return len(ranked[i].Label) < len(ranked[j].Label)
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+
+	taken := map[string]int{}
+	results := []Suggestion{}
+	for _, s := range ranked {
+		if len(results) >= limit {
+			break
+		}
+		if taken[s.Type] >= perType[s.Type] {
+			continue
+		}
+		taken[s.Type]++
+		results = append(results, s)
+	}
+	return results
+}
@@ -0,0 +1,360 @@
+// Package search คือดัชนีค้นหาแบบ full-text ที่ฝังอยู่ใน process ของ backend
+// ครอบคลุมหนังสือ นักเขียน และคลับ รองรับการตัดคำภาษาไทยและ stemming ภาษาอังกฤษ
+// ดัชนีอยู่ในหน่วยความจำ การแก้ทีละเอกสารจะถูกรวบไว้แล้วบันทึกลงไฟล์เป็นรอบ (ดู Flush และ FlushEvery)
+package search
+
+import (
+	"context"
+	"encoding/gob"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	TypeBook   = "book"
+	TypeAuthor = "author"
+	TypeClub   = "club"
+	TypeUser   = "user"
+)
+
+// ค่าคงที่ของ BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Field คือข้อความหนึ่งช่องของเอกสารพร้อมน้ำหนัก (เช่น ชื่อเรื่องมีน้ำหนักมากกว่าคำอธิบาย)
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Doc คือเอกสารหนึ่งรายการที่จะนำเข้าดัชนี
+type Doc struct {
+	Type   string
+	ID     string
+	Fields []Field
+}
+
+type document struct {
+	ID     string
+	Type   string
+	Terms  map[string]float64
+	Length float64
+}
+
+type Hit struct {
+	ID    string  `json:"id"`
+	Type  string  `json:"type"`
+	Score float64 `json:"score"`
+}
+
+type Index struct {
+	mu       sync.RWMutex
+	path     string
+	docs     map[string]*document
+	postings map[string]map[string]float64
+	totalLen float64
+	// dirty คือมีการแก้ไขที่ยังไม่ได้บันทึกลงไฟล์
+	dirty bool
+	// replacing คือจำนวน Replace ที่กำลังโหลดข้อมูลอยู่ ระหว่างนั้น Put/Remove ถูกจดไว้ใน pending
+	// เพื่อนำไปใช้กับดัชนีใหม่อีกครั้งก่อนสลับ
+	replacing int
+	pending   []change
+}
+
+// change คือ Put หรือ Remove หนึ่งครั้งที่เกิดระหว่าง Replace
+type change struct {
+	docType, id string
+	fields      []Field
+	remove      bool
+}
+
+// Default คือดัชนีที่ server ใช้ ตั้งค่าใน main ด้วย Open
+var Default *Index
+
+func docKey(docType, id string) string {
+	return docType + ":" + id
+}
+
+// New สร้างดัชนีว่างที่จะบันทึกลง path
+func New(path string) *Index {
+	return &Index{
+		path:     path,
+		docs:     map[string]*document{},
+		postings: map[string]map[string]float64{},
+	}
+}
+
+// Open โหลดดัชนีจากไฟล์ ถ้ายังไม่มีไฟล์จะได้ดัชนีว่าง (ให้เรียก Rebuild ต่อ)
+func Open(path string) (*Index, error) {
+	idx := New(path)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var docs []*document
+	if err := gob.NewDecoder(file).Decode(&docs); err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		idx.addLocked(doc)
+	}
+	return idx, nil
+}
+
+// Len คืนจำนวนเอกสารในดัชนี
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Put เพิ่มหรือแทนที่เอกสาร การเปลี่ยนแปลงจะลงไฟล์ใน Flush ครั้งถัดไป
+func (idx *Index) Put(docType, id string, fields ...Field) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.putLocked(docType, id, fields)
+	idx.dirty = true
+	if idx.replacing > 0 {
+		idx.pending = append(idx.pending, change{docType: docType, id: id, fields: fields})
+	}
+}
+
+// Remove ลบเอกสารออกจากดัชนี การเปลี่ยนแปลงจะลงไฟล์ใน Flush ครั้งถัดไป
+func (idx *Index) Remove(docType, id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(docKey(docType, id))
+	idx.dirty = true
+	if idx.replacing > 0 {
+		idx.pending = append(idx.pending, change{docType: docType, id: id, remove: true})
+	}
+}
+
+// Flush บันทึกดัชนีลงไฟล์ถ้ามีการแก้ไขตั้งแต่บันทึกครั้งล่าสุด
+func (idx *Index) Flush() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.dirty {
+		return nil
+	}
+	return idx.saveLocked()
+}
+
+// FlushEvery บันทึกดัชนีทุก interval และบันทึกครั้งสุดท้ายเมื่อ ctx ถูกยกเลิก
+func (idx *Index) FlushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := idx.Flush(); err != nil {
+				log.Printf("search: failed to save index: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := idx.Flush(); err != nil {
+				log.Printf("search: failed to save index: %v", err)
+			}
+		}
+	}
+}
+
+// Replace แทนที่เอกสารทั้งหมดด้วยผลของ load แล้วบันทึกลงไฟล์ทันทีครั้งเดียว ใช้ตอน rebuild
+// Put/Remove ที่เกิดระหว่าง load (เช่น หนังสือที่สร้างหรือลบระหว่าง import) ถูกใช้กับดัชนีใหม่ซ้ำก่อนสลับ
+// จึงไม่หายไปเพราะ load อ่านข้อมูลก่อนหน้านั้น
+func (idx *Index) Replace(load func() ([]Doc, error)) error {
+	idx.mu.Lock()
+	idx.replacing++
+	idx.mu.Unlock()
+	done := func() {
+		idx.replacing--
+		if idx.replacing == 0 {
+			idx.pending = nil
+		}
+	}
+
+	docs, err := load()
+	if err != nil {
+		idx.mu.Lock()
+		done()
+		idx.mu.Unlock()
+		return err
+	}
+	fresh := New(idx.path)
+	for _, doc := range docs {
+		fresh.putLocked(doc.Type, doc.ID, doc.Fields)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, c := range idx.pending {
+		if c.remove {
+			fresh.removeLocked(docKey(c.docType, c.id))
+		} else {
+			fresh.putLocked(c.docType, c.id, c.fields)
+		}
+	}
+	done()
+	idx.docs = fresh.docs
+	idx.postings = fresh.postings
+	idx.totalLen = fresh.totalLen
+	return idx.saveLocked()
+}
+
+func (idx *Index) putLocked(docType, id string, fields []Field) {
+	doc := &document{ID: id, Type: docType, Terms: map[string]float64{}}
+	for _, field := range fields {
+		for _, term := range Terms(field.Text) {
+			doc.Terms[term] += field.Weight
+			doc.Length += field.Weight
+		}
+	}
+	idx.removeLocked(docKey(docType, id))
+	idx.addLocked(doc)
+}
+
+func (idx *Index) addLocked(doc *document) {
+	key := docKey(doc.Type, doc.ID)
+	idx.docs[key] = doc
+	idx.totalLen += doc.Length
+	for term, tf := range doc.Terms {
+		posting, ok := idx.postings[term]
+		if !ok {
+			posting = map[string]float64{}
+			idx.postings[term] = posting
+		}
+		posting[key] = tf
+	}
+}
+
+func (idx *Index) removeLocked(key string) {
+	doc, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	for term := range doc.Terms {
+		delete(idx.postings[term], key)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLen -= doc.Length
+	delete(idx.docs, key)
+}
+
+// saveLocked เขียนลงไฟล์ชั่วคราวก่อนแล้วค่อย rename เพื่อไม่ให้ไฟล์เสียถ้า process ตายกลางทาง
+func (idx *Index) saveLocked() error {
+	if idx.path == "" {
+		idx.dirty = false
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	docs := make([]*document, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		docs = append(docs, doc)
+	}
+	if err := gob.NewEncoder(file).Encode(docs); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		return err
+	}
+	idx.dirty = false
+	return nil
+}
+
+// Search คืนเอกสารประเภท docType ที่มีทุก term บังคับของคำค้น เรียงตามคะแนน BM25
+// ok เป็น false ถ้าคำค้นไม่มี term ที่ใช้ค้นในดัชนีได้เลย (เช่น มีแต่ stopword)
+func (idx *Index) Search(docType, query string, limit int) (hits []Hit, ok bool) {
+	required, boost := queryTerms(query)
+	if len(required) == 0 {
+		return nil, false
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// เริ่มจาก posting ที่สั้นที่สุดเพื่อลดจำนวนเอกสารที่ต้องตรวจ
+	sort.Slice(required, func(i, j int) bool {
+		return len(idx.postings[required[i]]) < len(idx.postings[required[j]])
+	})
+
+	scores := map[string]float64{}
+	for key := range idx.postings[required[0]] {
+		doc := idx.docs[key]
+		if docType != "" && doc.Type != docType {
+			continue
+		}
+		matched := true
+		for _, term := range required[1:] {
+			if _, found := doc.Terms[term]; !found {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			scores[key] = 0
+		}
+	}
+
+	n := float64(len(idx.docs))
+	avgLen := 1.0
+	if n > 0 && idx.totalLen > 0 {
+		avgLen = idx.totalLen / n
+	}
+	for _, term := range append(required, boost...) {
+		posting := idx.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for key := range scores {
+			tf, found := posting[key]
+			if !found {
+				continue
+			}
+			norm := tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*idx.docs[key].Length/avgLen))
+			scores[key] += idf * norm
+		}
+	}
+
+	hits = make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		doc := idx.docs[key]
+		hits = append(hits, Hit{ID: doc.ID, Type: doc.Type, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, true
+}
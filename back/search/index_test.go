@@ -0,0 +1,105 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexFlushesOnlyWhenDirty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "search.idx")
+	idx := New(path)
+
+	idx.Put(TypeBook, "1", Field{Text: "Norwegian Wood", Weight: 3})
+	idx.Put(TypeBook, "2", Field{Text: "Kafka on the Shore", Weight: 3})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Put wrote the index file before Flush (stat err = %v)", err)
+	}
+
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// ไม่มีอะไรเปลี่ยน Flush ต้องไม่เขียนไฟล์ใหม่
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("clean index was written again")
+	}
+
+	idx.Remove(TypeBook, "2")
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 1 {
+		t.Fatalf("reopened index has %d documents, want 1", reopened.Len())
+	}
+	if hits, _ := reopened.Search(TypeBook, "norwegian", 10); len(hits) != 1 || hits[0].ID != "1" {
+		t.Fatalf("hits = %+v", hits)
+	}
+}
+
+func TestIndexReplaceSavesImmediately(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "search.idx")
+	idx := New(path)
+	if err := idx.Replace(func() ([]Doc, error) {
+		return []Doc{
+			{Type: TypeAuthor, ID: "a", Fields: []Field{{Text: "Haruki Murakami", Weight: 3}}},
+			{Type: TypeBook, ID: "b", Fields: []Field{{Text: "1Q84", Weight: 3}}},
+		}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 2 {
+		t.Fatalf("reopened index has %d documents, want 2", reopened.Len())
+	}
+}
+
+func TestIndexReplaceKeepsWritesMadeDuringLoad(t *testing.T) {
+	idx := New("")
+	idx.Put(TypeBook, "deleted", Field{Text: "Deleted Book", Weight: 3})
+
+	err := idx.Replace(func() ([]Doc, error) {
+		// snapshot ถูกอ่านก่อน แล้วจึงมีหนังสือถูกสร้างและลบระหว่างที่ยัง rebuild อยู่
+		snapshot := []Doc{
+			{Type: TypeBook, ID: "kept", Fields: []Field{{Text: "Kept Book", Weight: 3}}},
+			{Type: TypeBook, ID: "deleted", Fields: []Field{{Text: "Deleted Book", Weight: 3}}},
+		}
+		idx.Put(TypeBook, "created", Field{Text: "Created Book", Weight: 3})
+		idx.Remove(TypeBook, "deleted")
+		return snapshot, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits, _ := idx.Search(TypeBook, "book", 10)
+	found := map[string]bool{}
+	for _, hit := range hits {
+		found[hit.ID] = true
+	}
+	if len(found) != 2 || !found["kept"] || !found["created"] {
+		t.Fatalf("hits after rebuild = %+v, want kept and created", hits)
+	}
+
+	// หลัง Replace จบแล้ว ไม่ต้องจดการแก้ไว้อีก
+	idx.Put(TypeBook, "later", Field{Text: "Later Book", Weight: 3})
+	if len(idx.pending) != 0 {
+		t.Fatalf("pending = %d after the rebuild finished", len(idx.pending))
+	}
+}
@@ -0,0 +1,351 @@
+package search
+
+import (
+	"back/config"
+	"back/models"
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func bookDoc(book models.Book, names map[primitive.ObjectID]string) Doc {
+	fields := []Field{
+		{Text: book.Title, Weight: 3},
+		{Text: names[book.AuthorID], Weight: 2},
+		{Text: book.Description, Weight: 1},
+	}
+	// ชื่อเรื่องและคำอธิบายทุกภาษาค้นได้ด้วยน้ำหนักเดียวกับภาษาหลัก
+	for _, title := range book.Titles {
+		fields = append(fields, Field{Text: title, Weight: 3})
+	}
+	for _, description := range book.Descriptions {
+		fields = append(fields, Field{Text: description, Weight: 1})
+	}
+	// ผู้แต่งร่วมค้นได้เหมือนผู้แต่งหลัก ผู้แปล/ผู้วาด/บรรณาธิการมีน้ำหนักน้อยกว่า
+	for _, contributor := range book.Contributors {
+		if contributor.AuthorID == book.AuthorID {
+			continue
+		}
+		weight := 1.0
+		if contributor.Role == models.RoleAuthor || contributor.Role == models.RoleCoAuthor {
+			weight = 2
+		}
+		fields = append(fields, Field{Text: names[contributor.AuthorID], Weight: weight})
+	}
+	return Doc{Type: TypeBook, ID: book.ID.Hex(), Fields: fields}
+}
+
+// contributorIDs คือ id ของทุกคนที่มีส่วนร่วมในหนังสือ รวมผู้แต่งหลัก
+func contributorIDs(book models.Book) []primitive.ObjectID {
+	ids := []primitive.ObjectID{book.AuthorID}
+	for _, contributor := range book.Contributors {
+		ids = append(ids, contributor.AuthorID)
+	}
+	return ids
+}
+
+func authorDoc(author models.Author) Doc {
+	fields := []Field{{Text: author.Name, Weight: 3}}
+	for _, name := range author.Names {
+		fields = append(fields, Field{Text: name, Weight: 3})
+	}
+	for _, alias := range author.Aliases {
+		fields = append(fields, Field{Text: alias, Weight: 2})
+	}
+	return Doc{Type: TypeAuthor, ID: author.ID.Hex(), Fields: fields}
+}
+
+func clubDoc(club models.Club) Doc {
+	return Doc{
+		Type: TypeClub,
+		ID:   club.ID.Hex(),
+		Fields: []Field{
+			{Text: club.Name, Weight: 3},
+			{Text: club.Description, Weight: 1},
+		},
+	}
+}
+
+// loadAuthors โหลดนักเขียนตาม id ที่ระบุ หรือทั้งหมดถ้า ids เป็น nil
+func loadAuthors(ctx context.Context, ids []primitive.ObjectID) ([]models.Author, error) {
+	filter := bson.M{}
+	if ids != nil {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	cursor, err := config.DB.Database("bookwarm").Collection("author").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var authors []models.Author
+	if err := cursor.All(ctx, &authors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+// authorNames โหลดชื่อนักเขียนตาม id ที่ระบุ หรือทั้งหมดถ้า ids เป็น nil
+func authorNames(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]string, error) {
+	authors, err := loadAuthors(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[primitive.ObjectID]string, len(authors))
+	for _, author := range authors {
+		names[author.ID] = searchName(author)
+	}
+	return names, nil
+}
+
+// searchName คือชื่อนักเขียนทุกภาษาต่อกัน ใช้เป็นข้อความของหนังสือ ค้น "Murakami" จึงเจอหนังสือของ "มูราคามิ"
+func searchName(author models.Author) string {
+	locales := make([]string, 0, len(author.Names))
+	for locale := range author.Names {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	parts := []string{author.Name}
+	for _, locale := range locales {
+		parts = append(parts, author.Names[locale])
+	}
+	return strings.Join(parts, " ")
+}
+
+// Rebuild อ่านหนังสือ นักเขียน และคลับทั้งหมดจาก MongoDB แล้วสร้างดัชนีใหม่ทั้งก้อน
+// การแก้ดัชนีที่เกิดระหว่างอ่านไม่หาย (ดู Index.Replace)
+func Rebuild(ctx context.Context, idx *Index) error {
+	return idx.Replace(func() ([]Doc, error) {
+		db := config.DB.Database("bookwarm")
+
+		authors, err := loadAuthors(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var docs []Doc
+		names := make(map[primitive.ObjectID]string, len(authors))
+		for _, author := range authors {
+			names[author.ID] = searchName(author)
+			docs = append(docs, authorDoc(author))
+		}
+
+		bookCursor, err := db.Collection("books").Find(ctx, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		var books []models.Book
+		if err := bookCursor.All(ctx, &books); err != nil {
+			return nil, err
+		}
+		for _, book := range books {
+			docs = append(docs, bookDoc(book, names))
+		}
+
+		clubCursor, err := db.Collection("clubs").Find(ctx, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		var clubs []models.Club
+		if err := clubCursor.All(ctx, &clubs); err != nil {
+			return nil, err
+		}
+		for _, club := range clubs {
+			docs = append(docs, clubDoc(club))
+		}
+		return docs, nil
+	})
+}
+
+// RefreshSuggestions โหลดชื่อหนังสือ นักเขียน คลับ และผู้ใช้ทั้งหมดเข้า autocomplete ใหม่
+func RefreshSuggestions(ctx context.Context) error {
+	db := config.DB.Database("bookwarm")
+	var suggestions []Suggestion
+
+	var books []models.Book
+	cursor, err := db.Collection("books").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"title": 1, "titles": 1, "coverImage": 1}))
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, &books); err != nil {
+		return err
+	}
+	for _, book := range books {
+		suggestions = append(suggestions, bookSuggestion(book))
+	}
+
+	var authors []models.Author
+	cursor, err = db.Collection("author").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, &authors); err != nil {
+		return err
+	}
+	for _, author := range authors {
+		suggestions = append(suggestions, authorSuggestion(author))
+	}
+
+	var clubs []models.Club
+	cursor, err = db.Collection("clubs").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"name": 1, "cover_image": 1}))
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, &clubs); err != nil {
+		return err
+	}
+	for _, club := range clubs {
+		suggestions = append(suggestions, clubSuggestion(club))
+	}
+
+	var users []models.User
+	cursor, err = db.Collection("users").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"displayname": 1, "profile_img_url": 1}))
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return err
+	}
+	for _, user := range users {
+		suggestions = append(suggestions, Suggestion{Type: TypeUser, ID: user.ID.Hex(), Label: user.DisplayName, Image: user.ProfilePic})
+	}
+
+	Suggestions.Reset(suggestions)
+	return nil
+}
+
+// RefreshSuggestionsEvery โหลด autocomplete ใหม่ทุก interval จนกว่า ctx จะถูกยกเลิก
+func RefreshSuggestionsEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := RefreshSuggestions(ctx); err != nil {
+			log.Printf("search: failed to refresh suggestions: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func bookSuggestion(book models.Book) Suggestion {
+	return Suggestion{Type: TypeBook, ID: book.ID.Hex(), Label: book.Title, Labels: book.Titles, Image: book.CoverImage}
+}
+
+func authorSuggestion(author models.Author) Suggestion {
+	return Suggestion{Type: TypeAuthor, ID: author.ID.Hex(), Label: author.Name, Labels: author.Names}
+}
+
+func clubSuggestion(club models.Club) Suggestion {
+	return Suggestion{Type: TypeClub, ID: club.ID.Hex(), Label: club.Name, Image: club.CoverImage}
+}
+
+func put(doc Doc) {
+	if Default == nil {
+		return
+	}
+	Default.Put(doc.Type, doc.ID, doc.Fields...)
+}
+
+func remove(docType string, id primitive.ObjectID) {
+	if Default == nil {
+		return
+	}
+	Default.Remove(docType, id.Hex())
+}
+
+// IndexBook อ่านหนังสือล่าสุดจากฐานข้อมูลแล้วอัปเดตดัชนี ใช้หลังสร้าง/แก้ไขหนังสือ
+func IndexBook(bookID primitive.ObjectID) {
+	if Default == nil {
+		return
+	}
+	db := config.DB.Database("bookwarm")
+	var book models.Book
+	if err := db.Collection("books").FindOne(context.TODO(), bson.M{"_id": bookID}).Decode(&book); err != nil {
+		log.Printf("search: failed to load book %s: %v", bookID.Hex(), err)
+		return
+	}
+	names, err := authorNames(context.TODO(), contributorIDs(book))
+	if err != nil {
+		log.Printf("search: failed to load contributors of book %s: %v", bookID.Hex(), err)
+	}
+	put(bookDoc(book, names))
+	Suggestions.Upsert(bookSuggestion(book))
+}
+
+func RemoveBook(bookID primitive.ObjectID) {
+	remove(TypeBook, bookID)
+	Suggestions.Delete(TypeBook, bookID.Hex())
+}
+
+// IndexAuthor อัปเดตดัชนีของนักเขียน รวมถึงหนังสือของนักเขียนคนนี้ที่ค้นด้วยชื่อนักเขียนได้
+func IndexAuthor(authorID primitive.ObjectID) {
+	if Default == nil {
+		return
+	}
+	db := config.DB.Database("bookwarm")
+	var author models.Author
+	if err := db.Collection("author").FindOne(context.TODO(), bson.M{"_id": authorID}).Decode(&author); err != nil {
+		log.Printf("search: failed to load author %s: %v", authorID.Hex(), err)
+		return
+	}
+	put(authorDoc(author))
+	Suggestions.Upsert(authorSuggestion(author))
+	reindexBooksBy(authorID)
+}
+
+func RemoveAuthor(authorID primitive.ObjectID) {
+	remove(TypeAuthor, authorID)
+	Suggestions.Delete(TypeAuthor, authorID.Hex())
+	reindexBooksBy(authorID)
+}
+
+// reindexBooksBy อัปเดตดัชนีของหนังสือทุกเล่มที่นักเขียนคนนี้มีส่วนร่วม เพื่อให้ชื่อที่ค้นได้เป็นชื่อล่าสุด
+func reindexBooksBy(authorID primitive.ObjectID) {
+	ctx := context.TODO()
+	cursor, err := config.DB.Database("bookwarm").Collection("books").Find(ctx, bson.M{"$or": []bson.M{
+		{"authorId": authorID},
+		{"contributors.authorId": authorID},
+	}})
+	if err != nil {
+		log.Printf("search: failed to load books of author %s: %v", authorID.Hex(), err)
+		return
+	}
+	var books []models.Book
+	if err := cursor.All(ctx, &books); err != nil {
+		log.Printf("search: failed to decode books of author %s: %v", authorID.Hex(), err)
+		return
+	}
+	for _, book := range books {
+		names, err := authorNames(ctx, contributorIDs(book))
+		if err != nil {
+			log.Printf("search: failed to load contributors of book %s: %v", book.ID.Hex(), err)
+			continue
+		}
+		put(bookDoc(book, names))
+	}
+}
+
+func IndexClub(clubID primitive.ObjectID) {
+	if Default == nil {
+		return
+	}
+	var club models.Club
+	if err := config.DB.Database("bookwarm").Collection("clubs").FindOne(context.TODO(), bson.M{"_id": clubID}).Decode(&club); err != nil {
+		log.Printf("search: failed to load club %s: %v", clubID.Hex(), err)
+		return
+	}
+	put(clubDoc(club))
+	Suggestions.Upsert(clubSuggestion(club))
+}
+
+func RemoveClub(clubID primitive.ObjectID) {
+	remove(TypeClub, clubID)
+	Suggestions.Delete(TypeClub, clubID.Hex())
+}
@@ -0,0 +1,225 @@
+// Package taxonomy จัดหมวด (category) และประเภท (genre) เป็นต้นไม้ที่ลึกได้ไม่จำกัด
+// แต่ละเอกสารเก็บแค่ parent_id ส่วน slug, path และลูกหลานคำนวณจากทั้ง collection ทุกครั้งที่โหลด
+// (ข้อมูลชุดนี้เล็ก) จึงไม่ต้องตามแก้ข้อมูลของลูกหลานเมื่อย้ายหรือเปลี่ยนชื่อ
+package taxonomy
+
+import (
+	"back/config"
+	"back/i18n"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Node คือหมวดหรือประเภทหนึ่งในต้นไม้
+type Node struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id"`
+	Name     string              `json:"name" bson:"name"`
+	Names    map[string]string   `json:"names,omitempty" bson:"names,omitempty"` // ชื่อภาษาอื่น slug ยังคิดจากชื่อภาษาหลักเสมอ
+	ParentID *primitive.ObjectID `json:"parentId,omitempty" bson:"parent_id,omitempty"`
+	Slug     string              `json:"slug" bson:"-"`
+	Path     string              `json:"path" bson:"-"` // slug ของบรรพบุรุษต่อกันด้วย "/" เช่น fiction/fantasy/epic-fantasy
+	Children []*Node             `json:"children" bson:"-"`
+}
+
+// Tree คือทั้ง collection ในรูปต้นไม้ เรียงพี่น้องตามชื่อ
+type Tree struct {
+	Roots  []*Node
+	byID   map[primitive.ObjectID]*Node
+	byPath map[string]*Node
+}
+
+// Load โหลดทั้ง collection ("category" หรือ "genre") แล้วสร้างต้นไม้
+func Load(ctx context.Context, collection string) (*Tree, error) {
+	cursor, err := config.DB.Database("bookwarm").Collection(collection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*Node
+	if err := cursor.All(ctx, &nodes); err != nil {
+		return nil, err
+	}
+	return Build(nodes), nil
+}
+
+// Build สร้างต้นไม้จากรายการ node ที่อ้างถึง parent ด้วย ParentID
+// node ที่ parent ไม่มีอยู่ หรืออยู่ในวงวน จะถูกถือเป็น root
+func Build(nodes []*Node) *Tree {
+	t := &Tree{byID: make(map[primitive.ObjectID]*Node, len(nodes)), byPath: map[string]*Node{}}
+	for _, node := range nodes {
+		node.Children = []*Node{}
+		t.byID[node.ID] = node
+	}
+	for _, node := range nodes {
+		parent := t.parent(node)
+		if parent == nil {
+			t.Roots = append(t.Roots, node)
+		} else {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	t.place(t.Roots, "")
+	return t
+}
+
+// parent คืน parent ของ node หรือ nil ถ้าไม่มี/ไม่มีอยู่จริง/ย้อนกลับมาหาตัวเอง
+func (t *Tree) parent(node *Node) *Node {
+	if node.ParentID == nil {
+		return nil
+	}
+	parent := t.byID[*node.ParentID]
+	seen := map[primitive.ObjectID]bool{node.ID: true}
+	for p := parent; p != nil; {
+		if seen[p.ID] {
+			return nil
+		}
+		seen[p.ID] = true
+		if p.ParentID == nil {
+			break
+		}
+		p = t.byID[*p.ParentID]
+	}
+	return parent
+}
+
+// place เรียงพี่น้องและกำหนด slug กับ path slug ที่ซ้ำกันในระดับเดียวกันได้เลขต่อท้าย
+// (เกิดได้เฉพาะข้อมูลเก่า ชื่อใหม่ที่ซ้ำกับพี่น้องถูกปฏิเสธ ดู Sibling)
+func (t *Tree) place(siblings []*Node, prefix string) {
+	sort.Slice(siblings, func(i, j int) bool {
+		if siblings[i].Name != siblings[j].Name {
+			return siblings[i].Name < siblings[j].Name
+		}
+		return siblings[i].ID.Hex() < siblings[j].ID.Hex()
+	})
+	used := map[string]int{}
+	for _, node := range siblings {
+		slug := Slugify(node.Name)
+		if slug == "" {
+			slug = node.ID.Hex()
+		}
+		used[slug]++
+		if used[slug] > 1 {
+			slug += "-" + strconv.Itoa(used[slug])
+		}
+		node.Slug = slug
+		node.Path = prefix + slug
+		t.byPath[node.Path] = node
+		t.place(node.Children, node.Path+"/")
+	}
+}
+
+// Sibling คืน node อื่นในระดับเดียวกับ parentID (nil คือระดับบนสุด) ที่ชื่อได้ slug เดียวกับ name ยกเว้น except
+// ใช้กันชื่อซ้ำกับพี่น้อง เพราะ slug ที่ซ้ำได้เลขต่อท้ายตามลำดับ path จึงเปลี่ยนได้เมื่อมีการเพิ่มหรือเปลี่ยนชื่อ
+func (t *Tree) Sibling(parentID *primitive.ObjectID, name string, except primitive.ObjectID) *Node {
+	slug := Slugify(name)
+	if slug == "" {
+		return nil
+	}
+	siblings := t.Roots
+	if parentID != nil {
+		parent := t.byID[*parentID]
+		if parent == nil {
+			return nil
+		}
+		siblings = parent.Children
+	}
+	for _, node := range siblings {
+		if node.ID != except && Slugify(node.Name) == slug {
+			return node
+		}
+	}
+	return nil
+}
+
+// Slugify ทำชื่อเป็น slug ตัวเล็ก คั่นคำด้วย "-" เก็บตัวอักษรทุกภาษา (รวมสระและวรรณยุกต์ไทย) และตัวเลข
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// Localize เปลี่ยนชื่อของทุก node เป็นภาษา locale (ดู i18n.Pick) slug และ path ไม่เปลี่ยน
+// ลิงก์เดียวกันจึงใช้ได้ทุกภาษา
+func (t *Tree) Localize(locale string) {
+	for _, node := range t.byID {
+		node.Name = i18n.Pick(node.Names, node.Name, locale)
+	}
+}
+
+// Node คืน node ตาม id
+func (t *Tree) Node(id primitive.ObjectID) *Node {
+	return t.byID[id]
+}
+
+// ByPath คืน node ตาม slug path (ไม่สนใจ "/" หัวท้าย)
+func (t *Tree) ByPath(path string) *Node {
+	return t.byPath[strings.Trim(path, "/")]
+}
+
+// Ancestors คืนบรรพบุรุษของ node เรียงจาก root ลงมา ไม่รวมตัวเอง
+func (t *Tree) Ancestors(id primitive.ObjectID) []*Node {
+	var ancestors []*Node
+	node := t.byID[id]
+	for node != nil {
+		parent := t.parent(node)
+		if parent == nil {
+			break
+		}
+		ancestors = append([]*Node{parent}, ancestors...)
+		node = parent
+	}
+	return ancestors
+}
+
+// Descendants คืน ids พร้อมลูกหลานทั้งหมดของแต่ละตัว ใช้กรองหนังสือ
+// id ที่ไม่มีในต้นไม้ยังอยู่ในผลลัพธ์ (จะไม่ตรงหนังสือเล่มใดเอง)
+func (t *Tree) Descendants(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{}
+	var out []primitive.ObjectID
+	var walk func(id primitive.ObjectID)
+	walk = func(id primitive.ObjectID) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		out = append(out, id)
+		if node := t.byID[id]; node != nil {
+			for _, child := range node.Children {
+				walk(child.ID)
+			}
+		}
+	}
+	for _, id := range ids {
+		walk(id)
+	}
+	return out
+}
+
+// IsDescendant บอกว่า id เป็นตัวเดียวกับหรืออยู่ใต้ ancestor หรือไม่ ใช้กันการย้ายไปอยู่ใต้ลูกหลานของตัวเอง
+func (t *Tree) IsDescendant(id, ancestor primitive.ObjectID) bool {
+	if id == ancestor {
+		return true
+	}
+	for _, node := range t.Ancestors(id) {
+		if node.ID == ancestor {
+			return true
+		}
+	}
+	return false
+}
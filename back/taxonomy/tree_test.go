@@ -0,0 +1,91 @@
+package taxonomy
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSibling(t *testing.T) {
+	fiction := &Node{ID: primitive.NewObjectID(), Name: "Fiction"}
+	fantasy := &Node{ID: primitive.NewObjectID(), Name: "Fantasy", ParentID: &fiction.ID}
+	poetry := &Node{ID: primitive.NewObjectID(), Name: "Poetry"}
+	tree := Build([]*Node{fiction, fantasy, poetry})
+	missing := primitive.NewObjectID()
+
+	tests := []struct {
+		name   string
+		parent *primitive.ObjectID
+		input  string
+		except primitive.ObjectID
+		want   *Node
+	}{
+		{"same name at the root", nil, "Fiction", primitive.NilObjectID, fiction},
+		{"same slug with other punctuation", nil, "  fiction!", primitive.NilObjectID, fiction},
+		{"same name under a parent", &fiction.ID, "FANTASY", primitive.NilObjectID, fantasy},
+		{"same name at another level", nil, "Fantasy", primitive.NilObjectID, nil},
+		{"renaming to its own name", &fiction.ID, "Fantasy", fantasy.ID, nil},
+		{"new name", nil, "Drama", primitive.NilObjectID, nil},
+		{"name without a slug", nil, "!!!", primitive.NilObjectID, nil},
+		{"unknown parent", &missing, "Fantasy", primitive.NilObjectID, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tree.Sibling(tt.parent, tt.input, tt.except); got != tt.want {
+				t.Fatalf("Sibling = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	// ตัวอักษรไทยรวมสระและวรรณยุกต์คงไว้ทั้งหมด ช่องว่างและเครื่องหมายกลายเป็น "-"
+	for name, want := range map[string]string{
+		"  Sci-Fi & Fantasy!  ": "sci-fi-fantasy",
+		"นิยาย สืบสวน":          "นิยาย-สืบสวน",
+		"ไม้":                   "ไม้",
+		"Éclair":                "éclair",
+		"!!!":                   "",
+	} {
+		if got := Slugify(name); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestBuildPlacesPaths(t *testing.T) {
+	id := func(n byte) primitive.ObjectID { return primitive.ObjectID{11: n} }
+	fiction := &Node{ID: id(1), Name: "Fiction"}
+	fantasy := &Node{ID: id(2), Name: "Fantasy", ParentID: &fiction.ID}
+	// ชื่อซ้ำจากข้อมูลเก่า ตัวที่ id มากกว่าได้เลขต่อท้าย
+	oldFantasy := &Node{ID: id(3), Name: "Fantasy", ParentID: &fiction.ID}
+	symbols := &Node{ID: id(4), Name: "???"}
+	missing := id(0xff)
+	orphan := &Node{ID: id(5), Name: "Orphan", ParentID: &missing}
+	loopA := &Node{ID: id(6), Name: "Loop A"}
+	loopB := &Node{ID: id(7), Name: "Loop B", ParentID: &loopA.ID}
+	loopA.ParentID = &loopB.ID
+
+	tree := Build([]*Node{oldFantasy, fantasy, fiction, symbols, orphan, loopA, loopB})
+
+	if fantasy.Path != "fiction/fantasy" || oldFantasy.Path != "fiction/fantasy-2" {
+		t.Errorf("paths = %q, %q", fantasy.Path, oldFantasy.Path)
+	}
+	if tree.ByPath("fiction/fantasy-2") != oldFantasy {
+		t.Error("ByPath did not find the renamed duplicate")
+	}
+	// ชื่อที่ไม่มี slug ใช้ id แทน
+	if symbols.Path != symbols.ID.Hex() {
+		t.Errorf("symbols path = %q", symbols.Path)
+	}
+
+	// parent ที่หายไปหรือวนกันทำให้ขึ้นไปอยู่ที่ราก
+	var roots []string
+	for _, root := range tree.Roots {
+		roots = append(roots, root.Path)
+	}
+	if want := symbols.ID.Hex() + " fiction loop-a loop-b orphan"; strings.Join(roots, " ") != want {
+		t.Errorf("roots = %q, want %q", roots, want)
+	}
+}
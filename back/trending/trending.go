@@ -0,0 +1,218 @@
+// Package trending จัดอันดับหนังสือและคลับที่กำลังมาแรงในช่วงเวลาล่าสุด (24 ชั่วโมง, 7 วัน, 30 วัน)
+//
+// กิจกรรมในช่วงล่าสุดถูกเทียบกับช่วงก่อนหน้าที่ยาวเท่ากัน เล่มที่มีกิจกรรมเพิ่มขึ้นเร็วจึงขึ้นนำ
+// แม้ยอดรวมยังน้อยกว่าเล่มที่นิยมมานาน ผลถูกคำนวณเป็นรอบด้วย RefreshEvery และเก็บไว้ในหน่วยความจำ
+package trending
+
+import (
+	"back/config"
+	"context"
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Window คือช่วงเวลาที่ใช้จัดอันดับ
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+var Windows = []Window{
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+	{Name: "30d", Duration: 30 * 24 * time.Hour},
+}
+
+// DefaultWindow คือช่วงเวลาที่ใช้เมื่อไม่ได้ระบุ
+const DefaultWindow = "7d"
+
+const (
+	// maxEntries คือจำนวนอันดับที่เก็บไว้ต่อช่วงเวลา
+	maxEntries = 100
+	// smoothing กันไม่ให้เล่มที่มีกิจกรรมครั้งแรกแค่ 1-2 ครั้งได้อัตราเร่งสูงเกินจริง
+	smoothing = 5
+)
+
+// source คือกิจกรรมหนึ่งชนิด นับเอกสารใน Collection ตาม TimeField โดยจัดกลุ่มตาม KeyField
+type source struct {
+	Collection string
+	KeyField   string
+	TimeField  string
+	Weight     float64
+}
+
+// กิจกรรมของหนังสือ รีวิวใช้ความตั้งใจมากกว่าการกด mark จึงมีน้ำหนักมากกว่า
+var bookSources = []source{
+	{Collection: "marks", KeyField: "book_id", TimeField: "updated_at", Weight: 1},
+	{Collection: "reviews", KeyField: "book_id", TimeField: "review_date", Weight: 2},
+	{Collection: "post", KeyField: "book_id", TimeField: "created_at", Weight: 1.5},
+}
+
+// กิจกรรมของคลับ
+var clubSources = []source{
+	{Collection: "post", KeyField: "club_id", TimeField: "created_at", Weight: 1},
+	{Collection: "club_joins", KeyField: "club_id", TimeField: "joined_at", Weight: 2},
+}
+
+// Entry คืออันดับของหนังสือหรือคลับหนึ่งรายการในช่วงเวลาหนึ่ง
+//
+//	score = recent × (recent + smoothing) / (previous + smoothing)
+//
+// recent และ previous คือผลรวมน้ำหนักของกิจกรรมในช่วงล่าสุดและช่วงก่อนหน้า
+// velocity คืออัตราส่วน (recent + smoothing) / (previous + smoothing) มากกว่า 1 แปลว่ากำลังโต
+type Entry struct {
+	ID       primitive.ObjectID `json:"-"`
+	Recent   float64            `json:"recent"`
+	Previous float64            `json:"previous"`
+	Velocity float64            `json:"velocity"`
+	Score    float64            `json:"score"`
+}
+
+// Snapshot คือผลของ Compute ห้ามแก้หลังสร้างเสร็จ
+type Snapshot struct {
+	Books      map[string][]Entry // key คือชื่อของ Window
+	Clubs      map[string][]Entry
+	ComputedAt time.Time
+}
+
+var current atomic.Pointer[Snapshot]
+
+// Current คืนผลล่าสุด หรือ nil ถ้ายังคำนวณครั้งแรกไม่เสร็จ
+func Current() *Snapshot {
+	return current.Load()
+}
+
+// ValidWindow บอกว่า name เป็นชื่อของ Window ที่รองรับหรือไม่
+func ValidWindow(name string) bool {
+	for _, w := range Windows {
+		if w.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Compute คำนวณอันดับทุกช่วงเวลาจากฐานข้อมูล
+func Compute(ctx context.Context) (*Snapshot, error) {
+	now := time.Now()
+	snapshot := &Snapshot{Books: map[string][]Entry{}, Clubs: map[string][]Entry{}, ComputedAt: now}
+	for _, w := range Windows {
+		books, err := rank(ctx, bookSources, now, w.Duration)
+		if err != nil {
+			return nil, err
+		}
+		clubs, err := rank(ctx, clubSources, now, w.Duration)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Books[w.Name] = books
+		snapshot.Clubs[w.Name] = clubs
+	}
+	return snapshot, nil
+}
+
+func rank(ctx context.Context, sources []source, now time.Time, window time.Duration) ([]Entry, error) {
+	totals := map[primitive.ObjectID]*Entry{}
+	for _, src := range sources {
+		if err := src.count(ctx, now, window, totals); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]Entry, 0, len(totals))
+	for _, e := range totals {
+		if e.Recent == 0 {
+			continue
+		}
+		e.Velocity = (e.Recent + smoothing) / (e.Previous + smoothing)
+		e.Score = e.Recent * e.Velocity
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		if entries[i].Recent != entries[j].Recent {
+			return entries[i].Recent > entries[j].Recent
+		}
+		return entries[i].ID.Hex() < entries[j].ID.Hex()
+	})
+	if len(entries) > maxEntries {
+		entries = entries[:maxEntries]
+	}
+	return entries, nil
+}
+
+// count บวกน้ำหนักของกิจกรรมในช่วงล่าสุดและช่วงก่อนหน้าลงใน totals
+func (src source) count(ctx context.Context, now time.Time, window time.Duration, totals map[primitive.ObjectID]*Entry) error {
+	recentFrom := now.Add(-window)
+	previousFrom := now.Add(-2 * window)
+	field := "$" + src.TimeField
+	cursor, err := config.DB.Database("bookwarm").Collection(src.Collection).Aggregate(ctx, []bson.M{
+		{"$match": bson.M{
+			src.TimeField: bson.M{"$gte": previousFrom, "$lte": now},
+			src.KeyField:  bson.M{"$type": "objectId"},
+		}},
+		{"$group": bson.M{
+			"_id": "$" + src.KeyField,
+			"recent": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$gte": []interface{}{field, recentFrom}}, 1, 0,
+			}}},
+			"previous": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$lt": []interface{}{field, recentFrom}}, 1, 0,
+			}}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	var groups []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Recent   int                `bson:"recent"`
+		Previous int                `bson:"previous"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+	for _, g := range groups {
+		e := totals[g.ID]
+		if e == nil {
+			e = &Entry{ID: g.ID}
+			totals[g.ID] = e
+		}
+		e.Recent += src.Weight * float64(g.Recent)
+		e.Previous += src.Weight * float64(g.Previous)
+	}
+	return nil
+}
+
+// Refresh คำนวณอันดับใหม่แล้วแทนที่ผลที่ใช้อยู่
+func Refresh(ctx context.Context) error {
+	snapshot, err := Compute(ctx)
+	if err != nil {
+		return err
+	}
+	current.Store(snapshot)
+	return nil
+}
+
+// RefreshEvery คำนวณอันดับใหม่ทุก interval จนกว่า ctx จะถูกยกเลิก
+func RefreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := Refresh(ctx); err != nil {
+			log.Printf("trending: failed to compute rankings: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidISBN = errors.New("invalid ISBN")
+
+// cleanISBN ตัดขีด ช่องว่าง และคำนำหน้า "ISBN" ออก เหลือแต่ตัวเลข (และ X ตัวท้ายของ ISBN-10)
+func cleanISBN(raw string) string {
+	raw = strings.ToUpper(strings.TrimSpace(raw))
+	raw = strings.TrimPrefix(raw, "ISBN-13")
+	raw = strings.TrimPrefix(raw, "ISBN-10")
+	raw = strings.TrimPrefix(raw, "ISBN")
+	var b strings.Builder
+	for _, r := range raw {
+		if unicode.IsDigit(r) || r == 'X' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func validISBN10(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	sum := 0
+	for i, r := range s {
+		var v int
+		switch {
+		case r >= '0' && r <= '9':
+			v = int(r - '0')
+		case r == 'X' && i == 9:
+			v = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * v
+	}
+	return sum%11 == 0
+}
+
+func isbn13CheckDigit(first12 string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		v := int(first12[i] - '0')
+		if i%2 == 1 {
+			v *= 3
+		}
+		sum += v
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func validISBN13(s string) bool {
+	if len(s) != 13 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+		return false
+	}
+	return isbn13CheckDigit(s[:12]) == s[12]
+}
+
+// NormalizeISBN ตรวจ checksum ของ ISBN-10 หรือ ISBN-13 แล้วคืนทั้งสองแบบโดยไม่มีขีด
+// ISBN-13 ที่ขึ้นต้นด้วย 979 ไม่มี ISBN-10 ที่เทียบเท่า isbn10 จะเป็นค่าว่าง
+func NormalizeISBN(raw string) (isbn13, isbn10 string, err error) {
+	s := cleanISBN(raw)
+	switch {
+	case validISBN10(s):
+		isbn10 = s
+		isbn13 = "978" + s[:9]
+		isbn13 += string(isbn13CheckDigit(isbn13))
+	case validISBN13(s):
+		isbn13 = s
+		if strings.HasPrefix(s, "978") {
+			isbn10 = s[3:12] + isbn10CheckDigit(s[3:12])
+		}
+	default:
+		return "", "", ErrInvalidISBN
+	}
+	return isbn13, isbn10, nil
+}
+
+func isbn10CheckDigit(first9 string) string {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(first9[i]-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return "X"
+	}
+	return string(rune('0' + check))
+}
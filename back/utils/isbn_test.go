@@ -0,0 +1,36 @@
+package utils
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	// ISBN เดียวกันในรูปแบบต่าง ๆ ต้องได้ค่าเดียวกัน
+	valid := []struct{ raw, isbn13, isbn10 string }{
+		{"0306406152", "9780306406157", "0306406152"},
+		{"ISBN 0-306-40615-2", "9780306406157", "0306406152"},
+		{"978-0-306-40615-7", "9780306406157", "0306406152"},
+		{"080442957X", "9780804429573", "080442957X"},
+		{"080442957x", "9780804429573", "080442957X"},
+		{"ISBN-13: 9780804429573", "9780804429573", "080442957X"},
+		{"9791090636071", "9791090636071", ""}, // 979 ไม่มี ISBN-10
+	}
+	for _, v := range valid {
+		isbn13, isbn10, err := NormalizeISBN(v.raw)
+		if err != nil || isbn13 != v.isbn13 || isbn10 != v.isbn10 {
+			t.Errorf("NormalizeISBN(%q) = %q, %q, %v; want %q, %q", v.raw, isbn13, isbn10, err, v.isbn13, v.isbn10)
+		}
+	}
+
+	invalid := []string{
+		"0306406153",    // checksum ของ ISBN-10 ผิด
+		"9780306406158", // checksum ของ ISBN-13 ผิด
+		"08044295X7",    // X อยู่ได้แค่ตัวท้าย
+		"9770306406158", // checksum ถูกแต่ไม่ขึ้นต้นด้วย 978/979
+		"030640615",
+		"",
+	}
+	for _, raw := range invalid {
+		if _, _, err := NormalizeISBN(raw); err != ErrInvalidISBN {
+			t.Errorf("NormalizeISBN(%q) error = %v, want ErrInvalidISBN", raw, err)
+		}
+	}
+}
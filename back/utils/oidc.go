@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"back/config"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+type OIDCDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type OIDCClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+type cachedDiscovery struct {
+	doc       *OIDCDiscovery
+	fetchedAt time.Time
+}
+
+type cachedKeys struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+var (
+	oidcCacheMu    sync.Mutex
+	discoveryCache = map[string]cachedDiscovery{}
+	jwksCache      = map[string]cachedKeys{}
+)
+
+const oidcCacheTTL = time.Hour
+
+// DiscoverOIDC ดึง .well-known/openid-configuration ของ issuer (cache ไว้ 1 ชั่วโมง)
+func DiscoverOIDC(issuer string) (*OIDCDiscovery, error) {
+	oidcCacheMu.Lock()
+	cached, ok := discoveryCache[issuer]
+	oidcCacheMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < oidcCacheTTL {
+		return cached.doc, nil
+	}
+
+	resp, err := oidcHTTPClient.Get(issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery returned status %d", resp.StatusCode)
+	}
+
+	var doc OIDCDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("issuer mismatch: %s", doc.Issuer)
+	}
+
+	oidcCacheMu.Lock()
+	discoveryCache[issuer] = cachedDiscovery{doc: &doc, fetchedAt: time.Now()}
+	oidcCacheMu.Unlock()
+	return &doc, nil
+}
+
+// RandomURLString สร้างสตริงสุ่มแบบ base64url สำหรับ state, nonce และ PKCE verifier
+func RandomURLString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// PKCEChallenge คำนวณ code_challenge แบบ S256 จาก verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func OIDCAuthURL(provider config.OIDCProvider, doc *OIDCDiscovery, state, nonce, verifier string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {provider.RedirectURL},
+		"scope":                 {strings.Join(provider.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {PKCEChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + params.Encode()
+}
+
+// ExchangeOIDCCode แลก authorization code เป็น id_token ที่ token endpoint
+func ExchangeOIDCCode(provider config.OIDCProvider, doc *OIDCDiscovery, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURL},
+		"client_id":     {provider.ClientID},
+		"code_verifier": {verifier},
+	}
+	if provider.ClientSecret != "" {
+		form.Set("client_secret", provider.ClientSecret)
+	}
+
+	resp, err := oidcHTTPClient.PostForm(doc.TokenEndpoint, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("token endpoint error: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return body.IDToken, nil
+}
+
+// VerifyIDToken ตรวจลายเซ็น RS256 กับ JWKS ของ issuer และตรวจ iss, aud, exp, nonce
+func VerifyIDToken(provider config.OIDCProvider, doc *OIDCDiscovery, rawIDToken, nonce string) (*OIDCClaims, error) {
+	token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return oidcSigningKey(doc.JWKSURI, kid)
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid id_token: %v", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid id_token claims")
+	}
+	// MapClaims.Valid ถือว่า token ที่ไม่มี exp ยังไม่หมดอายุ แต่ id_token ต้องมี exp เสมอ
+	if !numericClaim(claims["exp"]) {
+		return nil, errors.New("id_token has no valid exp")
+	}
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != provider.IssuerURL {
+		return nil, errors.New("id_token issuer mismatch")
+	}
+	if !audienceContains(claims["aud"], provider.ClientID) {
+		return nil, errors.New("id_token audience mismatch")
+	}
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	result := &OIDCClaims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	result.Picture, _ = claims["picture"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+	if result.Subject == "" {
+		return nil, errors.New("id_token has no subject")
+	}
+	return result, nil
+}
+
+func numericClaim(value interface{}) bool {
+	switch v := value.(type) {
+	case float64:
+		return true
+	case json.Number:
+		_, err := v.Int64()
+		return err == nil
+	}
+	return false
+}
+
+func audienceContains(aud interface{}, clientID string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == clientID
+	case []interface{}:
+		for _, item := range value {
+			if s, ok := item.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func oidcSigningKey(jwksURI, kid string) (*rsa.PublicKey, error) {
+	oidcCacheMu.Lock()
+	cached, ok := jwksCache[jwksURI]
+	oidcCacheMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < oidcCacheTTL {
+		if key, found := cached.keys[kid]; found {
+			return key, nil
+		}
+	}
+
+	// kid ไม่รู้จักหรือ cache หมดอายุ ให้โหลด JWKS ใหม่ (กรณี provider หมุน key)
+	keys, err := fetchJWKS(jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	oidcCacheMu.Lock()
+	jwksCache[jwksURI] = cachedKeys{keys: keys, fetchedAt: time.Now()}
+	oidcCacheMu.Unlock()
+
+	if key, found := keys[kid]; found {
+		return key, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key %q not found", kid)
+}
+
+func fetchJWKS(jwksURI string) (map[string]*rsa.PublicKey, error) {
+	resp, err := oidcHTTPClient.Get(jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
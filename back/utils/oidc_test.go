@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"back/config"
+	"back/utils/oidctest"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func mockProvider(issuerURL string) config.OIDCProvider {
+	return config.OIDCProvider{
+		Name:        "mock",
+		IssuerURL:   issuerURL,
+		ClientID:    "bookwarm",
+		RedirectURL: "http://bookwarm.test/api/auth/oidc/mock/callback",
+		Scopes:      []string{"openid", "email", "profile"},
+	}
+}
+
+// authorize เปิดหน้า authorize ของ issuer แล้วคืน code และ state จาก redirect กลับมาที่ callback
+func authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(location.String(), "http://bookwarm.test/api/auth/oidc/mock/callback?") {
+		t.Fatalf("redirected to %s", location)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+// login ทำ flow ตั้งแต่ discovery จนได้ id_token ที่ยังไม่ได้ตรวจ
+func login(t *testing.T, provider config.OIDCProvider) (doc *OIDCDiscovery, rawIDToken, nonce string) {
+	t.Helper()
+	doc, err := DiscoverOIDC(provider.IssuerURL)
+	if err != nil {
+		t.Fatalf("DiscoverOIDC: %v", err)
+	}
+	state, _ := RandomURLString(24)
+	nonce, _ = RandomURLString(24)
+	verifier, _ := RandomURLString(48)
+
+	code, gotState := authorize(t, OIDCAuthURL(provider, doc, state, nonce, verifier))
+	if gotState != state {
+		t.Fatalf("state = %q, want %q", gotState, state)
+	}
+	rawIDToken, err = ExchangeOIDCCode(provider, doc, code, verifier)
+	if err != nil {
+		t.Fatalf("ExchangeOIDCCode: %v", err)
+	}
+	return doc, rawIDToken, nonce
+}
+
+func TestOIDCFlow(t *testing.T) {
+	server, _ := oidctest.NewServer(t)
+	provider := mockProvider(server.URL)
+
+	doc, rawIDToken, nonce := login(t, provider)
+	if doc.TokenEndpoint != server.URL+"/token" || doc.JWKSURI != server.URL+"/jwks" {
+		t.Fatalf("unexpected discovery document %+v", doc)
+	}
+	claims, err := VerifyIDToken(provider, doc, rawIDToken, nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	want := OIDCClaims{Subject: "mock-user-1", Email: "reader@example.com", EmailVerified: true, Name: "Mock Reader"}
+	if *claims != want {
+		t.Fatalf("claims = %+v, want %+v", *claims, want)
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	server, issuer := oidctest.NewServer(t)
+	issuer.URL = "https://other.example.com"
+	if _, err := DiscoverOIDC(server.URL); err == nil {
+		t.Fatal("discovery with a different issuer was accepted")
+	}
+}
+
+func TestOIDCExchangeRequiresPKCEVerifier(t *testing.T) {
+	server, _ := oidctest.NewServer(t)
+	provider := mockProvider(server.URL)
+	doc, err := DiscoverOIDC(provider.IssuerURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := authorize(t, OIDCAuthURL(provider, doc, "state", "nonce", "the-real-verifier"))
+	if _, err := ExchangeOIDCCode(provider, doc, code, "another-verifier"); err == nil {
+		t.Fatal("code exchange with a wrong verifier succeeded")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(jwt.MapClaims)
+		nonce  string
+	}{
+		{"nonce mismatch", nil, "another-nonce"},
+		{"issuer mismatch", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, ""},
+		{"audience mismatch", func(c jwt.MapClaims) { c["aud"] = "someone-else" }, ""},
+		{"audience list without client", func(c jwt.MapClaims) { c["aud"] = []string{"a", "b"} }, ""},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }, ""},
+		{"non-numeric exp", func(c jwt.MapClaims) { c["exp"] = "tomorrow" }, ""},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, ""},
+		{"missing subject", func(c jwt.MapClaims) { delete(c, "sub") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, issuer := oidctest.NewServer(t)
+			issuer.Claims = tt.claims
+			provider := mockProvider(server.URL)
+			doc, rawIDToken, nonce := login(t, provider)
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if _, err := VerifyIDToken(provider, doc, rawIDToken, nonce); err == nil {
+				t.Fatal("id_token was accepted")
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAcceptsAudienceList(t *testing.T) {
+	server, issuer := oidctest.NewServer(t)
+	issuer.Claims = func(c jwt.MapClaims) { c["aud"] = []string{"other", "bookwarm"} }
+	provider := mockProvider(server.URL)
+	doc, rawIDToken, nonce := login(t, provider)
+	if _, err := VerifyIDToken(provider, doc, rawIDToken, nonce); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsForeignSignature(t *testing.T) {
+	server, issuer := oidctest.NewServer(t)
+	provider := mockProvider(server.URL)
+	doc, err := DiscoverOIDC(provider.IssuerURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{
+		"iss": server.URL, "sub": "attacker", "aud": provider.ClientID, "nonce": "n",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+
+	// kid เดียวกันแต่เซ็นด้วย key อื่น
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	forged.Header["kid"] = issuer.KeyID
+	signed, _ := forged.SignedString(otherKey)
+	if _, err := VerifyIDToken(provider, doc, signed, "n"); err == nil {
+		t.Fatal("token signed by another key was accepted")
+	}
+
+	// HS256 โดยใช้ค่า public key เป็น secret
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmac.Header["kid"] = issuer.KeyID
+	signed, _ = hmac.SignedString(issuer.Key.PublicKey.N.Bytes())
+	if _, err := VerifyIDToken(provider, doc, signed, "n"); err == nil {
+		t.Fatal("HS256 token was accepted")
+	}
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	// ตัวอย่างจาก RFC 7636 ภาคผนวก B
+	if got := PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Fatalf("PKCEChallenge = %q", got)
+	}
+}
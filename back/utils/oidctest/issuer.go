@@ -0,0 +1,217 @@
+// Package oidctest คือ OpenID Connect issuer จำลองสำหรับทดสอบ social login แบบ offline
+// ใช้ได้ทั้งใน test (ดู NewServer) และเป็น server แยกผ่าน go run ./cmd/mockoidc
+//
+// หน้า /authorize อนุมัติทันทีโดยไม่ถามอะไร และ /token ตรวจ PKCE (S256) ก่อนออก id_token
+// ที่เซ็นด้วย RS256 ตาม key ที่ประกาศใน /jwks
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type pendingCode struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// Issuer คือ issuer จำลองหนึ่งราย ค่าของผู้ใช้ที่ login เปลี่ยนได้ระหว่าง test
+type Issuer struct {
+	URL           string // issuer ที่ประกาศใน discovery และใส่ใน iss
+	KeyID         string
+	Key           *rsa.PrivateKey
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// Claims ถ้าตั้งไว้จะถูกเรียกก่อนเซ็น id_token ใช้จำลอง provider ที่ออก token ผิด เช่น iss/aud/nonce ไม่ตรง
+	Claims func(jwt.MapClaims)
+
+	mu    sync.Mutex
+	codes map[string]pendingCode
+}
+
+// New สร้าง issuer พร้อม RSA key ใหม่และผู้ใช้ตั้งต้นที่ยืนยันอีเมลแล้ว
+func New(issuerURL string) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{
+		URL:           issuerURL,
+		KeyID:         "mock-key",
+		Key:           key,
+		Subject:       "mock-user-1",
+		Email:         "reader@example.com",
+		EmailVerified: true,
+		Name:          "Mock Reader",
+		codes:         map[string]pendingCode{},
+	}, nil
+}
+
+// NewServer เปิด issuer บน httptest.Server ที่ปิดเองเมื่อ test จบ
+func NewServer(t testing.TB) (*httptest.Server, *Issuer) {
+	t.Helper()
+	issuer, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(issuer.Handler())
+	t.Cleanup(server.Close)
+	issuer.URL = server.URL
+	return server, issuer
+}
+
+// Sign เซ็น claims ด้วย key ของ issuer ใช้สร้าง id_token ที่ไม่ผ่าน flow ปกติ
+func (i *Issuer) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.KeyID
+	return token.SignedString(i.Key)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Handler คืน endpoint ทั้งหมดของ issuer
+func (i *Issuer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                i.URL,
+			"authorization_endpoint":                i.URL + "/authorize",
+			"token_endpoint":                        i.URL + "/token",
+			"jwks_uri":                              i.URL + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": i.KeyID,
+				"n":   base64.RawURLEncoding.EncodeToString(i.Key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.Key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+			http.Error(w, "authorization code flow with S256 PKCE is required", http.StatusBadRequest)
+			return
+		}
+		redirect, err := url.Parse(q.Get("redirect_uri"))
+		if err != nil || redirect.Scheme == "" {
+			http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+			return
+		}
+
+		code, err := randomString(16)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		i.mu.Lock()
+		i.codes[code] = pendingCode{
+			clientID:      q.Get("client_id"),
+			redirectURI:   q.Get("redirect_uri"),
+			nonce:         q.Get("nonce"),
+			codeChallenge: q.Get("code_challenge"),
+		}
+		i.mu.Unlock()
+
+		params := redirect.Query()
+		params.Set("code", code)
+		params.Set("state", q.Get("state"))
+		redirect.RawQuery = params.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+		code := r.PostForm.Get("code")
+
+		i.mu.Lock()
+		pending, ok := i.codes[code]
+		delete(i.codes, code)
+		i.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		switch {
+		case !ok:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		case r.PostForm.Get("client_id") != pending.clientID || r.PostForm.Get("redirect_uri") != pending.redirectURI:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "client or redirect mismatch"})
+			return
+		case base64.RawURLEncoding.EncodeToString(sum[:]) != pending.codeChallenge:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":            i.URL,
+			"sub":            i.Subject,
+			"aud":            pending.clientID,
+			"nonce":          pending.nonce,
+			"email":          i.Email,
+			"email_verified": i.EmailVerified,
+			"name":           i.Name,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		}
+		if i.Claims != nil {
+			i.Claims(claims)
+		}
+		signed, err := i.Sign(claims)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": code,
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     signed,
+		})
+	})
+
+	return mux
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeTitle ทำให้ชื่อหนังสือเทียบกันได้: ตัวพิมพ์เล็ก ตัดเครื่องหมายวรรคตอนและช่องว่างออก
+// (เก็บสระและวรรณยุกต์ไทยไว้ เพราะเปลี่ยนความหมายของคำ)
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Similarity คืนค่าความคล้ายระหว่าง 0 ถึง 1 จาก Levenshtein distance เทียบกับความยาวของข้อความที่ยาวกว่า
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	longest := max(len(ra), len(rb))
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}